@@ -6,6 +6,8 @@ require (
 	github.com/duizendstra/go/google/errors v0.0.1
 	github.com/duizendstra/go/google/logging v0.0.1
 	golang.org/x/oauth2 v0.23.0
+	golang.org/x/sync v0.8.0
+	golang.org/x/time v0.6.0
 	google.golang.org/api v0.199.0
 )
 
@@ -34,3 +36,5 @@ require (
 	google.golang.org/grpc v1.67.1 // indirect
 	google.golang.org/protobuf v1.34.2 // indirect
 )
+
+replace github.com/duizendstra/go/google/logging => ../logging
@@ -0,0 +1,87 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package serviceaccount
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/duizendstra/go/google/errors"
+)
+
+// clockSkewThreshold is the offset from the token endpoint's clock beyond
+// which an invalid_grant response is attributed to clock skew.
+const clockSkewThreshold = 30 * time.Second
+
+// ErrClockSkew is matched by errors.Is for every *ClockSkewError.
+var ErrClockSkew = stderrors.New("clock skew")
+
+// ClockSkewError is returned when the token endpoint rejects the JWT with
+// invalid_grant and the local clock differs from the endpoint's Date header
+// by more than clockSkewThreshold, so the iat and exp claims are out of the
+// accepted window. Err is the *errors.GoogleAPIError of the response.
+type ClockSkewError struct {
+	// Offset is the endpoint's time minus the local time; positive when the
+	// local clock is behind.
+	Offset time.Duration
+	Err    error
+}
+
+func (e *ClockSkewError) Error() string {
+	return fmt.Sprintf("token exchange rejected, local clock is off by %s: %v", e.Offset, e.Err)
+}
+
+func (e *ClockSkewError) Unwrap() error { return e.Err }
+
+func (e *ClockSkewError) Is(target error) bool { return target == ErrClockSkew }
+
+// WithClockSkewCorrection retries the token exchange once when it fails with
+// a *ClockSkewError, with the iat and exp claims shifted by the measured
+// offset. The retry is logged as a WARNING; the host clock should still be
+// fixed.
+func WithClockSkewCorrection() Option {
+	return func(cfg *clientConfig) {
+		cfg.correctClockSkew = true
+	}
+}
+
+// clockSkewError wraps apiErr in a *ClockSkewError when the response is an
+// invalid_grant and its Date header is more than clockSkewThreshold from
+// local, the local time the response was received.
+func clockSkewError(apiErr *errors.GoogleAPIError, header http.Header, local time.Time) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal([]byte(apiErr.Body), &body) != nil || body.Error != "invalid_grant" {
+		return apiErr
+	}
+	date, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		return apiErr
+	}
+	offset := date.Sub(local.Truncate(time.Second))
+	if offset.Abs() <= clockSkewThreshold {
+		return apiErr
+	}
+	return &ClockSkewError{Offset: offset, Err: apiErr}
+}
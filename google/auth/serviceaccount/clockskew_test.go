@@ -0,0 +1,128 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package serviceaccount
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	logger "github.com/duizendstra/go/google/logging"
+)
+
+// skewedTokenServer is a token endpoint whose clock is ahead by offset and
+// that rejects JWTs not issued within a minute of its own time.
+func skewedTokenServer(offset time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now().Add(offset)
+		w.Header().Set("Date", now.UTC().Format(http.TimeFormat))
+		var claims JWTClaims
+		json.Unmarshal([]byte(r.FormValue("assertion")), &claims)
+		if d := now.Unix() - claims.Iat; d > 60 || d < -60 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant","error_description":"Invalid JWT: Token must be a short-lived token (60 minutes) and in a reasonable timeframe. Check your iat and exp values in the JWT claim."}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"skew_token","expires_in":3600}`))
+	}))
+}
+
+func TestClockSkewError(t *testing.T) {
+	ts := skewedTokenServer(10 * time.Minute)
+	defer ts.Close()
+
+	log := logger.NewStructuredLogger("test-project", "test-component", nil, &bytes.Buffer{})
+	_, err := NewHTTPClient(context.Background(), log, payloadIAMClient{}, "sa@test-project.iam.gserviceaccount.com", "user@example.com", "scope",
+		WithTokenURL(ts.URL))
+	if !errors.Is(err, ErrClockSkew) {
+		t.Fatalf("Expected ErrClockSkew, got %v", err)
+	}
+	var skewErr *ClockSkewError
+	errors.As(err, &skewErr)
+	if skewErr.Offset < 9*time.Minute || skewErr.Offset > 11*time.Minute {
+		t.Errorf("Expected an offset of about 10m, got %s", skewErr.Offset)
+	}
+}
+
+func TestWithClockSkewCorrection(t *testing.T) {
+	ts := skewedTokenServer(-10 * time.Minute)
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	log := logger.NewStructuredLogger("test-project", "test-component", nil, &buf)
+	_, err := NewHTTPClient(context.Background(), log, payloadIAMClient{}, "sa@test-project.iam.gserviceaccount.com", "user@example.com", "scope",
+		WithTokenURL(ts.URL), WithClockSkewCorrection())
+	if err != nil {
+		t.Fatalf("NewHTTPClient returned unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "retrying with corrected iat") {
+		t.Errorf("Expected a WARNING about the correction, got %s", buf.String())
+	}
+}
+
+func TestClockSkewCorrectionWithReducedScopes(t *testing.T) {
+	skewed := skewedTokenServer(-10 * time.Minute)
+	defer skewed.Close()
+	var requested []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var claims JWTClaims
+		json.Unmarshal([]byte(r.FormValue("assertion")), &claims)
+		requested = append(requested, claims.Scope)
+		if strings.Contains(claims.Scope, "scope-b") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_scope","error_description":"Invalid scope."}`))
+			return
+		}
+		skewed.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	log := logger.NewStructuredLogger("test-project", "test-component", nil, &bytes.Buffer{})
+	_, err := NewHTTPClient(context.Background(), log, payloadIAMClient{}, "sa@test-project.iam.gserviceaccount.com", "user@example.com", "scope-a scope-b",
+		WithTokenURL(ts.URL), WithReducedScopes("scope-a"), WithClockSkewCorrection())
+	if err != nil {
+		t.Fatalf("NewHTTPClient returned unexpected error: %v", err)
+	}
+	// The skew retry repeats the reduced exchange that failed.
+	if len(requested) != 3 || requested[2] != "scope-a" {
+		t.Errorf("Expected the clock skew retry to use the reduced scopes, got %v", requested)
+	}
+}
+
+func TestInvalidGrantWithoutSkew(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant","error_description":"Not a valid email or user ID."}`))
+	}))
+	defer ts.Close()
+
+	log := logger.NewStructuredLogger("test-project", "test-component", nil, &bytes.Buffer{})
+	_, err := NewHTTPClient(context.Background(), log, payloadIAMClient{}, "sa@test-project.iam.gserviceaccount.com", "user@example.com", "scope",
+		WithTokenURL(ts.URL))
+	if err == nil || errors.Is(err, ErrClockSkew) {
+		t.Errorf("Expected a plain invalid_grant error, got %v", err)
+	}
+}
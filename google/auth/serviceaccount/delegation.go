@@ -0,0 +1,161 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package serviceaccount
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/duizendstra/go/google/errors"
+	structured "github.com/duizendstra/go/google/logging"
+)
+
+// Reasons of a DelegationDiagnosis.
+const (
+	// DelegationOK means a token was minted for all scopes.
+	DelegationOK = "ok"
+	// DelegationNotConfigured means the service account is not authorized for
+	// domain-wide delegation of any of the scopes in the Admin console.
+	DelegationNotConfigured = "delegation_not_configured"
+	// DelegationScopeNotGranted means delegation is configured, but not for
+	// the scopes listed in MissingScopes.
+	DelegationScopeNotGranted = "scope_not_granted"
+	// DelegationInvalidScope means a scope is not a valid OAuth2 scope.
+	DelegationInvalidScope = "invalid_scope"
+	// DelegationSubjectUnavailable means the subject does not exist, is
+	// suspended, or is not in a domain the delegation covers.
+	DelegationSubjectUnavailable = "subject_unavailable"
+	// DelegationClockSkew means the token endpoint rejected the JWT for the
+	// local clock, see ClockSkewError.
+	DelegationClockSkew = "clock_skew"
+	// DelegationSigningFailed means the IAM call to sign the JWT failed,
+	// typically for a missing roles/iam.serviceAccountTokenCreator.
+	DelegationSigningFailed = "signing_failed"
+	// DelegationUnknown is any other failure; see Detail.
+	DelegationUnknown = "unknown"
+)
+
+// DelegationDiagnosis is the machine-readable result of CheckDelegation.
+type DelegationDiagnosis struct {
+	Reason  string `json:"reason"`
+	Subject string `json:"subject"`
+	// MissingScopes are the scopes that could not be delegated, set for
+	// DelegationScopeNotGranted.
+	MissingScopes []string `json:"missing_scopes,omitempty"`
+	// Detail is the error of the failed mint, empty for DelegationOK.
+	Detail string `json:"detail,omitempty"`
+	// Err is the error of the failed mint.
+	Err error `json:"-"`
+}
+
+// OK reports whether delegation works for the subject and scopes.
+func (d DelegationDiagnosis) OK() bool {
+	return d.Reason == DelegationOK
+}
+
+// CheckDelegation checks, for onboarding flows, whether targetServiceAccount
+// can mint tokens for subject with the space-separated scopes. It mints a
+// token for all scopes and, when the exchange is rejected as unauthorized,
+// one for each scope, to tell a missing delegation from missing scopes. The
+// tokens are discarded. The failed mints are not logged; the diagnosis is
+// logged once, at WARNING unless it is DelegationOK.
+func CheckDelegation(ctx context.Context, logger structured.Logger, iamClient IAMServiceClient, targetServiceAccount, subject, scopes string, opts ...Option) DelegationDiagnosis {
+	cfg := newClientConfig(opts)
+	quiet := structured.FromSlog(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	probe := func(scopes string) (string, error) {
+		assertion, err := createJWTAssertion(time.Now().Add(cfg.clockOffset), targetServiceAccount, subject, scopes)
+		if err != nil {
+			return DelegationUnknown, err
+		}
+		signed, err := iamClient.SignJwt(ctx, "projects/-/serviceAccounts/"+targetServiceAccount, assertion)
+		if err != nil {
+			return DelegationSigningFailed, err
+		}
+		if _, _, err := getAccessToken(quiet, cfg.tokenHTTPClient(), cfg.tokenURL, signed.SignedJwt); err != nil {
+			return classifyTokenError(err), err
+		}
+		return DelegationOK, nil
+	}
+
+	diagnosis := DelegationDiagnosis{Subject: subject}
+	diagnosis.Reason, diagnosis.Err = probe(scopes)
+	if diagnosis.Err != nil {
+		diagnosis.Detail = diagnosis.Err.Error()
+	}
+	if diagnosis.Reason == DelegationNotConfigured {
+		requested := strings.Fields(scopes)
+		var missing []string
+		for _, scope := range requested {
+			if reason, _ := probe(scope); reason != DelegationOK {
+				missing = append(missing, scope)
+			}
+		}
+		if len(missing) < len(requested) {
+			diagnosis.Reason, diagnosis.MissingScopes = DelegationScopeNotGranted, missing
+		}
+	}
+
+	if diagnosis.OK() {
+		logger.LogInfo(ctx, "Delegation check passed", "subject", subject, "scopes", scopes)
+	} else {
+		logger.LogWarning(ctx, "Delegation check failed", "subject", subject, "scopes", scopes,
+			"reason", diagnosis.Reason, "missing_scopes", diagnosis.MissingScopes, "error", diagnosis.Err)
+	}
+	return diagnosis
+}
+
+// classifyTokenError maps an error of the token exchange to a delegation reason.
+func classifyTokenError(err error) string {
+	if stderrors.Is(err, ErrClockSkew) {
+		return DelegationClockSkew
+	}
+	code, description, _ := tokenError(err)
+	switch {
+	case code == "unauthorized_client":
+		return DelegationNotConfigured
+	case code == "invalid_scope":
+		return DelegationInvalidScope
+	case code == "invalid_grant" && strings.Contains(strings.ToLower(description), "email or user id"):
+		return DelegationSubjectUnavailable
+	}
+	return DelegationUnknown
+}
+
+// tokenError returns the OAuth2 error code and description of a token
+// endpoint rejection.
+func tokenError(err error) (code, description string, ok bool) {
+	var apiErr *errors.GoogleAPIError
+	if !stderrors.As(err, &apiErr) {
+		return "", "", false
+	}
+	var body struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if json.Unmarshal([]byte(apiErr.Body), &body) != nil || body.Error == "" {
+		return "", "", false
+	}
+	return body.Error, body.ErrorDescription, true
+}
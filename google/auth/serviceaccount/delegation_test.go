@@ -0,0 +1,112 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package serviceaccount
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	logger "github.com/duizendstra/go/google/logging"
+	"google.golang.org/api/iam/v1"
+)
+
+// delegationServer is a token endpoint for a domain where delegation covers
+// the granted scopes and the users in users.
+func delegationServer(granted []string, users ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var claims JWTClaims
+		json.Unmarshal([]byte(r.FormValue("assertion")), &claims)
+		known := false
+		for _, u := range users {
+			known = known || u == claims.Sub
+		}
+		if !known {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant","error_description":"Invalid email or User ID"}`))
+			return
+		}
+		for _, scope := range strings.Fields(claims.Scope) {
+			ok := false
+			for _, g := range granted {
+				ok = ok || g == scope
+			}
+			if !ok {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error":"unauthorized_client","error_description":"Client is unauthorized to retrieve access tokens using this method, or client not authorized for any of the scopes requested."}`))
+				return
+			}
+		}
+		w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+	}))
+}
+
+type failingIAMClient struct{}
+
+func (failingIAMClient) SignJwt(ctx context.Context, name string, payload string) (*iam.SignJwtResponse, error) {
+	return nil, errors.New("permission iam.serviceAccounts.signJwt denied")
+}
+
+func TestCheckDelegation(t *testing.T) {
+	tests := []struct {
+		name            string
+		granted         []string
+		subject         string
+		iamClient       IAMServiceClient
+		expectedReason  string
+		expectedMissing []string
+	}{
+		{"Delegated", []string{"gmail", "drive"}, "user@example.com", payloadIAMClient{}, DelegationOK, nil},
+		{"Not configured", nil, "user@example.com", payloadIAMClient{}, DelegationNotConfigured, nil},
+		{"Scope not granted", []string{"gmail"}, "user@example.com", payloadIAMClient{}, DelegationScopeNotGranted, []string{"drive"}},
+		{"Unknown subject", []string{"gmail", "drive"}, "suspended@example.com", payloadIAMClient{}, DelegationSubjectUnavailable, nil},
+		{"Signing failed", []string{"gmail", "drive"}, "user@example.com", failingIAMClient{}, DelegationSigningFailed, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := delegationServer(tt.granted, "user@example.com")
+			defer ts.Close()
+
+			var buf bytes.Buffer
+			log := logger.NewStructuredLogger("test-project", "test-component", nil, &buf)
+			diagnosis := CheckDelegation(context.Background(), log, tt.iamClient, "sa@test-project.iam.gserviceaccount.com", tt.subject, "gmail drive",
+				WithTokenURL(ts.URL))
+
+			if diagnosis.Reason != tt.expectedReason {
+				t.Errorf("Expected reason %s, got %s (%s)", tt.expectedReason, diagnosis.Reason, diagnosis.Detail)
+			}
+			if strings.Join(diagnosis.MissingScopes, " ") != strings.Join(tt.expectedMissing, " ") {
+				t.Errorf("Expected missing scopes %v, got %v", tt.expectedMissing, diagnosis.MissingScopes)
+			}
+			if diagnosis.OK() != (tt.expectedReason == DelegationOK) || (diagnosis.Err == nil) != diagnosis.OK() {
+				t.Errorf("Expected OK and Err to agree with reason %s, got OK=%v Err=%v", diagnosis.Reason, diagnosis.OK(), diagnosis.Err)
+			}
+			if lines := strings.Count(buf.String(), "\n"); lines != 1 {
+				t.Errorf("Expected one log entry for the diagnosis, got %d: %s", lines, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package fakeauth provides a local fake of the Google OAuth2 and IAM
+// endpoints used by the serviceaccount package: SignJwt (IAM and IAM
+// Credentials), the JWT bearer token exchange, generateIdToken, and the JWKS
+// needed to verify the ID tokens it issues. Failure modes such as
+// invalid_grant or rate limiting can be injected per endpoint, so CI can
+// exercise the full auth stack without GCP credentials.
+package fakeauth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/option"
+)
+
+// keyID is the kid of the key the fake signs with.
+const keyID = "fakeauth-key"
+
+// Endpoint identifies a fake endpoint for failure injection and request counts.
+type Endpoint string
+
+const (
+	// EndpointSignJwt is projects.serviceAccounts.signJwt of IAM and IAM Credentials.
+	EndpointSignJwt Endpoint = "signJwt"
+	// EndpointToken is the OAuth2 token exchange.
+	EndpointToken Endpoint = "token"
+	// EndpointGenerateIDToken is projects.serviceAccounts.generateIdToken of IAM Credentials.
+	EndpointGenerateIDToken Endpoint = "generateIdToken"
+)
+
+// FailureMode is an error response the fake returns instead of succeeding.
+type FailureMode int
+
+const (
+	// InvalidGrant returns 400 with the OAuth2 invalid_grant error, as for a
+	// subject without domain-wide delegation.
+	InvalidGrant FailureMode = iota + 1
+	// RateLimited returns 429 RESOURCE_EXHAUSTED with a Retry-After of one second.
+	RateLimited
+	// PermissionDenied returns 403 PERMISSION_DENIED.
+	PermissionDenied
+	// Unavailable returns 503 UNAVAILABLE.
+	Unavailable
+)
+
+// Server is a fake Google OAuth2 and IAM server.
+type Server struct {
+	*httptest.Server
+
+	key *rsa.PrivateKey
+
+	mu       sync.Mutex
+	failures map[Endpoint][]FailureMode
+	requests map[Endpoint]int
+	issued   int
+}
+
+// NewServer starts a fake server. Close it when done.
+func NewServer() *Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(fmt.Sprintf("fakeauth: generating key: %v", err))
+	}
+	s := &Server{
+		key:      key,
+		failures: make(map[Endpoint][]FailureMode),
+		requests: make(map[Endpoint]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/projects/", s.handleServiceAccount)
+	mux.HandleFunc("POST /token", s.handleToken)
+	mux.HandleFunc("GET /certs", s.handleCerts)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// ClientOptions point the IAM and IAM Credentials clients at the fake, e.g.
+// for serviceaccount.GoogleIAMServiceClient.ClientOptions.
+func (s *Server) ClientOptions() []option.ClientOption {
+	return []option.ClientOption{option.WithEndpoint(s.URL + "/"), option.WithoutAuthentication()}
+}
+
+// TokenURL is the token endpoint, for serviceaccount.WithTokenURL.
+func (s *Server) TokenURL() string {
+	return s.URL + "/token"
+}
+
+// CertsURL is the JWKS endpoint, for serviceaccount.IDTokenVerifier.CertsURL.
+func (s *Server) CertsURL() string {
+	return s.URL + "/certs"
+}
+
+// Fail makes the next times requests to endpoint fail with mode.
+func (s *Server) Fail(endpoint Endpoint, mode FailureMode, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < times; i++ {
+		s.failures[endpoint] = append(s.failures[endpoint], mode)
+	}
+}
+
+// Requests returns the number of requests received by endpoint.
+func (s *Server) Requests(endpoint Endpoint) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[endpoint]
+}
+
+// begin counts a request and writes the next injected failure, if any.
+func (s *Server) begin(w http.ResponseWriter, endpoint Endpoint) bool {
+	s.mu.Lock()
+	s.requests[endpoint]++
+	var mode FailureMode
+	if queue := s.failures[endpoint]; len(queue) > 0 {
+		mode, s.failures[endpoint] = queue[0], queue[1:]
+	}
+	s.mu.Unlock()
+
+	switch mode {
+	case InvalidGrant:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "Not a valid email or user ID."})
+	case RateLimited:
+		w.Header().Set("Retry-After", "1")
+		writeGoogleError(w, http.StatusTooManyRequests, "RESOURCE_EXHAUSTED", "Quota exceeded.")
+	case PermissionDenied:
+		writeGoogleError(w, http.StatusForbidden, "PERMISSION_DENIED", "Permission 'iam.serviceAccounts.signJwt' denied.")
+	case Unavailable:
+		writeGoogleError(w, http.StatusServiceUnavailable, "UNAVAILABLE", "The service is currently unavailable.")
+	default:
+		return true
+	}
+	return false
+}
+
+// handleServiceAccount serves projects/-/serviceAccounts/{email}:signJwt and :generateIdToken.
+func (s *Server) handleServiceAccount(w http.ResponseWriter, r *http.Request) {
+	name, method, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/"), ":")
+	email := name[strings.LastIndex(name, "/")+1:]
+	if !ok || !strings.HasPrefix(name, "projects/-/serviceAccounts/") || email == "" {
+		writeGoogleError(w, http.StatusNotFound, "NOT_FOUND", "Unknown resource "+r.URL.Path)
+		return
+	}
+
+	switch method {
+	case "signJwt":
+		if !s.begin(w, EndpointSignJwt) {
+			return
+		}
+		var req struct {
+			Payload string `json:"payload"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !json.Valid([]byte(req.Payload)) {
+			writeGoogleError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "Payload must be a JSON object.")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"keyId": keyID, "signedJwt": s.sign([]byte(req.Payload))})
+	case "generateIdToken":
+		if !s.begin(w, EndpointGenerateIDToken) {
+			return
+		}
+		var req struct {
+			Audience     string `json:"audience"`
+			IncludeEmail bool   `json:"includeEmail"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Audience == "" {
+			writeGoogleError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "Audience is required.")
+			return
+		}
+		now := time.Now().Unix()
+		claims := map[string]any{
+			"iss": "https://accounts.google.com",
+			"aud": req.Audience,
+			"azp": email,
+			"sub": email,
+			"iat": now,
+			"exp": now + 3600,
+		}
+		if req.IncludeEmail {
+			claims["email"] = email
+			claims["email_verified"] = true
+		}
+		payload, _ := json.Marshal(claims)
+		writeJSON(w, http.StatusOK, map[string]string{"token": s.sign(payload)})
+	default:
+		writeGoogleError(w, http.StatusNotFound, "NOT_FOUND", "Unknown method "+method)
+	}
+}
+
+// handleToken exchanges a JWT signed by the fake for an access token.
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if !s.begin(w, EndpointToken) {
+		return
+	}
+	if r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+	if !s.verify(r.FormValue("assertion")) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "Invalid JWT signature."})
+		return
+	}
+
+	s.mu.Lock()
+	s.issued++
+	token := fmt.Sprintf("fakeauth-access-token-%d", s.issued)
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]any{"access_token": token, "token_type": "Bearer", "expires_in": 3600})
+}
+
+// handleCerts serves the JWKS with the fake's public key.
+func (s *Server) handleCerts(w http.ResponseWriter, r *http.Request) {
+	pub := s.key.PublicKey
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	writeJSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+		"kid": keyID,
+		"kty": "RSA",
+		"alg": "RS256",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}}})
+}
+
+// sign returns an RS256 JWT with the given claims payload.
+func (s *Server) sign(payload []byte) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": keyID})
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		panic(fmt.Sprintf("fakeauth: signing JWT: %v", err))
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// verify reports whether jwt was signed by the fake.
+func (s *Server) verify(jwt string) bool {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	return rsa.VerifyPKCS1v15(&s.key.PublicKey, crypto.SHA256, digest[:], signature) == nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeGoogleError writes an error in the google.rpc.Status JSON shape.
+func writeGoogleError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]any{"error": map[string]any{"code": status, "status": code, "message": message}})
+}
@@ -0,0 +1,144 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package fakeauth_test
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"testing"
+
+	"github.com/duizendstra/go/google/auth/serviceaccount"
+	"github.com/duizendstra/go/google/auth/serviceaccount/fakeauth"
+	"github.com/duizendstra/go/google/errors"
+	logger "github.com/duizendstra/go/google/logging"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/iamcredentials/v1"
+)
+
+const serviceAccount = "robot@test-project.iam.gserviceaccount.com"
+
+func newClient(t *testing.T, fake *fakeauth.Server, iamClient serviceaccount.IAMServiceClient) (*http.Client, error) {
+	t.Helper()
+	logger := logger.NewStructuredLogger("test-project", "test-component", nil, nil)
+	return serviceaccount.NewHTTPClient(context.Background(), logger, iamClient, serviceAccount, "user@example.com", "https://www.googleapis.com/auth/gmail.readonly",
+		serviceaccount.WithTokenURL(fake.TokenURL()))
+}
+
+func accessToken(t *testing.T, client *http.Client) string {
+	t.Helper()
+	token, err := client.Transport.(*oauth2.Transport).Source.Token()
+	if err != nil {
+		t.Fatalf("Token returned unexpected error: %v", err)
+	}
+	return token.AccessToken
+}
+
+func TestFullAuthStack(t *testing.T) {
+	fake := fakeauth.NewServer()
+	defer fake.Close()
+
+	for _, iamClient := range []serviceaccount.IAMServiceClient{
+		&serviceaccount.GoogleIAMServiceClient{ClientOptions: fake.ClientOptions()},
+		&serviceaccount.GoogleIAMCredentialsClient{ClientOptions: fake.ClientOptions(), Delegates: []string{"hop@test-project.iam.gserviceaccount.com"}},
+	} {
+		client, err := newClient(t, fake, iamClient)
+		if err != nil {
+			t.Fatalf("NewHTTPClient returned unexpected error: %v", err)
+		}
+		if token := accessToken(t, client); token == "" {
+			t.Errorf("Expected an access token from the fake")
+		}
+	}
+	if fake.Requests(fakeauth.EndpointSignJwt) != 2 || fake.Requests(fakeauth.EndpointToken) != 2 {
+		t.Errorf("Expected 2 signJwt and 2 token requests, got %d and %d", fake.Requests(fakeauth.EndpointSignJwt), fake.Requests(fakeauth.EndpointToken))
+	}
+}
+
+func TestFailureModes(t *testing.T) {
+	fake := fakeauth.NewServer()
+	defer fake.Close()
+	iamClient := &serviceaccount.GoogleIAMServiceClient{ClientOptions: fake.ClientOptions()}
+
+	fake.Fail(fakeauth.EndpointToken, fakeauth.InvalidGrant, 1)
+	_, err := newClient(t, fake, iamClient)
+	var apiErr *errors.GoogleAPIError
+	if !stderrors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected a 400 GoogleAPIError for invalid_grant, got %v", err)
+	}
+
+	fake.Fail(fakeauth.EndpointSignJwt, fakeauth.RateLimited, 1)
+	if _, err := newClient(t, fake, iamClient); err == nil {
+		t.Fatalf("Expected an error when SignJwt is rate limited")
+	}
+
+	if _, err := newClient(t, fake, iamClient); err != nil {
+		t.Errorf("Expected the fake to recover after the injected failures, got %v", err)
+	}
+}
+
+func TestGenerateIDToken(t *testing.T) {
+	fake := fakeauth.NewServer()
+	defer fake.Close()
+	ctx := context.Background()
+
+	service, err := iamcredentials.NewService(ctx, fake.ClientOptions()...)
+	if err != nil {
+		t.Fatalf("NewService returned unexpected error: %v", err)
+	}
+	resp, err := service.Projects.ServiceAccounts.GenerateIdToken("projects/-/serviceAccounts/"+serviceAccount,
+		&iamcredentials.GenerateIdTokenRequest{Audience: "https://my-service.run.app", IncludeEmail: true}).Context(ctx).Do()
+	if err != nil {
+		t.Fatalf("GenerateIdToken returned unexpected error: %v", err)
+	}
+
+	verifier := &serviceaccount.IDTokenVerifier{CertsURL: fake.CertsURL()}
+	claims, err := verifier.Verify(ctx, resp.Token, "https://my-service.run.app")
+	if err != nil {
+		t.Fatalf("Verify returned unexpected error: %v", err)
+	}
+	if claims.Email != serviceAccount || !claims.EmailVerified {
+		t.Errorf("Expected the service account email claim, got %+v", claims)
+	}
+}
+
+func TestIDTokenCacheWithIAMCredentials(t *testing.T) {
+	fake := fakeauth.NewServer()
+	defer fake.Close()
+	ctx := context.Background()
+
+	cache := serviceaccount.NewIDTokenCache(&serviceaccount.IAMCredentialsIDTokenMinter{
+		ServiceAccount: serviceAccount,
+		ClientOptions:  fake.ClientOptions(),
+	})
+	for i := 0; i < 3; i++ {
+		token, err := cache.Token(ctx, "https://my-service.run.app")
+		if err != nil {
+			t.Fatalf("Token returned unexpected error: %v", err)
+		}
+		verifier := &serviceaccount.IDTokenVerifier{CertsURL: fake.CertsURL()}
+		if _, err := verifier.Verify(ctx, token, "https://my-service.run.app"); err != nil {
+			t.Fatalf("Verify returned unexpected error: %v", err)
+		}
+	}
+	if n := fake.Requests(fakeauth.EndpointGenerateIDToken); n != 1 {
+		t.Errorf("Expected 1 generateIdToken call, got %d", n)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package serviceaccount
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/option"
+)
+
+func TestGoogleIAMCredentialsClientSignJwt(t *testing.T) {
+	var gotPath string
+	var gotRequest struct {
+		Delegates []string `json:"delegates"`
+		Payload   string   `json:"payload"`
+	}
+
+	// Create a test HTTP server to mock the IAM Credentials API
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&gotRequest); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"keyId": "key-1", "signedJwt": "mocked_signed_jwt"})
+	}))
+	defer ts.Close()
+
+	client := &GoogleIAMCredentialsClient{
+		Delegates:     []string{"sa-a@test-project.iam.gserviceaccount.com", "projects/-/serviceAccounts/sa-b@test-project.iam.gserviceaccount.com"},
+		ClientOptions: []option.ClientOption{option.WithEndpoint(ts.URL), option.WithoutAuthentication()},
+	}
+
+	resp, err := client.SignJwt(context.Background(), "projects/-/serviceAccounts/target@test-project.iam.gserviceaccount.com", `{"sub":"user@example.com"}`)
+	if err != nil {
+		t.Fatalf("SignJwt returned unexpected error: %v", err)
+	}
+
+	if resp.SignedJwt != "mocked_signed_jwt" || resp.KeyId != "key-1" {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+	if gotPath != "/v1/projects/-/serviceAccounts/target@test-project.iam.gserviceaccount.com:signJwt" {
+		t.Errorf("Unexpected request path: %s", gotPath)
+	}
+	expectedDelegates := []string{
+		"projects/-/serviceAccounts/sa-a@test-project.iam.gserviceaccount.com",
+		"projects/-/serviceAccounts/sa-b@test-project.iam.gserviceaccount.com",
+	}
+	if !reflect.DeepEqual(gotRequest.Delegates, expectedDelegates) {
+		t.Errorf("Expected delegates %v, got %v", expectedDelegates, gotRequest.Delegates)
+	}
+	if gotRequest.Payload != `{"sub":"user@example.com"}` {
+		t.Errorf("Unexpected payload: %s", gotRequest.Payload)
+	}
+}
@@ -0,0 +1,289 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package serviceaccount
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// GoogleCertsURL is the JWKS endpoint with the keys Google uses to sign ID tokens.
+const GoogleCertsURL = "https://www.googleapis.com/oauth2/v3/certs"
+
+// DefaultClockSkew is the clock skew tolerated when checking iat and exp.
+const DefaultClockSkew = 5 * time.Minute
+
+// defaultCertsMaxAge is used when the JWKS response has no Cache-Control max-age.
+const defaultCertsMaxAge = time.Hour
+
+// minCertsRefreshInterval is the shortest time between two JWKS fetches for
+// tokens with an unknown key ID, so forged key IDs cannot force a fetch per
+// request.
+const minCertsRefreshInterval = time.Minute
+
+// certsFetchTimeout bounds a JWKS fetch, which is shared by all waiting
+// verifications and so does not use the context of any of them.
+const certsFetchTimeout = 10 * time.Second
+
+// googleIssuers are the accepted values of the iss claim.
+var googleIssuers = map[string]bool{
+	"accounts.google.com":         true,
+	"https://accounts.google.com": true,
+}
+
+// IDTokenClaims holds the claims of a verified Google-issued ID token.
+type IDTokenClaims struct {
+	Iss           string `json:"iss"`
+	Sub           string `json:"sub"`
+	Aud           string `json:"aud"`
+	Azp           string `json:"azp"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Iat           int64  `json:"iat"`
+	Exp           int64  `json:"exp"`
+}
+
+// IDTokenVerifier verifies Google-issued ID tokens locally against the
+// published JWKS, caching the keys for as long as Google allows.
+type IDTokenVerifier struct {
+	// CertsURL is the JWKS endpoint. Defaults to GoogleCertsURL.
+	CertsURL string
+	// ClockSkew is the tolerance applied to iat and exp. Defaults to DefaultClockSkew.
+	ClockSkew time.Duration
+	// HTTPClient fetches the JWKS. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	mu      sync.Mutex
+	keys    map[string]*rsa.PublicKey
+	expiry  time.Time
+	fetched time.Time
+	refresh singleflight.Group
+}
+
+var defaultIDTokenVerifier = &IDTokenVerifier{}
+
+// VerifyIDToken verifies the signature, issuer, audience, and lifetime of a
+// Google-issued ID token and returns its claims.
+func VerifyIDToken(ctx context.Context, token, audience string) (*IDTokenClaims, error) {
+	return defaultIDTokenVerifier.Verify(ctx, token, audience)
+}
+
+// Verify verifies the signature, issuer, audience, and lifetime of a
+// Google-issued ID token and returns its claims.
+func (v *IDTokenVerifier) Verify(ctx context.Context, token, audience string) (*IDTokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid ID token: expected 3 segments, got %d", len(parts))
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid ID token header: %w", err)
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("invalid ID token: unsupported algorithm %q", header.Alg)
+	}
+
+	var claims IDTokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid ID token payload: %w", err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token signature encoding: %w", err)
+	}
+
+	key, err := v.publicKey(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, fmt.Errorf("invalid ID token signature: %w", err)
+	}
+
+	if !googleIssuers[claims.Iss] {
+		return nil, fmt.Errorf("invalid ID token: unexpected issuer %q", claims.Iss)
+	}
+	if claims.Aud != audience {
+		return nil, fmt.Errorf("invalid ID token: audience %q does not match %q", claims.Aud, audience)
+	}
+
+	skew := v.ClockSkew
+	if skew == 0 {
+		skew = DefaultClockSkew
+	}
+	now := time.Now()
+	if now.Add(-skew).Unix() > claims.Exp {
+		return nil, fmt.Errorf("invalid ID token: expired at %s", time.Unix(claims.Exp, 0).UTC().Format(time.RFC3339))
+	}
+	if now.Add(skew).Unix() < claims.Iat {
+		return nil, fmt.Errorf("invalid ID token: issued in the future at %s", time.Unix(claims.Iat, 0).UTC().Format(time.RFC3339))
+	}
+
+	return &claims, nil
+}
+
+// publicKey returns the key with the given ID, refreshing the cached JWKS when
+// it has expired or does not contain the key (Google rotates keys regularly).
+// A key ID that is still unknown within minCertsRefreshInterval of the last
+// fetch is rejected without fetching again.
+func (v *IDTokenVerifier) publicKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	key, ok := v.keys[kid]
+	fresh := time.Now().Before(v.expiry)
+	recent := time.Since(v.fetched) < minCertsRefreshInterval
+	v.mu.Unlock()
+
+	switch {
+	case ok && fresh:
+		return key, nil
+	case fresh && recent:
+		return nil, fmt.Errorf("invalid ID token: unknown key ID %q", kid)
+	}
+
+	ch := v.refresh.DoChan("", func() (interface{}, error) {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), certsFetchTimeout)
+		defer cancel()
+		return nil, v.refreshKeys(fetchCtx)
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+	}
+
+	v.mu.Lock()
+	key, ok = v.keys[kid]
+	v.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("invalid ID token: unknown key ID %q", kid)
+	}
+	return key, nil
+}
+
+var reMaxAge = regexp.MustCompile(`max-age=(\d+)`)
+
+// refreshKeys downloads the JWKS without holding v.mu, which it only takes
+// to record the fetch and store the keys.
+func (v *IDTokenVerifier) refreshKeys(ctx context.Context) error {
+	v.mu.Lock()
+	v.fetched = time.Now()
+	v.mu.Unlock()
+
+	certsURL := v.CertsURL
+	if certsURL == "" {
+		certsURL = GoogleCertsURL
+	}
+	client := v.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", certsURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating JWKS request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error fetching JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("JWKS request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return fmt.Errorf("error decoding JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return fmt.Errorf("error decoding modulus of key %q: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return fmt.Errorf("error decoding exponent of key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	maxAge := defaultCertsMaxAge
+	if m := reMaxAge.FindStringSubmatch(resp.Header.Get("Cache-Control")); m != nil {
+		if seconds, err := strconv.Atoi(m[1]); err == nil {
+			maxAge = time.Duration(seconds) * time.Second
+		}
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.expiry = time.Now().Add(maxAge)
+	v.mu.Unlock()
+	return nil
+}
+
+// decodeSegment decodes a base64url-encoded JSON JWT segment into v.
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
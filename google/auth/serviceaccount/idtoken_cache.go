@@ -0,0 +1,273 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package serviceaccount
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/api/iamcredentials/v1"
+	"google.golang.org/api/option"
+)
+
+// DefaultIDTokenRefreshAhead is how long before expiry a cached ID token is refreshed.
+const DefaultIDTokenRefreshAhead = 5 * time.Minute
+
+// idTokenMintTimeout bounds a mint, which is shared by the waiting callers
+// and the background refresh and so does not use the context of any of them.
+const idTokenMintTimeout = 30 * time.Second
+
+// metadataIdentityURL is the metadata server endpoint minting ID tokens for
+// the default service account.
+const metadataIdentityURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/identity"
+
+// IDTokenMinter mints a Google-signed ID token for an audience.
+type IDTokenMinter interface {
+	MintIDToken(ctx context.Context, audience string) (string, error)
+}
+
+// IAMCredentialsIDTokenMinter mints ID tokens for a service account with the
+// IAM Credentials generateIdToken method.
+type IAMCredentialsIDTokenMinter struct {
+	// ServiceAccount is the email of the account the tokens are minted for.
+	ServiceAccount string
+	// Delegates is the delegation chain, as for GoogleIAMCredentialsClient.
+	Delegates []string
+	// ClientOptions are passed to the IAM Credentials service.
+	ClientOptions []option.ClientOption
+}
+
+// MintIDToken calls generateIdToken with the email claim included.
+func (m *IAMCredentialsIDTokenMinter) MintIDToken(ctx context.Context, audience string) (string, error) {
+	opts := append([]option.ClientOption{option.WithScopes(iamcredentials.CloudPlatformScope)}, m.ClientOptions...)
+	service, err := iamcredentials.NewService(ctx, opts...)
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize IAM Credentials service: %w", err)
+	}
+	resp, err := service.Projects.ServiceAccounts.GenerateIdToken(delegateNames([]string{m.ServiceAccount})[0], &iamcredentials.GenerateIdTokenRequest{
+		Audience:     audience,
+		Delegates:    delegateNames(m.Delegates),
+		IncludeEmail: true,
+	}).Context(ctx).Do()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate ID token for %s: %w", audience, err)
+	}
+	return resp.Token, nil
+}
+
+// MetadataIDTokenMinter mints ID tokens for the default service account of
+// the Cloud Run service or GCE instance from the metadata server.
+type MetadataIDTokenMinter struct {
+	// URL is the identity endpoint. Defaults to the metadata server.
+	URL string
+	// HTTPClient calls the metadata server. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// MintIDToken requests a token in the full format, which includes the email claim.
+func (m *MetadataIDTokenMinter) MintIDToken(ctx context.Context, audience string) (string, error) {
+	endpoint := m.URL
+	if endpoint == "" {
+		endpoint = metadataIdentityURL
+	}
+	client := m.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	query := url.Values{"audience": {audience}, "format": {"full"}}
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating identity request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error fetching ID token for %s: %w", audience, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading ID token for %s: %w", audience, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("identity request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// IDTokenCache caches ID tokens per audience, so a service calling many
+// Cloud Run URLs does not pay a metadata server or IAM round trip per request.
+// A token within RefreshAhead of its expiry is still returned while a new one
+// is minted in the background. It is safe for concurrent use.
+type IDTokenCache struct {
+	// Minter mints the tokens.
+	Minter IDTokenMinter
+	// RefreshAhead defaults to DefaultIDTokenRefreshAhead.
+	RefreshAhead time.Duration
+
+	mu     sync.Mutex
+	tokens map[string]*cachedIDToken
+	// mints shares one mint per audience between callers and refreshes.
+	mints singleflight.Group
+}
+
+// cachedIDToken is the token of one audience.
+type cachedIDToken struct {
+	token      string
+	expiry     time.Time
+	refreshing bool
+}
+
+// NewIDTokenCache returns a cache of the tokens minted by minter.
+func NewIDTokenCache(minter IDTokenMinter) *IDTokenCache {
+	return &IDTokenCache{Minter: minter}
+}
+
+// Token returns a valid ID token for audience, minting one when none is cached.
+func (c *IDTokenCache) Token(ctx context.Context, audience string) (string, error) {
+	entry := c.entry(audience)
+	now := time.Now()
+
+	c.mu.Lock()
+	token, expiry := entry.token, entry.expiry
+	refresh := token != "" && now.Before(expiry) && now.Add(c.refreshAhead()).After(expiry) && !entry.refreshing
+	if refresh {
+		entry.refreshing = true
+	}
+	c.mu.Unlock()
+
+	switch {
+	case refresh:
+		go c.refresh(ctx, audience, entry)
+		return token, nil
+	case token != "" && now.Before(expiry):
+		return token, nil
+	}
+
+	ch := c.mints.DoChan(audience, func() (interface{}, error) {
+		// Another caller may have minted the token while this one waited.
+		c.mu.Lock()
+		token, expiry := entry.token, entry.expiry
+		c.mu.Unlock()
+		if token != "" && time.Now().Before(expiry) {
+			return token, nil
+		}
+		return c.mint(ctx, audience, entry)
+	})
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return "", res.Err
+		}
+		return res.Val.(string), nil
+	}
+}
+
+// Transport returns a RoundTripper that authenticates each request with an ID
+// token for the scheme and host of the request URL, the audience Cloud Run
+// expects. base defaults to http.DefaultTransport.
+func (c *IDTokenCache) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &idTokenTransport{cache: c, base: base}
+}
+
+// refresh mints a new token in the background, keeping the old one on failure.
+func (c *IDTokenCache) refresh(ctx context.Context, audience string, entry *cachedIDToken) {
+	c.mints.Do(audience, func() (interface{}, error) {
+		return c.mint(ctx, audience, entry)
+	})
+	c.mu.Lock()
+	entry.refreshing = false
+	c.mu.Unlock()
+}
+
+// mint mints and stores a token, bounded by idTokenMintTimeout instead of
+// the cancellation of ctx. It runs inside c.mints.
+func (c *IDTokenCache) mint(ctx context.Context, audience string, entry *cachedIDToken) (string, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idTokenMintTimeout)
+	defer cancel()
+	token, err := c.Minter.MintIDToken(ctx, audience)
+	if err != nil {
+		return "", err
+	}
+	var claims IDTokenClaims
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || decodeSegment(parts[1], &claims) != nil || claims.Exp == 0 {
+		return "", fmt.Errorf("invalid ID token for %s: missing exp claim", audience)
+	}
+
+	c.mu.Lock()
+	entry.token = token
+	entry.expiry = time.Unix(claims.Exp, 0)
+	c.mu.Unlock()
+	return token, nil
+}
+
+// entry returns the cache entry of audience, creating it as needed.
+func (c *IDTokenCache) entry(audience string) *cachedIDToken {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tokens == nil {
+		c.tokens = make(map[string]*cachedIDToken)
+	}
+	entry, ok := c.tokens[audience]
+	if !ok {
+		entry = &cachedIDToken{}
+		c.tokens[audience] = entry
+	}
+	return entry
+}
+
+func (c *IDTokenCache) refreshAhead() time.Duration {
+	if c.RefreshAhead > 0 {
+		return c.RefreshAhead
+	}
+	return DefaultIDTokenRefreshAhead
+}
+
+// idTokenTransport adds an ID token for the request URL to every request.
+type idTokenTransport struct {
+	cache *IDTokenCache
+	base  http.RoundTripper
+}
+
+func (t *idTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	audience := req.URL.Scheme + "://" + req.URL.Host
+	token, err := t.cache.Token(req.Context(), audience)
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(req)
+}
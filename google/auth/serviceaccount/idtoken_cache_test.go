@@ -0,0 +1,183 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package serviceaccount
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockIDTokenMinter mints unsigned tokens with the configured lifetime.
+type mockIDTokenMinter struct {
+	mu       sync.Mutex
+	lifetime time.Duration
+	mints    map[string]int
+}
+
+func (m *mockIDTokenMinter) MintIDToken(ctx context.Context, audience string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.mints == nil {
+		m.mints = make(map[string]int)
+	}
+	m.mints[audience]++
+	payload := fmt.Sprintf(`{"aud":%q,"exp":%d,"n":%d}`, audience, time.Now().Add(m.lifetime).Unix(), m.mints[audience])
+	return "e30." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".sig", nil
+}
+
+func (m *mockIDTokenMinter) count(audience string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mints[audience]
+}
+
+func TestIDTokenCachePerAudience(t *testing.T) {
+	minter := &mockIDTokenMinter{lifetime: time.Hour}
+	cache := NewIDTokenCache(minter)
+	ctx := context.Background()
+
+	first, err := cache.Token(ctx, "https://a.run.app")
+	if err != nil {
+		t.Fatalf("Token returned unexpected error: %v", err)
+	}
+	second, _ := cache.Token(ctx, "https://a.run.app")
+	other, _ := cache.Token(ctx, "https://b.run.app")
+
+	if first != second {
+		t.Errorf("Expected the cached token to be reused")
+	}
+	if other == first {
+		t.Errorf("Expected a separate token per audience")
+	}
+	if minter.count("https://a.run.app") != 1 || minter.count("https://b.run.app") != 1 {
+		t.Errorf("Expected one mint per audience, got %v", minter.mints)
+	}
+}
+
+func TestIDTokenCacheRefreshAhead(t *testing.T) {
+	minter := &mockIDTokenMinter{lifetime: 2 * time.Minute}
+	cache := NewIDTokenCache(minter)
+	ctx := context.Background()
+
+	first, _ := cache.Token(ctx, "https://a.run.app")
+	// The token is within the refresh window: it is returned while a new one is minted.
+	stale, _ := cache.Token(ctx, "https://a.run.app")
+	if stale != first {
+		t.Errorf("Expected the still valid token to be returned during the refresh")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for minter.count("https://a.run.app") < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if minter.count("https://a.run.app") != 2 {
+		t.Fatalf("Expected a background refresh, got %d mints", minter.count("https://a.run.app"))
+	}
+}
+
+func TestIDTokenCacheExpired(t *testing.T) {
+	minter := &mockIDTokenMinter{lifetime: -time.Minute}
+	cache := NewIDTokenCache(minter)
+
+	cache.Token(context.Background(), "https://a.run.app")
+	cache.Token(context.Background(), "https://a.run.app")
+	if minter.count("https://a.run.app") != 2 {
+		t.Errorf("Expected an expired token to be minted again, got %d mints", minter.count("https://a.run.app"))
+	}
+}
+
+// blockingIDTokenMinter blocks every mint until release is closed.
+type blockingIDTokenMinter struct {
+	release chan struct{}
+}
+
+func (m *blockingIDTokenMinter) MintIDToken(ctx context.Context, audience string) (string, error) {
+	select {
+	case <-m.release:
+		return "", fmt.Errorf("released")
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func TestIDTokenCacheHungMinter(t *testing.T) {
+	minter := &blockingIDTokenMinter{release: make(chan struct{})}
+	defer close(minter.release)
+	cache := NewIDTokenCache(minter)
+
+	// Every waiter gives up with its own context while the shared mint hangs.
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		_, err := cache.Token(ctx, "https://a.run.app")
+		cancel()
+		if err != context.DeadlineExceeded {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	}
+}
+
+func TestIDTokenTransport(t *testing.T) {
+	var authorization string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer ts.Close()
+
+	minter := &mockIDTokenMinter{lifetime: time.Hour}
+	client := &http.Client{Transport: NewIDTokenCache(minter).Transport(nil)}
+	resp, err := client.Get(ts.URL + "/orders")
+	if err != nil {
+		t.Fatalf("Get returned unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if minter.count(ts.URL) != 1 {
+		t.Errorf("Expected a token for audience %s, got %v", ts.URL, minter.mints)
+	}
+	if len(authorization) < len("Bearer ") || authorization[:7] != "Bearer " {
+		t.Errorf("Expected a bearer token, got '%s'", authorization)
+	}
+}
+
+func TestMetadataIDTokenMinter(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" || r.URL.Query().Get("audience") != "https://a.run.app" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("header.payload.signature\n"))
+	}))
+	defer ts.Close()
+
+	minter := &MetadataIDTokenMinter{URL: ts.URL}
+	token, err := minter.MintIDToken(context.Background(), "https://a.run.app")
+	if err != nil {
+		t.Fatalf("MintIDToken returned unexpected error: %v", err)
+	}
+	if token != "header.payload.signature" {
+		t.Errorf("Expected the token from the metadata server, got '%s'", token)
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package serviceaccount
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// signTestIDToken creates an RS256-signed JWT for the given claims.
+func signTestIDToken(t *testing.T, key *rsa.PrivateKey, kid string, claims IDTokenClaims) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("Error signing token: %v", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+
+	// Create a test HTTP server to mock the Google JWKS endpoint
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		jwks := map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "test-key",
+				"kty": "RSA",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		}
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jwks)
+	}))
+	defer ts.Close()
+
+	verifier := &IDTokenVerifier{CertsURL: ts.URL, ClockSkew: time.Minute}
+	now := time.Now()
+	valid := IDTokenClaims{
+		Iss:   "https://accounts.google.com",
+		Sub:   "1234567890",
+		Aud:   "https://my-service.run.app",
+		Email: "caller@test-project.iam.gserviceaccount.com",
+		Iat:   now.Unix(),
+		Exp:   now.Add(time.Hour).Unix(),
+	}
+
+	tests := []struct {
+		name        string
+		kid         string
+		mutate      func(c *IDTokenClaims)
+		audience    string
+		expectedErr string
+	}{
+		{
+			name:     "Valid token",
+			kid:      "test-key",
+			audience: "https://my-service.run.app",
+		},
+		{
+			name:     "Expired within clock skew",
+			kid:      "test-key",
+			mutate:   func(c *IDTokenClaims) { c.Exp = now.Add(-30 * time.Second).Unix() },
+			audience: "https://my-service.run.app",
+		},
+		{
+			name:        "Expired beyond clock skew",
+			kid:         "test-key",
+			mutate:      func(c *IDTokenClaims) { c.Exp = now.Add(-2 * time.Minute).Unix() },
+			audience:    "https://my-service.run.app",
+			expectedErr: "invalid ID token: expired at",
+		},
+		{
+			name:        "Wrong audience",
+			kid:         "test-key",
+			audience:    "https://other-service.run.app",
+			expectedErr: "invalid ID token: audience",
+		},
+		{
+			name:        "Wrong issuer",
+			kid:         "test-key",
+			mutate:      func(c *IDTokenClaims) { c.Iss = "https://evil.example.com" },
+			audience:    "https://my-service.run.app",
+			expectedErr: "invalid ID token: unexpected issuer",
+		},
+		{
+			name:        "Unknown key",
+			kid:         "rotated-key",
+			audience:    "https://my-service.run.app",
+			expectedErr: "invalid ID token: unknown key ID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid
+			if tt.mutate != nil {
+				tt.mutate(&claims)
+			}
+			token := signTestIDToken(t, key, tt.kid, claims)
+
+			got, err := verifier.Verify(context.Background(), token, tt.audience)
+			if tt.expectedErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.expectedErr) {
+					t.Fatalf("Expected error starting with %q, got: %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify returned unexpected error: %v", err)
+			}
+			if got.Email != claims.Email {
+				t.Errorf("Expected email '%s', got '%s'", claims.Email, got.Email)
+			}
+		})
+	}
+
+	// All cases share the cached JWKS; the unknown key is rejected without a
+	// refresh because the keys were fetched less than a minute ago.
+	if requests != 1 {
+		t.Errorf("Expected 1 JWKS request, got %d", requests)
+	}
+
+	// Once the minimum interval has passed, an unknown key refreshes the JWKS once.
+	verifier.mu.Lock()
+	verifier.fetched = time.Now().Add(-2 * minCertsRefreshInterval)
+	verifier.mu.Unlock()
+	for i := 0; i < 3; i++ {
+		token := signTestIDToken(t, key, "rotated-key", valid)
+		if _, err := verifier.Verify(context.Background(), token, valid.Aud); err == nil {
+			t.Fatal("Expected an error for an unknown key")
+		}
+	}
+	if requests != 2 {
+		t.Errorf("Expected 2 JWKS requests, got %d", requests)
+	}
+}
+
+func TestVerifyIDTokenTampered(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "test-key",
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	defer ts.Close()
+
+	verifier := &IDTokenVerifier{CertsURL: ts.URL}
+	token := signTestIDToken(t, otherKey, "test-key", IDTokenClaims{
+		Iss: "accounts.google.com",
+		Aud: "aud",
+		Iat: time.Now().Unix(),
+		Exp: time.Now().Add(time.Hour).Unix(),
+	})
+
+	_, err := verifier.Verify(context.Background(), token, "aud")
+	if err == nil || !strings.HasPrefix(err.Error(), "invalid ID token signature") {
+		t.Fatalf("Expected signature error, got: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package serviceaccount
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/iam/v1"
+	"google.golang.org/api/option"
+)
+
+// defaultMTLSTokenURL is the token endpoint for clients presenting a certificate.
+const defaultMTLSTokenURL = "https://oauth2.mtls.googleapis.com/token"
+
+// Endpoints of the IAM APIs for clients presenting a certificate.
+const (
+	IAMMTLSEndpoint            = "https://iam.mtls.googleapis.com/"
+	IAMCredentialsMTLSEndpoint = "https://iamcredentials.mtls.googleapis.com/"
+)
+
+// defaultTokenSource finds the credentials of the clients built by
+// MTLSClientOptions. Tests replace it.
+var defaultTokenSource = google.DefaultTokenSource
+
+// CertificateSource returns the client certificate presented in mTLS
+// handshakes. It has the signature of option.ClientCertSource and is called
+// whenever a server requests a certificate, so it may return a rotated one.
+type CertificateSource = func(*tls.CertificateRequestInfo) (*tls.Certificate, error)
+
+// StaticCertificate returns a CertificateSource for a single certificate.
+func StaticCertificate(cert tls.Certificate) CertificateSource {
+	return func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return &cert, nil
+	}
+}
+
+// CertificateFromFiles loads a PEM certificate and private key, such as those
+// mounted from a secret, and returns them as a CertificateSource.
+func CertificateFromFiles(certFile, keyFile string) (CertificateSource, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading client certificate: %w", err)
+	}
+	return StaticCertificate(cert), nil
+}
+
+// WithClientCertificate presents the certificate from source when exchanging
+// the signed JWT, for organizations that enforce certificate-based access.
+// Unless WithTokenURL is given, the exchange then uses Google's mTLS token
+// endpoint. The IAM calls are configured separately, with MTLSClientOptions
+// in the ClientOptions of the IAM client.
+func WithClientCertificate(source CertificateSource) Option {
+	return func(cfg *clientConfig) {
+		cfg.clientCertificate = source
+		if cfg.tokenURL == defaultTokenURL {
+			cfg.tokenURL = defaultMTLSTokenURL
+		}
+	}
+}
+
+// WithRootCAs verifies the token endpoint against pool instead of the system
+// roots, for private token endpoints behind an internal CA.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(cfg *clientConfig) {
+		cfg.rootCAs = pool
+	}
+}
+
+// MTLSClientOptions returns the ClientOptions that make GoogleIAMServiceClient,
+// GoogleIAMCredentialsClient, or IAMCredentialsIDTokenMinter present the
+// certificate from source and call endpoint, IAMMTLSEndpoint or
+// IAMCredentialsMTLSEndpoint. The requests are authorized with the
+// application default credentials:
+//
+//	opts, err := serviceaccount.MTLSClientOptions(ctx, source, serviceaccount.IAMMTLSEndpoint)
+//	iamClient := &serviceaccount.GoogleIAMServiceClient{ClientOptions: opts}
+func MTLSClientOptions(ctx context.Context, source CertificateSource, endpoint string) ([]option.ClientOption, error) {
+	return mtlsClientOptions(ctx, source, endpoint, nil)
+}
+
+// mtlsClientOptions builds the HTTP client itself, because the API client
+// libraries ignore a certificate source unless
+// GOOGLE_API_USE_CLIENT_CERTIFICATE is set.
+func mtlsClientOptions(ctx context.Context, source CertificateSource, endpoint string, rootCAs *x509.CertPool) ([]option.ClientOption, error) {
+	tokens, err := defaultTokenSource(ctx, iam.CloudPlatformScope)
+	if err != nil {
+		return nil, fmt.Errorf("error finding default credentials: %w", err)
+	}
+	client := &http.Client{Transport: &oauth2.Transport{Source: tokens, Base: newTLSTransport(source, rootCAs)}}
+	return []option.ClientOption{option.WithHTTPClient(client), option.WithEndpoint(endpoint)}, nil
+}
+
+// newTLSTransport returns a transport presenting the certificate from source
+// and verifying servers against rootCAs, or the system roots when nil.
+func newTLSTransport(source CertificateSource, rootCAs *x509.CertPool) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		GetClientCertificate: source,
+		RootCAs:              rootCAs,
+		MinVersion:           tls.VersionTLS12,
+	}
+	return transport
+}
+
+// tokenHTTPClient returns the client for the token exchange. A client with a
+// certificate or custom roots is built once per configuration, so mints reuse
+// its connections.
+func (cfg *clientConfig) tokenHTTPClient() *http.Client {
+	if cfg.clientCertificate == nil && cfg.rootCAs == nil {
+		return http.DefaultClient
+	}
+	cfg.tokenClientOnce.Do(func() {
+		cfg.tokenClient = &http.Client{Transport: newTLSTransport(cfg.clientCertificate, cfg.rootCAs)}
+	})
+	return cfg.tokenClient
+}
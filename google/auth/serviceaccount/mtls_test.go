@@ -0,0 +1,149 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package serviceaccount
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	stdlog "log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	logger "github.com/duizendstra/go/google/logging"
+	"golang.org/x/oauth2"
+)
+
+// newClientCertificate returns a self-signed client certificate.
+func newClientCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "workload"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Error creating certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// mtlsTokenServer is a token endpoint that requires a client certificate.
+func mtlsTokenServer() *httptest.Server {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != "workload" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"access_token":"mtls_token","expires_in":3600}`))
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	ts.Config.ErrorLog = stdlog.New(io.Discard, "", 0)
+	ts.StartTLS()
+	return ts
+}
+
+func TestWithClientCertificate(t *testing.T) {
+	ts := mtlsTokenServer()
+	defer ts.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(ts.Certificate())
+
+	log := logger.NewStructuredLogger("test-project", "test-component", nil, &bytes.Buffer{})
+	_, err := NewHTTPClient(context.Background(), log, payloadIAMClient{}, "sa@test-project.iam.gserviceaccount.com", "user@example.com", "scope",
+		WithTokenURL(ts.URL), WithRootCAs(roots), WithClientCertificate(StaticCertificate(newClientCertificate(t))))
+	if err != nil {
+		t.Fatalf("NewHTTPClient returned unexpected error: %v", err)
+	}
+
+	_, err = NewHTTPClient(context.Background(), log, payloadIAMClient{}, "sa@test-project.iam.gserviceaccount.com", "user@example.com", "scope",
+		WithTokenURL(ts.URL), WithRootCAs(roots))
+	if err == nil {
+		t.Error("Expected the exchange without a certificate to fail")
+	}
+}
+
+func TestWithClientCertificateTokenURL(t *testing.T) {
+	source := StaticCertificate(tls.Certificate{})
+	if cfg := newClientConfig([]Option{WithClientCertificate(source)}); cfg.tokenURL != defaultMTLSTokenURL {
+		t.Errorf("Expected token URL %s, got %s", defaultMTLSTokenURL, cfg.tokenURL)
+	}
+	if cfg := newClientConfig([]Option{WithTokenURL("https://token.internal"), WithClientCertificate(source)}); cfg.tokenURL != "https://token.internal" {
+		t.Errorf("Expected the explicit token URL to be kept, got %s", cfg.tokenURL)
+	}
+}
+
+func TestMTLSClientOptions(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || r.Header.Get("Authorization") != "Bearer adc_token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"signedJwt":"signed"}`))
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	ts.Config.ErrorLog = stdlog.New(io.Discard, "", 0)
+	ts.StartTLS()
+	defer ts.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(ts.Certificate())
+
+	original := defaultTokenSource
+	defer func() { defaultTokenSource = original }()
+	defaultTokenSource = func(ctx context.Context, scope ...string) (oauth2.TokenSource, error) {
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "adc_token"}), nil
+	}
+
+	opts, err := mtlsClientOptions(context.Background(), StaticCertificate(newClientCertificate(t)), ts.URL+"/", roots)
+	if err != nil {
+		t.Fatalf("mtlsClientOptions returned unexpected error: %v", err)
+	}
+	iamClient := &GoogleIAMServiceClient{ClientOptions: opts}
+	resp, err := iamClient.SignJwt(context.Background(), "projects/-/serviceAccounts/sa", "{}")
+	if err != nil {
+		t.Fatalf("SignJwt returned unexpected error: %v", err)
+	}
+	if resp.SignedJwt != "signed" {
+		t.Errorf("Expected the signed JWT from the mTLS endpoint, got '%s'", resp.SignedJwt)
+	}
+}
+
+func TestTokenHTTPClientReused(t *testing.T) {
+	cfg := newClientConfig([]Option{WithClientCertificate(StaticCertificate(tls.Certificate{}))})
+	if first, second := cfg.tokenHTTPClient(), cfg.tokenHTTPClient(); first != second {
+		t.Error("Expected every mint to reuse the token exchange client")
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package serviceaccount
+
+import (
+	"crypto/x509"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultTokenURL is Google's OAuth2 token endpoint.
+const defaultTokenURL = "https://oauth2.googleapis.com/token"
+
+// Option configures NewHTTPClient.
+type Option func(*clientConfig)
+
+// clientConfig holds the settings applied by Options.
+type clientConfig struct {
+	tokenURL     string
+	quotaProject string
+	usageStats   *UsageStats
+	tokenCache   *fileTokenCache
+	mintLimiter  *MintLimiter
+	// reducedScopes are retried once when the requested scopes are rejected.
+	reducedScopes   string
+	onReducedScopes func(subject, requested, granted string)
+	// correctClockSkew retries once with clockOffset set from a ClockSkewError.
+	correctClockSkew bool
+	clockOffset      time.Duration
+	// clientCertificate and rootCAs configure TLS for the token exchange,
+	// whose client is built once into tokenClient.
+	clientCertificate CertificateSource
+	rootCAs           *x509.CertPool
+	tokenClient       *http.Client
+	tokenClientOnce   sync.Once
+}
+
+func newClientConfig(opts []Option) *clientConfig {
+	cfg := &clientConfig{tokenURL: defaultTokenURL}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithTokenURL overrides the OAuth2 token endpoint the signed JWT is exchanged at.
+func WithTokenURL(tokenURL string) Option {
+	return func(cfg *clientConfig) {
+		cfg.tokenURL = tokenURL
+	}
+}
+
+// WithQuotaProject sets the project that is billed for quota on requests made
+// with the generated client, via the x-goog-user-project header.
+func WithQuotaProject(project string) Option {
+	return func(cfg *clientConfig) {
+		cfg.quotaProject = project
+	}
+}
+
+// quotaProjectTransport adds the x-goog-user-project header to each request.
+type quotaProjectTransport struct {
+	base    http.RoundTripper
+	project string
+}
+
+func (t *quotaProjectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("X-Goog-User-Project", t.project)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package serviceaccount
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logger "github.com/duizendstra/go/google/logging"
+	"golang.org/x/oauth2"
+)
+
+func TestNewHTTPClientQuotaProject(t *testing.T) {
+	logger := logger.NewStructuredLogger("test-project", "test-component", nil, nil)
+
+	var quotaProject string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]string{"access_token": "mocked_access_token"})
+			return
+		}
+		quotaProject = r.Header.Get("X-Goog-User-Project")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	client, err := NewHTTPClient(context.Background(), logger, &MockIAMServiceClient{}, "test-service-account", "test-user@example.com", "test-scope",
+		WithTokenURL(ts.URL), WithQuotaProject("billing-project"))
+	if err != nil {
+		t.Fatalf("NewHTTPClient returned unexpected error: %v", err)
+	}
+
+	// The oauth2 transport must stay outermost so its token source stays reachable.
+	if _, ok := client.Transport.(*oauth2.Transport); !ok {
+		t.Fatalf("Expected *oauth2.Transport, got %T", client.Transport)
+	}
+
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("HTTP client returned error: %v", err)
+	}
+	resp.Body.Close()
+
+	if quotaProject != "billing-project" {
+		t.Errorf("Expected X-Goog-User-Project 'billing-project', got '%s'", quotaProject)
+	}
+}
+
+func TestGenerateGoogleClientOptions(t *testing.T) {
+	logger := logger.NewStructuredLogger("test-project", "test-component", nil, nil)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "mocked_access_token"})
+	}))
+	defer ts.Close()
+
+	opts, err := GenerateGoogleClientOptions(context.Background(), logger, &MockIAMServiceClient{}, "test-service-account", "test-user@example.com", "test-scope",
+		WithTokenURL(ts.URL), WithQuotaProject("billing-project"))
+	if err != nil {
+		t.Fatalf("GenerateGoogleClientOptions returned unexpected error: %v", err)
+	}
+	if len(opts) != 2 {
+		t.Errorf("Expected HTTP client and quota project options, got %d options", len(opts))
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package serviceaccount
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// maxIdleSubjects bounds the per-subject limiters kept before idle ones are dropped.
+const maxIdleSubjects = 10000
+
+// MintLimit configures a MintLimiter. A zero rate means no limit at that level.
+type MintLimit struct {
+	// Global is the number of mints per second allowed across all subjects.
+	Global float64
+	// GlobalBurst is the number of mints allowed at once globally. Defaults to 1.
+	GlobalBurst int
+	// PerSubject is the number of mints per second allowed for each subject.
+	PerSubject float64
+	// SubjectBurst is the number of mints allowed at once per subject. Defaults to 1.
+	SubjectBurst int
+	// Wait queues a mint until the limits allow it, bounded by the context,
+	// instead of failing fast with a *RateLimitError.
+	Wait bool
+}
+
+// MintLimiter caps delegation token mints per subject and globally, to stay
+// within Google's domain-wide delegation rate limits. Share one limiter
+// between all NewHTTPClient calls through WithMintLimiter.
+type MintLimiter struct {
+	limit  MintLimit
+	global *rate.Limiter
+
+	mu       sync.Mutex
+	subjects map[string]*rate.Limiter
+}
+
+// RateLimitError is returned by NewHTTPClient when a MintLimiter rejects a mint.
+type RateLimitError struct {
+	// Subject is the user the token was requested for.
+	Subject string
+	// Global is true when the global limit was exceeded rather than the per-subject one.
+	Global bool
+	// RetryAfter is how long until the mint would be allowed.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	scope := "subject " + e.Subject
+	if e.Global {
+		scope = "all subjects"
+	}
+	return fmt.Sprintf("token mint rate limit exceeded for %s, retry after %s", scope, e.RetryAfter)
+}
+
+// NewMintLimiter creates a limiter enforcing limit.
+func NewMintLimiter(limit MintLimit) *MintLimiter {
+	if limit.GlobalBurst <= 0 {
+		limit.GlobalBurst = 1
+	}
+	if limit.SubjectBurst <= 0 {
+		limit.SubjectBurst = 1
+	}
+	l := &MintLimiter{limit: limit, subjects: make(map[string]*rate.Limiter)}
+	if limit.Global > 0 {
+		l.global = rate.NewLimiter(rate.Limit(limit.Global), limit.GlobalBurst)
+	}
+	return l
+}
+
+// WithMintLimiter rate limits the token mints of NewHTTPClient. Tokens reused
+// from a token cache do not count against the limits.
+func WithMintLimiter(limiter *MintLimiter) Option {
+	return func(cfg *clientConfig) {
+		cfg.mintLimiter = limiter
+	}
+}
+
+// Allow waits for or reserves one mint for subject, depending on MintLimit.Wait.
+func (l *MintLimiter) Allow(ctx context.Context, subject string) error {
+	perSubject := l.subject(subject)
+
+	if l.limit.Wait {
+		if perSubject != nil {
+			if err := perSubject.Wait(ctx); err != nil {
+				return fmt.Errorf("waiting for token mint rate limit: %w", err)
+			}
+		}
+		if l.global != nil {
+			if err := l.global.Wait(ctx); err != nil {
+				return fmt.Errorf("waiting for token mint rate limit: %w", err)
+			}
+		}
+		return nil
+	}
+
+	now := time.Now()
+	var subjectReservation *rate.Reservation
+	if perSubject != nil {
+		subjectReservation = perSubject.ReserveN(now, 1)
+		if delay := subjectReservation.DelayFrom(now); delay > 0 {
+			subjectReservation.CancelAt(now)
+			return &RateLimitError{Subject: subject, RetryAfter: delay}
+		}
+	}
+	if l.global != nil {
+		globalReservation := l.global.ReserveN(now, 1)
+		if delay := globalReservation.DelayFrom(now); delay > 0 {
+			globalReservation.CancelAt(now)
+			if subjectReservation != nil {
+				subjectReservation.CancelAt(now)
+			}
+			return &RateLimitError{Subject: subject, Global: true, RetryAfter: delay}
+		}
+	}
+	return nil
+}
+
+// subject returns the limiter of a subject, or nil without a per-subject limit.
+func (l *MintLimiter) subject(subject string) *rate.Limiter {
+	if l.limit.PerSubject <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if limiter, ok := l.subjects[subject]; ok {
+		return limiter
+	}
+	if len(l.subjects) >= maxIdleSubjects {
+		// A limiter with a full bucket behaves like a new one, so it can be dropped.
+		for s, limiter := range l.subjects {
+			if limiter.Tokens() >= float64(l.limit.SubjectBurst) {
+				delete(l.subjects, s)
+			}
+		}
+	}
+	limiter := rate.NewLimiter(rate.Limit(l.limit.PerSubject), l.limit.SubjectBurst)
+	l.subjects[subject] = limiter
+	return limiter
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package serviceaccount
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	logger "github.com/duizendstra/go/google/logging"
+)
+
+func TestMintLimiterFailFast(t *testing.T) {
+	limiter := NewMintLimiter(MintLimit{PerSubject: 0.001, SubjectBurst: 2, Global: 0.001, GlobalBurst: 3})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := limiter.Allow(ctx, "a@example.com"); err != nil {
+			t.Fatalf("Expected mint %d to be allowed, got %v", i+1, err)
+		}
+	}
+
+	var rateErr *RateLimitError
+	err := limiter.Allow(ctx, "a@example.com")
+	if !errors.As(err, &rateErr) || rateErr.Global || rateErr.Subject != "a@example.com" || rateErr.RetryAfter <= 0 {
+		t.Fatalf("Expected a per-subject RateLimitError, got %v", err)
+	}
+
+	if err := limiter.Allow(ctx, "b@example.com"); err != nil {
+		t.Fatalf("Expected another subject to be allowed, got %v", err)
+	}
+	err = limiter.Allow(ctx, "c@example.com")
+	if !errors.As(err, &rateErr) || !rateErr.Global {
+		t.Fatalf("Expected a global RateLimitError, got %v", err)
+	}
+
+	// The rejected global mint must not consume c's per-subject allowance.
+	if tokens := limiter.subject("c@example.com").Tokens(); tokens < 1.9 {
+		t.Errorf("Expected the subject reservation to be returned, got %.2f tokens", tokens)
+	}
+}
+
+func TestMintLimiterWait(t *testing.T) {
+	limiter := NewMintLimiter(MintLimit{PerSubject: 20, Wait: true})
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Allow(ctx, "a@example.com"); err != nil {
+			t.Fatalf("Expected mint to wait, got %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("Expected the mints to be queued for at least 80ms, got %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := limiter.Allow(ctx, "a@example.com"); err == nil {
+		t.Errorf("Expected an error once the context is cancelled")
+	}
+}
+
+func TestNewHTTPClientMintLimiter(t *testing.T) {
+	logger := logger.NewStructuredLogger("test-project", "test-component", nil, nil)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "mocked_access_token"})
+	}))
+	defer ts.Close()
+
+	limiter := NewMintLimiter(MintLimit{PerSubject: 0.001})
+	newClient := func() error {
+		_, err := NewHTTPClient(context.Background(), logger, &MockIAMServiceClient{}, "test-service-account", "user@example.com", "test-scope",
+			WithTokenURL(ts.URL), WithMintLimiter(limiter))
+		return err
+	}
+
+	if err := newClient(); err != nil {
+		t.Fatalf("NewHTTPClient returned unexpected error: %v", err)
+	}
+	var rateErr *RateLimitError
+	if err := newClient(); !errors.As(err, &rateErr) {
+		t.Errorf("Expected a RateLimitError for the second mint, got %v", err)
+	}
+}
+
+func TestMintLimiterChargesRetries(t *testing.T) {
+	logger := logger.NewStructuredLogger("test-project", "test-component", nil, nil)
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_scope","error_description":"Invalid scope."}`))
+	}))
+	defer ts.Close()
+
+	limiter := NewMintLimiter(MintLimit{PerSubject: 0.001})
+	_, err := NewHTTPClient(context.Background(), logger, payloadIAMClient{}, "test-service-account", "user@example.com", "scope-a scope-b",
+		WithTokenURL(ts.URL), WithMintLimiter(limiter), WithReducedScopes("scope-a"))
+	var rateErr *RateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Errorf("Expected the reduced-scope retry to be rate limited, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected only the first exchange to reach the token endpoint, got %d", calls)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package serviceaccount
+
+// WithReducedScopes retries the token exchange once with the given
+// space-separated scopes when the token endpoint rejects the requested ones,
+// logging a WARNING that describes the downgrade. It helps migrations where
+// some customers have not yet granted newly required scopes for domain-wide
+// delegation. Callers must handle the missing permissions of the reduced set.
+func WithReducedScopes(scopes string) Option {
+	return func(cfg *clientConfig) {
+		cfg.reducedScopes = scopes
+	}
+}
+
+// WithReducedScopesHandler calls fn with the subject and both scope sets
+// whenever a token is minted with the reduced scopes of WithReducedScopes,
+// so the caller can disable the features that need the missing scopes.
+func WithReducedScopesHandler(fn func(subject, requested, granted string)) Option {
+	return func(cfg *clientConfig) {
+		cfg.onReducedScopes = fn
+	}
+}
+
+// isScopeError reports whether err is a token endpoint rejection of the
+// requested scopes: invalid_scope, or unauthorized_client, which Google
+// returns when domain-wide delegation does not cover all of the scopes.
+func isScopeError(err error) bool {
+	code, _, _ := tokenError(err)
+	return code == "invalid_scope" || code == "unauthorized_client"
+}
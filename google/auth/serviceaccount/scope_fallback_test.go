@@ -0,0 +1,135 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package serviceaccount
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	logger "github.com/duizendstra/go/google/logging"
+	"google.golang.org/api/iam/v1"
+)
+
+// payloadIAMClient "signs" a JWT by returning its payload, so the token
+// endpoint of a test can inspect the claims.
+type payloadIAMClient struct{}
+
+func (payloadIAMClient) SignJwt(ctx context.Context, name string, payload string) (*iam.SignJwtResponse, error) {
+	return &iam.SignJwtResponse{SignedJwt: payload}, nil
+}
+
+func TestWithReducedScopes(t *testing.T) {
+	var requested []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var claims JWTClaims
+		json.Unmarshal([]byte(r.FormValue("assertion")), &claims)
+		requested = append(requested, claims.Scope)
+		if strings.Contains(claims.Scope, "drive") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"unauthorized_client","error_description":"Client is unauthorized to retrieve access tokens using this method, or client not authorized for any of the scopes requested."}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"reduced_token","expires_in":3600}`))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	log := logger.NewStructuredLogger("test-project", "test-component", nil, &buf)
+	scopes := "https://www.googleapis.com/auth/gmail.readonly https://www.googleapis.com/auth/drive.readonly"
+	reduced := "https://www.googleapis.com/auth/gmail.readonly"
+
+	var downgrades []string
+	client, err := NewHTTPClient(context.Background(), log, payloadIAMClient{}, "sa@test-project.iam.gserviceaccount.com", "user@example.com", scopes,
+		WithTokenURL(ts.URL), WithReducedScopes(reduced),
+		WithReducedScopesHandler(func(subject, requestedScopes, granted string) {
+			downgrades = append(downgrades, subject+"|"+requestedScopes+"|"+granted)
+		}))
+	if err != nil {
+		t.Fatalf("NewHTTPClient returned unexpected error: %v", err)
+	}
+	if client == nil {
+		t.Fatal("Expected a client")
+	}
+	if len(requested) != 2 || requested[0] != scopes || requested[1] != reduced {
+		t.Errorf("Expected the full and then the reduced scopes to be requested, got %v", requested)
+	}
+	if len(downgrades) != 1 || downgrades[0] != "user@example.com|"+scopes+"|"+reduced {
+		t.Errorf("Expected the handler to be told about the downgrade, got %v", downgrades)
+	}
+	if !strings.Contains(buf.String(), "retrying with reduced scopes") || !strings.Contains(buf.String(), `"level":"WARN"`) {
+		t.Errorf("Expected a WARNING describing the downgrade, got %s", buf.String())
+	}
+}
+
+func TestWithReducedScopesNotCached(t *testing.T) {
+	var requested []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var claims JWTClaims
+		json.Unmarshal([]byte(r.FormValue("assertion")), &claims)
+		requested = append(requested, claims.Scope)
+		if strings.Contains(claims.Scope, "scope-b") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_scope","error_description":"Invalid scope."}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"reduced_token","expires_in":3600}`))
+	}))
+	defer ts.Close()
+
+	log := logger.NewStructuredLogger("test-project", "test-component", nil, nil)
+	path := filepath.Join(t.TempDir(), "tokens")
+	key := bytes.Repeat([]byte{7}, 32)
+	for i := 0; i < 2; i++ {
+		if _, err := NewHTTPClient(context.Background(), log, payloadIAMClient{}, "sa@test-project.iam.gserviceaccount.com", "user@example.com", "scope-a scope-b",
+			WithTokenURL(ts.URL), WithReducedScopes("scope-a"), WithTokenCacheFile(path, key)); err != nil {
+			t.Fatalf("NewHTTPClient returned unexpected error: %v", err)
+		}
+	}
+	// Each client asks for the full scopes again instead of reusing the reduced token.
+	if len(requested) != 4 || requested[2] != "scope-a scope-b" {
+		t.Errorf("Expected the reduced token not to be cached for the full scopes, got %v", requested)
+	}
+}
+
+func TestWithReducedScopesOtherErrors(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant","error_description":"Invalid JWT Signature."}`))
+	}))
+	defer ts.Close()
+
+	log := logger.NewStructuredLogger("test-project", "test-component", nil, nil)
+	_, err := NewHTTPClient(context.Background(), log, payloadIAMClient{}, "sa@test-project.iam.gserviceaccount.com", "user@example.com", "scope-a scope-b",
+		WithTokenURL(ts.URL), WithReducedScopes("scope-a"))
+	if err == nil {
+		t.Fatal("Expected an error for invalid_grant")
+	}
+	if calls != 1 {
+		t.Errorf("Expected no retry for errors other than scope errors, got %d calls", calls)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package admin provides the OAuth2 scopes of the Admin SDK Directory, Reports, and Data Transfer APIs.
+package admin
+
+import "github.com/duizendstra/go/google/auth/serviceaccount/scopes"
+
+// Scopes of the Admin SDK Directory, Reports, and Data Transfer APIs.
+const (
+	DirectoryUser                     scopes.Scope = scopes.Prefix + "admin.directory.user"
+	DirectoryUserReadonly             scopes.Scope = scopes.Prefix + "admin.directory.user.readonly"
+	DirectoryUserSecurity             scopes.Scope = scopes.Prefix + "admin.directory.user.security"
+	DirectoryUserAlias                scopes.Scope = scopes.Prefix + "admin.directory.user.alias"
+	DirectoryUserAliasReadonly        scopes.Scope = scopes.Prefix + "admin.directory.user.alias.readonly"
+	DirectoryGroup                    scopes.Scope = scopes.Prefix + "admin.directory.group"
+	DirectoryGroupReadonly            scopes.Scope = scopes.Prefix + "admin.directory.group.readonly"
+	DirectoryGroupMember              scopes.Scope = scopes.Prefix + "admin.directory.group.member"
+	DirectoryGroupMemberReadonly      scopes.Scope = scopes.Prefix + "admin.directory.group.member.readonly"
+	DirectoryOrgunit                  scopes.Scope = scopes.Prefix + "admin.directory.orgunit"
+	DirectoryOrgunitReadonly          scopes.Scope = scopes.Prefix + "admin.directory.orgunit.readonly"
+	DirectoryDomain                   scopes.Scope = scopes.Prefix + "admin.directory.domain"
+	DirectoryDomainReadonly           scopes.Scope = scopes.Prefix + "admin.directory.domain.readonly"
+	DirectoryCustomer                 scopes.Scope = scopes.Prefix + "admin.directory.customer"
+	DirectoryCustomerReadonly         scopes.Scope = scopes.Prefix + "admin.directory.customer.readonly"
+	DirectoryDeviceChromeOS           scopes.Scope = scopes.Prefix + "admin.directory.device.chromeos"
+	DirectoryDeviceChromeOSReadonly   scopes.Scope = scopes.Prefix + "admin.directory.device.chromeos.readonly"
+	DirectoryDeviceMobile             scopes.Scope = scopes.Prefix + "admin.directory.device.mobile"
+	DirectoryDeviceMobileReadonly     scopes.Scope = scopes.Prefix + "admin.directory.device.mobile.readonly"
+	DirectoryResourceCalendar         scopes.Scope = scopes.Prefix + "admin.directory.resource.calendar"
+	DirectoryResourceCalendarReadonly scopes.Scope = scopes.Prefix + "admin.directory.resource.calendar.readonly"
+	DirectoryRoleManagement           scopes.Scope = scopes.Prefix + "admin.directory.rolemanagement"
+	DirectoryRoleManagementReadonly   scopes.Scope = scopes.Prefix + "admin.directory.rolemanagement.readonly"
+	DirectoryUserSchema               scopes.Scope = scopes.Prefix + "admin.directory.userschema"
+	DirectoryUserSchemaReadonly       scopes.Scope = scopes.Prefix + "admin.directory.userschema.readonly"
+	ReportsAuditReadonly              scopes.Scope = scopes.Prefix + "admin.reports.audit.readonly"
+	ReportsUsageReadonly              scopes.Scope = scopes.Prefix + "admin.reports.usage.readonly"
+	DataTransfer                      scopes.Scope = scopes.Prefix + "admin.datatransfer"
+	DataTransferReadonly              scopes.Scope = scopes.Prefix + "admin.datatransfer.readonly"
+)
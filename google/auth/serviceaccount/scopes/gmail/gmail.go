@@ -0,0 +1,39 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package gmail provides the OAuth2 scopes of the Gmail API.
+package gmail
+
+import "github.com/duizendstra/go/google/auth/serviceaccount/scopes"
+
+// Scopes of the Gmail API.
+const (
+	// Full grants full access to the mailbox, including permanent deletion.
+	Full            scopes.Scope = "https://mail.google.com/"
+	Readonly        scopes.Scope = scopes.Prefix + "gmail.readonly"
+	Metadata        scopes.Scope = scopes.Prefix + "gmail.metadata"
+	Modify          scopes.Scope = scopes.Prefix + "gmail.modify"
+	Compose         scopes.Scope = scopes.Prefix + "gmail.compose"
+	Send            scopes.Scope = scopes.Prefix + "gmail.send"
+	Insert          scopes.Scope = scopes.Prefix + "gmail.insert"
+	Labels          scopes.Scope = scopes.Prefix + "gmail.labels"
+	SettingsBasic   scopes.Scope = scopes.Prefix + "gmail.settings.basic"
+	SettingsSharing scopes.Scope = scopes.Prefix + "gmail.settings.sharing"
+)
@@ -0,0 +1,178 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package scopes provides typed OAuth2 scope constants for the common Google
+// Workspace APIs, with helpers to compose and validate them. The constants
+// live in one subpackage per API, e.g. gmail.Readonly or
+// admin.DirectoryUserReadonly.
+package scopes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Prefix is the common prefix of Google API scope URLs.
+const Prefix = "https://www.googleapis.com/auth/"
+
+// Scope is an OAuth2 scope URL.
+type Scope string
+
+// Scopes that are not specific to one Workspace API.
+const (
+	CloudPlatform Scope = Prefix + "cloud-platform"
+	UserinfoEmail Scope = Prefix + "userinfo.email"
+	OpenID        Scope = "openid"
+)
+
+// String returns the scope URL.
+func (s Scope) String() string {
+	return string(s)
+}
+
+// Join returns the scopes as the space-separated string expected by
+// serviceaccount.NewHTTPClient, without duplicates and in the given order.
+func Join(scopes ...Scope) string {
+	seen := make(map[Scope]bool, len(scopes))
+	parts := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		parts = append(parts, string(s))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Parse splits a space- or comma-separated scope string and validates each scope.
+func Parse(s string) ([]Scope, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' || r == '\n' || r == '\t' })
+	scopes := make([]Scope, 0, len(fields))
+	for _, f := range fields {
+		scopes = append(scopes, Scope(f))
+	}
+	if err := Validate(scopes...); err != nil {
+		return nil, err
+	}
+	return scopes, nil
+}
+
+// Validate returns an error listing the scopes that are not known Google
+// scope URLs, catching typos before they surface as an opaque invalid_scope
+// error from the token endpoint.
+func Validate(scopes ...Scope) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	var unknown []string
+	for _, s := range scopes {
+		if !Known(s) {
+			unknown = append(unknown, fmt.Sprintf("%q", s))
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown scopes: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// Known reports whether s is a known Google scope URL.
+func Known(s Scope) bool {
+	return known[s]
+}
+
+// All returns the known scopes, sorted.
+func All() []Scope {
+	all := make([]Scope, 0, len(known))
+	for s := range known {
+		all = append(all, s)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+	return all
+}
+
+// known lists the scope URLs accepted by Validate.
+var known = map[Scope]bool{
+	CloudPlatform: true,
+	UserinfoEmail: true,
+	OpenID:        true,
+
+	// Gmail
+	"https://mail.google.com/":        true,
+	Prefix + "gmail.readonly":         true,
+	Prefix + "gmail.metadata":         true,
+	Prefix + "gmail.modify":           true,
+	Prefix + "gmail.compose":          true,
+	Prefix + "gmail.send":             true,
+	Prefix + "gmail.insert":           true,
+	Prefix + "gmail.labels":           true,
+	Prefix + "gmail.settings.basic":   true,
+	Prefix + "gmail.settings.sharing": true,
+
+	// Admin SDK
+	Prefix + "admin.directory.user":                       true,
+	Prefix + "admin.directory.user.readonly":              true,
+	Prefix + "admin.directory.user.security":              true,
+	Prefix + "admin.directory.user.alias":                 true,
+	Prefix + "admin.directory.user.alias.readonly":        true,
+	Prefix + "admin.directory.group":                      true,
+	Prefix + "admin.directory.group.readonly":             true,
+	Prefix + "admin.directory.group.member":               true,
+	Prefix + "admin.directory.group.member.readonly":      true,
+	Prefix + "admin.directory.orgunit":                    true,
+	Prefix + "admin.directory.orgunit.readonly":           true,
+	Prefix + "admin.directory.domain":                     true,
+	Prefix + "admin.directory.domain.readonly":            true,
+	Prefix + "admin.directory.customer":                   true,
+	Prefix + "admin.directory.customer.readonly":          true,
+	Prefix + "admin.directory.device.chromeos":            true,
+	Prefix + "admin.directory.device.chromeos.readonly":   true,
+	Prefix + "admin.directory.device.mobile":              true,
+	Prefix + "admin.directory.device.mobile.readonly":     true,
+	Prefix + "admin.directory.resource.calendar":          true,
+	Prefix + "admin.directory.resource.calendar.readonly": true,
+	Prefix + "admin.directory.rolemanagement":             true,
+	Prefix + "admin.directory.rolemanagement.readonly":    true,
+	Prefix + "admin.directory.userschema":                 true,
+	Prefix + "admin.directory.userschema.readonly":        true,
+	Prefix + "admin.reports.audit.readonly":               true,
+	Prefix + "admin.reports.usage.readonly":               true,
+	Prefix + "admin.datatransfer":                         true,
+	Prefix + "admin.datatransfer.readonly":                true,
+
+	// Drive
+	Prefix + "drive":                   true,
+	Prefix + "drive.readonly":          true,
+	Prefix + "drive.file":              true,
+	Prefix + "drive.appdata":           true,
+	Prefix + "drive.metadata":          true,
+	Prefix + "drive.metadata.readonly": true,
+	Prefix + "drive.activity":          true,
+	Prefix + "drive.activity.readonly": true,
+
+	// Calendar
+	Prefix + "calendar":                   true,
+	Prefix + "calendar.readonly":          true,
+	Prefix + "calendar.events":            true,
+	Prefix + "calendar.events.readonly":   true,
+	Prefix + "calendar.settings.readonly": true,
+}
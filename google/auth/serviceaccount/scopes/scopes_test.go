@@ -0,0 +1,99 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package scopes_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/duizendstra/go/google/auth/serviceaccount/scopes"
+	"github.com/duizendstra/go/google/auth/serviceaccount/scopes/admin"
+	"github.com/duizendstra/go/google/auth/serviceaccount/scopes/calendar"
+	"github.com/duizendstra/go/google/auth/serviceaccount/scopes/drive"
+	"github.com/duizendstra/go/google/auth/serviceaccount/scopes/gmail"
+)
+
+func TestJoin(t *testing.T) {
+	got := scopes.Join(gmail.Readonly, admin.DirectoryUserReadonly, gmail.Readonly, drive.Readonly)
+	expected := "https://www.googleapis.com/auth/gmail.readonly https://www.googleapis.com/auth/admin.directory.user.readonly https://www.googleapis.com/auth/drive.readonly"
+	if got != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := scopes.Validate(gmail.Send, calendar.EventsReadonly, scopes.CloudPlatform); err != nil {
+		t.Errorf("Expected known scopes to validate, got %v", err)
+	}
+
+	err := scopes.Validate(gmail.Readonly, "https://www.googleapis.com/auth/admin.directory.users.readonly")
+	if err == nil || !strings.Contains(err.Error(), "admin.directory.users.readonly") {
+		t.Errorf("Expected an error naming the misspelled scope, got %v", err)
+	}
+
+	if err := scopes.Validate(); err == nil {
+		t.Errorf("Expected an error without scopes")
+	}
+}
+
+func TestParse(t *testing.T) {
+	got, err := scopes.Parse("https://www.googleapis.com/auth/gmail.readonly, https://www.googleapis.com/auth/drive.file")
+	if err != nil {
+		t.Fatalf("Parse returned unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0] != gmail.Readonly || got[1] != drive.File {
+		t.Errorf("Unexpected scopes: %v", got)
+	}
+
+	if _, err := scopes.Parse("https://www.googleapis.com/auth/gmail.read"); err == nil {
+		t.Errorf("Expected an error for an unknown scope")
+	}
+}
+
+func TestSubpackageConstantsAreKnown(t *testing.T) {
+	all := []scopes.Scope{
+		gmail.Full, gmail.Readonly, gmail.Metadata, gmail.Modify, gmail.Compose, gmail.Send,
+		gmail.Insert, gmail.Labels, gmail.SettingsBasic, gmail.SettingsSharing,
+
+		admin.DirectoryUser, admin.DirectoryUserReadonly, admin.DirectoryUserSecurity,
+		admin.DirectoryUserAlias, admin.DirectoryUserAliasReadonly, admin.DirectoryGroup,
+		admin.DirectoryGroupReadonly, admin.DirectoryGroupMember, admin.DirectoryGroupMemberReadonly,
+		admin.DirectoryOrgunit, admin.DirectoryOrgunitReadonly, admin.DirectoryDomain,
+		admin.DirectoryDomainReadonly, admin.DirectoryCustomer, admin.DirectoryCustomerReadonly,
+		admin.DirectoryDeviceChromeOS, admin.DirectoryDeviceChromeOSReadonly, admin.DirectoryDeviceMobile,
+		admin.DirectoryDeviceMobileReadonly, admin.DirectoryResourceCalendar, admin.DirectoryResourceCalendarReadonly,
+		admin.DirectoryRoleManagement, admin.DirectoryRoleManagementReadonly, admin.DirectoryUserSchema,
+		admin.DirectoryUserSchemaReadonly, admin.ReportsAuditReadonly, admin.ReportsUsageReadonly,
+		admin.DataTransfer, admin.DataTransferReadonly,
+
+		drive.Full, drive.Readonly, drive.File, drive.AppData, drive.Metadata, drive.MetadataReadonly,
+		drive.Activity, drive.ActivityReadonly,
+
+		calendar.Full, calendar.Readonly, calendar.Events, calendar.EventsReadonly, calendar.SettingsReadonly,
+	}
+	for _, s := range all {
+		if !scopes.Known(s) {
+			t.Errorf("Expected %s to be known", s)
+		}
+	}
+	if expected := len(all) + 3; len(scopes.All()) != expected {
+		t.Errorf("Expected %d known scopes, got %d", expected, len(scopes.All()))
+	}
+}
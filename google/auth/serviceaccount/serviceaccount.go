@@ -22,6 +22,7 @@ package serviceaccount
 import (
 	"context"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -41,11 +42,15 @@ type IAMServiceClient interface {
 }
 
 // GoogleIAMServiceClient is an implementation of IAMServiceClient that talks to the real IAM service.
-type GoogleIAMServiceClient struct{}
+type GoogleIAMServiceClient struct {
+	// ClientOptions are passed to the IAM service, e.g. to point it at a fake server.
+	ClientOptions []option.ClientOption
+}
 
 // SignJwt creates a signed JWT by calling Google's IAM service.
 func (c *GoogleIAMServiceClient) SignJwt(ctx context.Context, name string, payload string) (*iam.SignJwtResponse, error) {
-	iamService, err := iam.NewService(ctx, option.WithScopes(iam.CloudPlatformScope))
+	opts := append([]option.ClientOption{option.WithScopes(iam.CloudPlatformScope)}, c.ClientOptions...)
+	iamService, err := iam.NewService(ctx, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize IAM service: %w", err)
 	}
@@ -63,42 +68,187 @@ type JWTClaims struct {
 }
 
 // GenerateGoogleHTTPClient creates an authenticated HTTP client for GCP services.
-func GenerateGoogleHTTPClient(ctx context.Context, logger *structured.StructuredLogger, iamClient IAMServiceClient, targetServiceAccount, userEmail, scopes string, tokenURL ...string) (*http.Client, error) {
-	jwtAssertion, err := createJWTAssertion(targetServiceAccount, userEmail, scopes)
+func GenerateGoogleHTTPClient(ctx context.Context, logger structured.Logger, iamClient IAMServiceClient, targetServiceAccount, userEmail, scopes string, tokenURL ...string) (*http.Client, error) {
+	var opts []Option
+	if len(tokenURL) > 0 {
+		opts = append(opts, WithTokenURL(tokenURL[0]))
+	}
+	return NewHTTPClient(ctx, logger, iamClient, targetServiceAccount, userEmail, scopes, opts...)
+}
+
+// NewHTTPClient creates an authenticated HTTP client for GCP services, configured by opts.
+// The client mints a new access token when its token expires, so it can be
+// kept for longer than the one-hour lifetime of a token; mints after the
+// first one use the values of ctx but not its cancellation.
+func NewHTTPClient(ctx context.Context, logger structured.Logger, iamClient IAMServiceClient, targetServiceAccount, userEmail, scopes string, opts ...Option) (*http.Client, error) {
+	cfg := newClientConfig(opts)
+	minter := &tokenMinter{
+		ctx:                  context.WithoutCancel(ctx),
+		logger:               logger,
+		iamClient:            iamClient,
+		cfg:                  cfg,
+		targetServiceAccount: targetServiceAccount,
+		userEmail:            userEmail,
+		scopes:               scopes,
+	}
+
+	var token *oauth2.Token
+	if cfg.tokenCache != nil {
+		minter.cacheKey = tokenCacheKey(targetServiceAccount, userEmail, scopes, cfg.tokenURL)
+		token, _ = cfg.tokenCache.load(ctx, logger, minter.cacheKey)
+	}
+	if token == nil {
+		var err error
+		if token, err = minter.mint(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return newTokenClient(ctx, cfg, userEmail, oauth2.ReuseTokenSource(token, minter)), nil
+}
+
+// tokenMinter mints the access tokens of a delegation. As the token source
+// of a client, it mints again when the token of the client expires.
+type tokenMinter struct {
+	ctx                  context.Context
+	logger               structured.Logger
+	iamClient            IAMServiceClient
+	cfg                  *clientConfig
+	targetServiceAccount string
+	userEmail            string
+	scopes               string
+	cacheKey             string
+}
+
+// Token implements oauth2.TokenSource.
+func (m *tokenMinter) Token() (*oauth2.Token, error) {
+	return m.mint(m.ctx)
+}
+
+// mint mints an access token, applying the mint limiter, the scope and
+// clock skew retries, and the token cache of the configuration.
+func (m *tokenMinter) mint(ctx context.Context) (*oauth2.Token, error) {
+	logger, cfg, userEmail, scopes := m.logger, m.cfg, m.userEmail, m.scopes
+	// attemptScopes are the scopes of the last exchange, which a clock skew
+	// retry repeats.
+	attemptScopes, reduced := scopes, false
+	accessToken, expiresIn, err := m.exchange(ctx, attemptScopes)
+	if err != nil && cfg.reducedScopes != "" && isScopeError(err) {
+		logger.LogWarning(ctx, "Requested scopes rejected, retrying with reduced scopes",
+			"subject", userEmail, "scopes", scopes, "reduced_scopes", cfg.reducedScopes, "error", err)
+		attemptScopes, reduced = cfg.reducedScopes, true
+		accessToken, expiresIn, err = m.exchange(ctx, attemptScopes)
+	}
+	var skewErr *ClockSkewError
+	if err != nil && cfg.correctClockSkew && stderrors.As(err, &skewErr) {
+		logger.LogWarning(ctx, "Token exchange rejected for clock skew, retrying with corrected iat",
+			"subject", userEmail, "offset", skewErr.Offset.String())
+		cfg.clockOffset = skewErr.Offset
+		accessToken, expiresIn, err = m.exchange(ctx, attemptScopes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.usageStats != nil {
+		cfg.usageStats.recordMint(userEmail)
+	}
+	if reduced && cfg.onReducedScopes != nil {
+		cfg.onReducedScopes(userEmail, scopes, cfg.reducedScopes)
+	}
+	token := &oauth2.Token{AccessToken: accessToken, TokenType: "Bearer"}
+	if expiresIn > 0 {
+		token.Expiry = time.Now().Add(expiresIn)
+		// A reduced-scope token is not cached under the key of the requested
+		// scopes, so a later client tries the full scopes again.
+		if cfg.tokenCache != nil && !reduced {
+			cfg.tokenCache.store(ctx, logger, m.cacheKey, accessToken, token.Expiry)
+		}
+	}
+	return token, nil
+}
+
+// exchange charges one mint to the mint limiter and mints a token for scopes,
+// so the scope and clock skew retries are limited like the first attempt.
+func (m *tokenMinter) exchange(ctx context.Context, scopes string) (string, time.Duration, error) {
+	if m.cfg.mintLimiter != nil {
+		if err := m.cfg.mintLimiter.Allow(ctx, m.userEmail); err != nil {
+			m.logger.LogWarning(ctx, "Token mint rate limited", "subject", m.userEmail, "error", err)
+			return "", 0, err
+		}
+	}
+	return mintAccessToken(ctx, m.logger, m.iamClient, m.cfg, m.targetServiceAccount, m.userEmail, scopes)
+}
+
+// mintAccessToken signs a JWT assertion for userEmail with the IAM client and
+// exchanges it for an access token.
+func mintAccessToken(ctx context.Context, logger structured.Logger, iamClient IAMServiceClient, cfg *clientConfig, targetServiceAccount, userEmail, scopes string) (string, time.Duration, error) {
+	jwtAssertion, err := createJWTAssertion(time.Now().Add(cfg.clockOffset), targetServiceAccount, userEmail, scopes)
 	if err != nil {
 		logger.LogError(ctx, "Error creating JWT assertion", "error", err)
-		return nil, fmt.Errorf("error creating JWT assertion: %w", err)
+		return "", 0, fmt.Errorf("error creating JWT assertion: %w", err)
 	}
 
 	name := "projects/-/serviceAccounts/" + targetServiceAccount
 	signJwtResponse, err := iamClient.SignJwt(ctx, name, jwtAssertion)
 	if err != nil {
 		logger.LogError(ctx, "Error signing JWT", "error", err)
-		return nil, fmt.Errorf("error signing JWT: %w", err)
-	}
-	
-	tokenUrl := "https://oauth2.googleapis.com/token"
-	if len(tokenURL) > 0 {
-		tokenUrl = tokenURL[0]
+		return "", 0, fmt.Errorf("error signing JWT: %w", err)
 	}
-	
-	accessToken, err := getAccessToken(logger, tokenUrl, signJwtResponse.SignedJwt)
+
+	accessToken, expiresIn, err := getAccessToken(logger, cfg.tokenHTTPClient(), cfg.tokenURL, signJwtResponse.SignedJwt)
 	if err != nil {
 		logger.LogError(ctx, "Error getting access token", "error", err)
+		return "", 0, err
+	}
+	return accessToken, expiresIn, nil
+}
+
+// newTokenClient returns an HTTP client authorizing requests with the tokens
+// of tokenSource.
+func newTokenClient(ctx context.Context, cfg *clientConfig, userEmail string, tokenSource oauth2.TokenSource) *http.Client {
+	client := oauth2.NewClient(ctx, tokenSource)
+
+	// Wrap the base transport so the oauth2.Transport stays outermost and the
+	// token source remains reachable for callers that inspect it.
+	transport := client.Transport.(*oauth2.Transport)
+	base := transport.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if cfg.quotaProject != "" {
+		base = &quotaProjectTransport{base: base, project: cfg.quotaProject}
+	}
+	if cfg.usageStats != nil {
+		base = &usageTransport{base: base, stats: cfg.usageStats, subject: userEmail}
+	}
+	transport.Base = base
+	return client
+}
+
+// GenerateGoogleClientOptions creates an authenticated HTTP client like
+// NewHTTPClient and returns it as options for the google.golang.org/api clients.
+func GenerateGoogleClientOptions(ctx context.Context, logger structured.Logger, iamClient IAMServiceClient, targetServiceAccount, userEmail, scopes string, opts ...Option) ([]option.ClientOption, error) {
+	client, err := NewHTTPClient(ctx, logger, iamClient, targetServiceAccount, userEmail, scopes, opts...)
+	if err != nil {
 		return nil, err
 	}
 
-	tokenSource := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: accessToken})
-	return oauth2.NewClient(ctx, tokenSource), nil
+	clientOpts := []option.ClientOption{option.WithHTTPClient(client)}
+	if cfg := newClientConfig(opts); cfg.quotaProject != "" {
+		clientOpts = append(clientOpts, option.WithQuotaProject(cfg.quotaProject))
+	}
+	return clientOpts, nil
 }
 
-// createJWTAssertion generates the JWT assertion string for the HTTP client.
-func createJWTAssertion(targetServiceAccount, userEmail, scopes string) (string, error) {
+// createJWTAssertion generates the JWT assertion string for the HTTP client,
+// issued at issuedAt.
+func createJWTAssertion(issuedAt time.Time, targetServiceAccount, userEmail, scopes string) (string, error) {
 	if targetServiceAccount == "" || userEmail == "" || scopes == "" {
 		return "", fmt.Errorf("service account, user email, and scopes must all be provided")
 	}
 
-	now := time.Now().Unix()
+	now := issuedAt.Unix()
 	jwtPayload := JWTClaims{
 		Iss:   targetServiceAccount,
 		Sub:   userEmail,
@@ -116,36 +266,38 @@ func createJWTAssertion(targetServiceAccount, userEmail, scopes string) (string,
 	return string(payloadBytes), nil
 }
 
-// getAccessToken exchanges the signed JWT for an access token.
-func getAccessToken(logger *structured.StructuredLogger, tokenUrl, signedJwt string) (string, error) {
+// getAccessToken exchanges the signed JWT for an access token and returns it
+// with its lifetime, which is zero when the endpoint does not report one.
+func getAccessToken(logger structured.Logger, client *http.Client, tokenUrl, signedJwt string) (string, time.Duration, error) {
 	data := url.Values{
 		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
 		"assertion":  {signedJwt},  // Ensure the signed JWT is being passed here
 	}
 	
-	resp, err := http.PostForm(tokenUrl, data)
+	resp, err := client.PostForm(tokenUrl, data)
 	if err != nil {
 		logger.LogError(context.Background(), "Error posting to token URL", "url", tokenUrl, "error", err)
-		return "", fmt.Errorf("error posting to token endpoint: %w", err)
+		return "", 0, fmt.Errorf("error posting to token endpoint: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		logger.LogError(context.Background(), "Non-OK response from token URL", "status", resp.StatusCode, "body", string(body))
-		return "", &errors.GoogleAPIError{
+		return "", 0, clockSkewError(&errors.GoogleAPIError{
 			StatusCode: resp.StatusCode,
 			Body:       string(body),
-		}
+		}, resp.Header, time.Now())
 	}
 
 	var tokenResponse struct {
 		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
 		logger.LogError(context.Background(), "Error decoding access token response", "error", err)
-		return "", fmt.Errorf("error unmarshaling token response: %w", err)
+		return "", 0, fmt.Errorf("error unmarshaling token response: %w", err)
 	}
 
-	return tokenResponse.AccessToken, nil
+	return tokenResponse.AccessToken, time.Duration(tokenResponse.ExpiresIn) * time.Second, nil
 }
@@ -20,11 +20,14 @@
 package serviceaccount
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"google.golang.org/api/iam/v1"
@@ -171,3 +174,20 @@ func TestGenerateGoogleHTTPClient(t *testing.T) {
     }
 }
 
+
+func TestNewHTTPClientAcceptsLoggerInterface(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	slogLogger := logger.FromSlog(slog.New(slog.NewJSONHandler(&buf, nil)))
+	_, err := NewHTTPClient(context.Background(), slogLogger, &MockIAMServiceClient{}, "sa@example.iam.gserviceaccount.com", "user@example.com", "scope", WithTokenURL(ts.URL))
+	if err == nil {
+		t.Fatal("Expected an error from the token endpoint, got nil")
+	}
+	if !strings.Contains(buf.String(), `"level":"ERROR"`) {
+		t.Errorf("Expected the error to be logged through slog, got %s", buf.String())
+	}
+}
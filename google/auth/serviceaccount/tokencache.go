@@ -0,0 +1,188 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package serviceaccount
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/duizendstra/go/google/logging"
+	"golang.org/x/oauth2"
+)
+
+// tokenCacheMinValidity is the remaining lifetime a persisted token needs to be reused.
+const tokenCacheMinValidity = 5 * time.Minute
+
+// WithTokenCacheFile persists minted access tokens to the file at path,
+// encrypted with AES-256-GCM under key, which must be 32 bytes. NewHTTPClient
+// reuses a persisted token for the same service account, subject, scopes,
+// and token URL while it has at least five minutes left, skipping the
+// SignJwt and token exchange round trips. Like a client with a freshly
+// minted token, the client mints a new token when the persisted one expires.
+// The file must live on storage that outlives the instance for tokens to
+// survive a cold start. Cache failures are logged as warnings and never fail
+// client creation.
+func WithTokenCacheFile(path string, key []byte) Option {
+	return func(cfg *clientConfig) {
+		cfg.tokenCache = &fileTokenCache{path: path, key: key}
+	}
+}
+
+// cachedToken is a persisted access token.
+type cachedToken struct {
+	AccessToken string    `json:"access_token"`
+	Expiry      time.Time `json:"expiry"`
+}
+
+// fileTokenCache stores tokens in an encrypted JSON file keyed by tokenCacheKey.
+type fileTokenCache struct {
+	path string
+	key  []byte
+}
+
+// tokenCacheFileMu serializes access to cache files within the process.
+var tokenCacheFileMu sync.Mutex
+
+// tokenCacheKey identifies the token minted for a delegation. It is hashed
+// so the subject does not appear in the file even once decrypted.
+func tokenCacheKey(targetServiceAccount, userEmail, scopes, tokenURL string) string {
+	sum := sha256.Sum256([]byte(targetServiceAccount + "\x00" + userEmail + "\x00" + scopes + "\x00" + tokenURL))
+	return hex.EncodeToString(sum[:])
+}
+
+// load returns the persisted token for key, with its expiry, when it is still
+// valid long enough.
+func (c *fileTokenCache) load(ctx context.Context, logger structured.Logger, key string) (*oauth2.Token, bool) {
+	tokenCacheFileMu.Lock()
+	defer tokenCacheFileMu.Unlock()
+
+	tokens, err := c.read()
+	if err != nil {
+		logger.LogWarning(ctx, "Error reading token cache", "path", c.path, "error", err)
+		return nil, false
+	}
+	token, ok := tokens[key]
+	if !ok || time.Until(token.Expiry) < tokenCacheMinValidity {
+		return nil, false
+	}
+	return &oauth2.Token{AccessToken: token.AccessToken, TokenType: "Bearer", Expiry: token.Expiry}, true
+}
+
+// store persists a token for key and drops expired entries.
+func (c *fileTokenCache) store(ctx context.Context, logger structured.Logger, key, accessToken string, expiry time.Time) {
+	tokenCacheFileMu.Lock()
+	defer tokenCacheFileMu.Unlock()
+
+	tokens, err := c.read()
+	if err != nil {
+		logger.LogWarning(ctx, "Error reading token cache, starting a new one", "path", c.path, "error", err)
+		tokens = make(map[string]cachedToken)
+	}
+	now := time.Now()
+	for k, token := range tokens {
+		if token.Expiry.Before(now) {
+			delete(tokens, k)
+		}
+	}
+	tokens[key] = cachedToken{AccessToken: accessToken, Expiry: expiry}
+
+	if err := c.write(tokens); err != nil {
+		logger.LogWarning(ctx, "Error writing token cache", "path", c.path, "error", err)
+	}
+}
+
+// read decrypts the cache file. A missing file is an empty cache.
+func (c *fileTokenCache) read() (map[string]cachedToken, error) {
+	tokens := make(map[string]cachedToken)
+	data, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := c.cipher()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("token cache file is truncated")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting token cache: %w", err)
+	}
+	if err := json.Unmarshal(plaintext, &tokens); err != nil {
+		return nil, fmt.Errorf("error decoding token cache: %w", err)
+	}
+	return tokens, nil
+}
+
+// write encrypts tokens and atomically replaces the cache file.
+func (c *fileTokenCache) write(tokens map[string]cachedToken) error {
+	plaintext, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	gcm, err := c.cipher()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(gcm.Seal(nonce, nonce, plaintext, nil)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
+
+func (c *fileTokenCache) cipher() (cipher.AEAD, error) {
+	if len(c.key) != 32 {
+		return nil, fmt.Errorf("token cache key must be 32 bytes, got %d", len(c.key))
+	}
+	block, err := aes.NewCipher(c.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
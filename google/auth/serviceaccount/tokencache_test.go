@@ -0,0 +1,129 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package serviceaccount
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	logger "github.com/duizendstra/go/google/logging"
+)
+
+func TestTokenCacheFile(t *testing.T) {
+	logger := logger.NewStructuredLogger("test-project", "test-component", nil, nil)
+
+	var mints atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			mints.Add(1)
+			json.NewEncoder(w).Encode(map[string]any{"access_token": "mocked_access_token", "expires_in": 3600})
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer mocked_access_token" {
+			t.Errorf("Expected the cached token to be sent, got '%s'", got)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "tokens")
+	key := bytes.Repeat([]byte{7}, 32)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		client, err := NewHTTPClient(ctx, logger, &MockIAMServiceClient{}, "test-service-account", "user@example.com", "test-scope",
+			WithTokenURL(ts.URL), WithTokenCacheFile(path, key))
+		if err != nil {
+			t.Fatalf("NewHTTPClient returned unexpected error: %v", err)
+		}
+		resp, err := client.Get(ts.URL)
+		if err != nil {
+			t.Fatalf("HTTP client returned error: %v", err)
+		}
+		resp.Body.Close()
+	}
+	if got := mints.Load(); got != 1 {
+		t.Errorf("Expected 1 token mint with a persisted cache, got %d", got)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected the cache file to exist: %v", err)
+	}
+	if bytes.Contains(data, []byte("mocked_access_token")) {
+		t.Errorf("Expected the cache file to be encrypted")
+	}
+
+	// Another subject is minted separately.
+	if _, err := NewHTTPClient(ctx, logger, &MockIAMServiceClient{}, "test-service-account", "other@example.com", "test-scope",
+		WithTokenURL(ts.URL), WithTokenCacheFile(path, key)); err != nil {
+		t.Fatalf("NewHTTPClient returned unexpected error: %v", err)
+	}
+	if got := mints.Load(); got != 2 {
+		t.Errorf("Expected a new mint for another subject, got %d mints", got)
+	}
+
+	// A wrong key cannot read the cache and falls back to minting.
+	if _, err := NewHTTPClient(ctx, logger, &MockIAMServiceClient{}, "test-service-account", "user@example.com", "test-scope",
+		WithTokenURL(ts.URL), WithTokenCacheFile(path, bytes.Repeat([]byte{8}, 32))); err != nil {
+		t.Fatalf("NewHTTPClient returned unexpected error: %v", err)
+	}
+	if got := mints.Load(); got != 3 {
+		t.Errorf("Expected a mint when the cache cannot be decrypted, got %d mints", got)
+	}
+}
+
+func TestNewHTTPClientRefreshesExpiredToken(t *testing.T) {
+	logger := logger.NewStructuredLogger("test-project", "test-component", nil, nil)
+
+	var mints atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			mints.Add(1)
+			// A token this short-lived is already due for refresh
+			json.NewEncoder(w).Encode(map[string]any{"access_token": "mocked_access_token", "expires_in": 5})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	client, err := NewHTTPClient(context.Background(), logger, &MockIAMServiceClient{}, "test-service-account", "user@example.com", "test-scope",
+		WithTokenURL(ts.URL))
+	if err != nil {
+		t.Fatalf("NewHTTPClient returned unexpected error: %v", err)
+	}
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("HTTP client returned error: %v", err)
+	}
+	resp.Body.Close()
+
+	if got := mints.Load(); got != 2 {
+		t.Errorf("Expected the expired token to be minted again, got %d mints", got)
+	}
+}
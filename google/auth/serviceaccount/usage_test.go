@@ -0,0 +1,96 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package serviceaccount
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	logger "github.com/duizendstra/go/google/logging"
+)
+
+func TestUsageStats(t *testing.T) {
+	logger := logger.NewStructuredLogger("test-project", "test-component", nil, nil)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			json.NewEncoder(w).Encode(map[string]string{"access_token": "mocked_access_token"})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	stats := NewUsageStats()
+	ctx := context.Background()
+	for _, subject := range []string{"b@example.com", "a@example.com", "a@example.com"} {
+		client, err := NewHTTPClient(ctx, logger, &MockIAMServiceClient{}, "test-service-account", subject, "test-scope",
+			WithTokenURL(ts.URL), WithUsageStats(stats))
+		if err != nil {
+			t.Fatalf("NewHTTPClient returned unexpected error: %v", err)
+		}
+		resp, err := client.Get(ts.URL)
+		if err != nil {
+			t.Fatalf("HTTP client returned error: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	got := stats.Stats()
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 subjects, got %d", len(got))
+	}
+	if got[0].Subject != "a@example.com" || got[0].Mints != 2 || got[0].Requests != 2 {
+		t.Errorf("Unexpected usage for a@example.com: %+v", got[0])
+	}
+	if got[1].Subject != "b@example.com" || got[1].Mints != 1 || got[1].Requests != 1 {
+		t.Errorf("Unexpected usage for b@example.com: %+v", got[1])
+	}
+	if got[0].LastMinted.IsZero() || got[0].LastUsed == nil {
+		t.Errorf("Expected timestamps to be recorded: %+v", got[0])
+	}
+
+	var buf bytes.Buffer
+	if err := stats.Export(&buf); err != nil {
+		t.Fatalf("Export returned unexpected error: %v", err)
+	}
+	var exported []SubjectUsage
+	if err := json.Unmarshal(buf.Bytes(), &exported); err != nil || len(exported) != 2 {
+		t.Errorf("Expected 2 exported subjects, got %v (%v)", exported, err)
+	}
+}
+
+func TestUsageStatsWithoutRequests(t *testing.T) {
+	stats := NewUsageStats()
+	stats.recordMint("a@example.com")
+
+	var buf bytes.Buffer
+	if err := stats.Export(&buf); err != nil {
+		t.Fatalf("Export returned unexpected error: %v", err)
+	}
+	if strings.Contains(buf.String(), "lastUsed") {
+		t.Errorf("Expected lastUsed to be omitted for a subject without requests, got %s", buf.String())
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/grpc/status"
+)
+
+// Cause is one layer of the wrap chain of an error, as rendered in JSON by
+// the MarshalJSON methods of the error types, for debugging endpoints and
+// dead-letter records.
+type Cause struct {
+	// Type is the Go type of the error, such as "*errors.GoogleAPIError".
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	// Code is the ErrorCode of a *GoogleAPIError, the gRPC code of a status
+	// error, or the Field of a *ValidationError.
+	Code string `json:"code,omitempty"`
+	// Status is the HTTP status of an error of a known kind, see StatusFromError.
+	Status int `json:"status,omitempty"`
+	// Cause is the wrapped error; Joined are the errors of errors.Join.
+	Cause  *Cause  `json:"cause,omitempty"`
+	Joined []Cause `json:"joined,omitempty"`
+}
+
+// CauseChain returns the wrap chain of err, outermost layer first, including
+// errors joined with errors.Join. It returns nil for a nil error.
+func CauseChain(err error) *Cause {
+	if err == nil {
+		return nil
+	}
+	c := &Cause{Type: fmt.Sprintf("%T", err), Message: err.Error()}
+	switch e := err.(type) {
+	case *GoogleAPIError:
+		c.Code, c.Status = e.ErrorCode, e.StatusCode
+	case *ValidationError:
+		c.Code, c.Status = e.Field, StatusFromError(e)
+	case interface{ StatusCode() int }:
+		c.Status = e.StatusCode()
+	case interface{ GRPCStatus() *status.Status }:
+		code := e.GRPCStatus().Code()
+		c.Code, c.Status = code.String(), httpStatusFromCode(code)
+	}
+
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		c.Cause = CauseChain(u.Unwrap())
+	case interface{ Unwrap() []error }:
+		for _, next := range u.Unwrap() {
+			if next != nil {
+				c.Joined = append(c.Joined, *CauseChain(next))
+			}
+		}
+	}
+	return c
+}
+
+// MarshalJSON renders the error as its Cause chain.
+func (e *GoogleAPIError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(CauseChain(e))
+}
+
+// MarshalJSON renders the error as its Cause chain.
+func (e *ValidationError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(CauseChain(e))
+}
+
+// MarshalJSON renders the panic as its Cause chain; the stack is omitted.
+func (e *PanicError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(CauseChain(e))
+}
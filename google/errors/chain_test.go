@@ -0,0 +1,82 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCauseChain(t *testing.T) {
+	apiErr := &GoogleAPIError{StatusCode: http.StatusForbidden, Body: "denied", ErrorCode: "PERMISSION_DENIED"}
+	err := fmt.Errorf("sync users: %w", apiErr)
+
+	chain := CauseChain(err)
+	assert.Equal(t, "*fmt.wrapError", chain.Type)
+	assert.Equal(t, err.Error(), chain.Message)
+	if assert.NotNil(t, chain.Cause) {
+		assert.Equal(t, "*errors.GoogleAPIError", chain.Cause.Type)
+		assert.Equal(t, "PERMISSION_DENIED", chain.Cause.Code)
+		assert.Equal(t, http.StatusForbidden, chain.Cause.Status)
+		assert.Nil(t, chain.Cause.Cause)
+	}
+
+	assert.Nil(t, CauseChain(nil))
+}
+
+func TestCauseChainJoined(t *testing.T) {
+	err := errors.Join(
+		&ValidationError{Field: "email", Message: "required"},
+		status.Error(codes.NotFound, "no such user"),
+	)
+
+	chain := CauseChain(err)
+	if assert.Len(t, chain.Joined, 2) {
+		assert.Equal(t, "email", chain.Joined[0].Code)
+		assert.Equal(t, http.StatusBadRequest, chain.Joined[0].Status)
+		assert.Equal(t, "NotFound", chain.Joined[1].Code)
+		assert.Equal(t, http.StatusNotFound, chain.Joined[1].Status)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	cause := &GoogleAPIError{StatusCode: http.StatusServiceUnavailable, Body: "backend unavailable", ErrorCode: "UNAVAILABLE"}
+	panicErr := &PanicError{Value: fmt.Errorf("lookup: %w", cause), Stack: []byte("goroutine 1")}
+
+	data, err := json.Marshal(panicErr)
+	assert.NoError(t, err)
+
+	var rendered map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &rendered))
+	assert.Equal(t, "*errors.PanicError", rendered["type"])
+	assert.Equal(t, float64(http.StatusInternalServerError), rendered["status"])
+	assert.NotContains(t, string(data), "goroutine 1", "the stack is not rendered")
+
+	wrapped, _ := rendered["cause"].(map[string]interface{})
+	api, _ := wrapped["cause"].(map[string]interface{})
+	assert.Equal(t, "UNAVAILABLE", api["code"])
+}
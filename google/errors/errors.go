@@ -20,16 +20,28 @@
 package errors
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"net/http"
+	"time"
 )
 
+// ErrorIDHeader is the response header carrying the ID of a handled error.
+const ErrorIDHeader = "X-Error-ID"
+
 // GoogleAPIError represents an error response from an API request.
 type GoogleAPIError struct {
 	StatusCode   int
 	Body         string
 	ErrorCode    string
 	ErrorMessage string
+	// RetryAfter is the backoff HandleError advertises for 429 and 503
+	// responses. When zero, it is derived from the error details in Body.
+	RetryAfter time.Duration
 }
 
 func (e *GoogleAPIError) Error() string {
@@ -37,13 +49,88 @@ func (e *GoogleAPIError) Error() string {
 }
 
 // HandleError logs the error and sends an appropriate response to the client.
+// Every handled error gets a short ID that is logged as the error_id label
+// (or appended to the message when the logger does not implement
+// LabelLogger), sent in the X-Error-ID header, and included in the body, so a
+// client report can be matched to the exact log entry. A Google JSON error
+// body carries the ID as error.errorId; other API error bodies are passed
+// through unchanged. The status is inferred with StatusFromError, so
+// wrapped errors keep their status. For 429 and 503 API errors, Retry-After and
+// RateLimit-Limit headers are set from the retry advice of the error. The
+// error is counted by class and status through the ErrorMetrics installed
+// with SetErrorMetrics. Errors escalated with EscalateSeverity are logged at
+// their severity when the logger implements SeverityLogger.
 func HandleError(logger interface{ LogError(string) }, w http.ResponseWriter, err error) {
-	switch e := err.(type) {
-	case *GoogleAPIError:
-		logger.LogError(e.Error())
-		http.Error(w, e.Body, e.StatusCode)
+	errorID := newErrorID()
+	w.Header().Set(ErrorIDHeader, errorID)
+
+	logError(logger, err, errorID)
+
+	status := StatusFromError(err)
+	countError(err, status)
+
+	var apiErr *GoogleAPIError
+	var validationErr *ValidationError
+	switch {
+	case stderrors.As(err, &apiErr) && apiErr.StatusCode == status:
+		setRetryHeaders(w, apiErr)
+		writeAPIErrorBody(w, apiErr.Body, status, errorID)
+	case stderrors.As(err, &validationErr) && status == http.StatusBadRequest:
+		http.Error(w, fmt.Sprintf("%s (error ID: %s)", validationErr.Error(), errorID), status)
+	case status == http.StatusInternalServerError:
+		http.Error(w, fmt.Sprintf("Internal server error (error ID: %s)", errorID), status)
 	default:
-		logger.LogError(err.Error())
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		text := http.StatusText(status)
+		if status == StatusClientClosedRequest {
+			text = "Client closed request"
+		}
+		http.Error(w, fmt.Sprintf("%s (error ID: %s)", text, errorID), status)
+	}
+}
+
+// writeAPIErrorBody writes the body of an API error, as JSON when it is a
+// JSON document such as a Google error body.
+func writeAPIErrorBody(w http.ResponseWriter, body string, status int, errorID string) {
+	if !json.Valid([]byte(body)) {
+		http.Error(w, body, status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	fmt.Fprintln(w, withErrorID(body, errorID))
+}
+
+// withErrorID adds errorId to the error object of a Google JSON error body,
+// keeping the order of its fields. Other documents are returned unchanged.
+func withErrorID(body, errorID string) string {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		return body
+	}
+	errObj := bytes.TrimSpace(doc["error"])
+	if len(errObj) < 2 || errObj[0] != '{' {
+		return body
+	}
+	id, _ := json.Marshal(errorID)
+	field := append([]byte(`"errorId":`), id...)
+	if inner := bytes.TrimSpace(errObj[1 : len(errObj)-1]); len(inner) > 0 {
+		field = append([]byte{','}, field...)
+	}
+	updated := append(append(errObj[:len(errObj)-1:len(errObj)-1], field...), '}')
+	doc["error"] = updated
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return body
+	}
+	return string(out)
+}
+
+// newErrorID returns a short random identifier for a handled error.
+func newErrorID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
 	}
+	return hex.EncodeToString(b)
 }
@@ -44,6 +44,7 @@ func TestHandleError(t *testing.T) {
 		err          error
 		expectedCode int
 		expectedBody string
+		withID       bool
 	}{
 		{
 			name: "APIError",
@@ -54,11 +55,21 @@ func TestHandleError(t *testing.T) {
 			expectedCode: http.StatusNotFound,
 			expectedBody: "Not Found",
 		},
+		{
+			name: "APIError with JSON body",
+			err: &GoogleAPIError{
+				StatusCode: http.StatusForbidden,
+				Body:       `{"error":{"code":403,"message":"denied","status":"PERMISSION_DENIED"}}`,
+			},
+			expectedCode: http.StatusForbidden,
+			expectedBody: `{"error":{"code":403,"message":"denied","status":"PERMISSION_DENIED","errorId":"<id>"}}`,
+		},
 		{
 			name:         "GenericError",
 			err:          errors.New("generic error"),
 			expectedCode: http.StatusInternalServerError,
 			expectedBody: "Internal server error",
+			withID:       true,
 		},
 	}
 
@@ -74,11 +85,74 @@ func TestHandleError(t *testing.T) {
 
 			assert.Equal(t, tt.expectedCode, result.StatusCode)
 
+			errorID := result.Header.Get(ErrorIDHeader)
+			assert.Len(t, errorID, 8)
+
 			body, err := io.ReadAll(result.Body)
 			assert.NoError(t, err)
-			assert.Equal(t, tt.expectedBody, strings.TrimSpace(string(body)))
+			expectedBody := strings.ReplaceAll(tt.expectedBody, "<id>", errorID)
+			if tt.withID {
+				expectedBody += " (error ID: " + errorID + ")"
+			}
+			assert.Equal(t, expectedBody, strings.TrimSpace(string(body)))
 
-			assert.Contains(t, logger.Messages, tt.err.Error())
+			assert.Contains(t, logger.Messages, tt.err.Error()+" [error_id="+errorID+"]")
 		})
 	}
 }
+
+// MockLabelLogger records the labels of each message.
+type MockLabelLogger struct {
+	MockLogger
+	Labels []map[string]string
+}
+
+func (ml *MockLabelLogger) LogSeverityLabels(severity, message string, labels map[string]string) {
+	ml.Messages = append(ml.Messages, message)
+	ml.Labels = append(ml.Labels, labels)
+}
+
+func TestHandleErrorLabels(t *testing.T) {
+	logger := &MockLabelLogger{}
+	recorder := httptest.NewRecorder()
+
+	HandleError(logger, recorder, errors.New("generic error"))
+
+	errorID := recorder.Header().Get(ErrorIDHeader)
+	assert.Equal(t, []string{"generic error"}, logger.Messages)
+	assert.Equal(t, []map[string]string{{"error_id": errorID}}, logger.Labels)
+}
+
+func TestHandleErrorJSONContentType(t *testing.T) {
+	recorder := httptest.NewRecorder()
+
+	HandleError(&MockLogger{}, recorder, &GoogleAPIError{StatusCode: http.StatusForbidden, Body: `{"error":{"code":403}}`})
+
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+}
+
+func TestWithErrorID(t *testing.T) {
+	tests := []struct {
+		body     string
+		expected string
+	}{
+		{`{"error":{}}`, `{"error":{"errorId":"abc"}}`},
+		{`{"error": {"code": 500} }`, `{"error":{"code":500,"errorId":"abc"}}`},
+		{`{"error":"denied"}`, `{"error":"denied"}`},
+		{`["not","an","object"]`, `["not","an","object"]`},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, withErrorID(tt.body, "abc"))
+	}
+}
+
+func TestHandleErrorUniqueIDs(t *testing.T) {
+	logger := &MockLogger{}
+	first := httptest.NewRecorder()
+	second := httptest.NewRecorder()
+
+	HandleError(logger, first, errors.New("generic error"))
+	HandleError(logger, second, errors.New("generic error"))
+
+	assert.NotEqual(t, first.Header().Get(ErrorIDHeader), second.Header().Get(ErrorIDHeader))
+}
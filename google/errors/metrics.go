@@ -0,0 +1,119 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package errors
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc/status"
+)
+
+// Error classes reported to ErrorMetrics.
+const (
+	ClassAPI        = "api"
+	ClassValidation = "validation"
+	ClassPanic      = "panic"
+	ClassTimeout    = "timeout"
+	ClassCanceled   = "canceled"
+	ClassGRPC       = "grpc"
+	ClassInternal   = "internal"
+)
+
+// ErrorMetrics counts the errors handled by HandleError, for error-rate
+// dashboards per class and status without log-based metrics. Implementations
+// must be safe for concurrent use.
+type ErrorMetrics interface {
+	CountError(class string, status int)
+}
+
+// ErrorMetricsFunc adapts a function to ErrorMetrics.
+type ErrorMetricsFunc func(class string, status int)
+
+// CountError calls f.
+func (f ErrorMetricsFunc) CountError(class string, status int) {
+	f(class, status)
+}
+
+var (
+	metricsMu sync.RWMutex
+	metrics   ErrorMetrics
+)
+
+// SetErrorMetrics installs the ErrorMetrics HandleError reports to. Passing
+// nil stops the reporting.
+func SetErrorMetrics(m ErrorMetrics) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	metrics = m
+}
+
+// countError reports a handled error to the installed ErrorMetrics, if any.
+func countError(err error, status int) {
+	metricsMu.RLock()
+	m := metrics
+	metricsMu.RUnlock()
+	if m != nil {
+		m.CountError(ClassOf(err), status)
+	}
+}
+
+// ClassOf returns the class of err, decided like StatusFromError by the
+// outermost error of a known kind. An error of no known kind is ClassInternal.
+func ClassOf(err error) string {
+	if class, ok := classOf(err); ok {
+		return class
+	}
+	return ClassInternal
+}
+
+// classOf checks err and then its wrapped errors for a known kind.
+func classOf(err error) (string, bool) {
+	switch err.(type) {
+	case *GoogleAPIError:
+		return ClassAPI, true
+	case *ValidationError:
+		return ClassValidation, true
+	case *PanicError:
+		return ClassPanic, true
+	case interface{ GRPCStatus() *status.Status }:
+		return ClassGRPC, true
+	}
+	switch err {
+	case context.DeadlineExceeded:
+		return ClassTimeout, true
+	case context.Canceled:
+		return ClassCanceled, true
+	}
+
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		if next := u.Unwrap(); next != nil {
+			return classOf(next)
+		}
+	case interface{ Unwrap() []error }:
+		for _, next := range u.Unwrap() {
+			if class, ok := classOf(next); ok {
+				return class, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"API error", &GoogleAPIError{StatusCode: http.StatusNotFound}, ClassAPI},
+		{"Wrapped validation error", fmt.Errorf("create user: %w", &ValidationError{Field: "email", Message: "required"}), ClassValidation},
+		{"Panic", &PanicError{Value: "boom"}, ClassPanic},
+		{"Deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), ClassTimeout},
+		{"Canceled", context.Canceled, ClassCanceled},
+		{"gRPC status", status.Error(codes.NotFound, "missing"), ClassGRPC},
+		{"Joined", errors.Join(errors.New("plain"), &ValidationError{Message: "bad"}), ClassValidation},
+		{"Unknown", errors.New("plain"), ClassInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ClassOf(tt.err))
+		})
+	}
+}
+
+func TestHandleErrorCountsErrors(t *testing.T) {
+	type count struct {
+		class  string
+		status int
+	}
+	var counts []count
+	SetErrorMetrics(ErrorMetricsFunc(func(class string, status int) {
+		counts = append(counts, count{class, status})
+	}))
+	defer SetErrorMetrics(nil)
+
+	HandleError(&MockLogger{}, httptest.NewRecorder(), &GoogleAPIError{StatusCode: http.StatusTooManyRequests, Body: "quota"})
+	HandleError(&MockLogger{}, httptest.NewRecorder(), fmt.Errorf("handler: %w", context.DeadlineExceeded))
+
+	assert.Equal(t, []count{
+		{ClassAPI, http.StatusTooManyRequests},
+		{ClassTimeout, http.StatusGatewayTimeout},
+	}, counts)
+}
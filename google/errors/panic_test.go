@@ -0,0 +1,92 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package errors
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSafe(t *testing.T) {
+	errCause := errors.New("cause")
+
+	tests := []struct {
+		name          string
+		fn            func() error
+		expectedErr   string
+		expectedPanic bool
+	}{
+		{name: "No error", fn: func() error { return nil }},
+		{name: "Returned error", fn: func() error { return errCause }, expectedErr: "cause"},
+		{name: "Panic with value", fn: func() error { panic("boom") }, expectedErr: "panic: boom", expectedPanic: true},
+		{name: "Panic with error", fn: func() error { panic(errCause) }, expectedErr: "panic: cause", expectedPanic: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Safe(tt.fn)
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.expectedErr)
+
+			var panicErr *PanicError
+			assert.Equal(t, tt.expectedPanic, errors.As(err, &panicErr))
+			if tt.expectedPanic {
+				assert.Equal(t, http.StatusInternalServerError, panicErr.StatusCode())
+				assert.True(t, strings.Contains(string(panicErr.Stack), "panic_test.go"))
+			}
+		})
+	}
+}
+
+func TestSafeUnwrapsPanicError(t *testing.T) {
+	errCause := errors.New("cause")
+	err := Safe(func() error { panic(errCause) })
+	assert.ErrorIs(t, err, errCause)
+}
+
+func TestRecover(t *testing.T) {
+	work := func() (err error) {
+		defer Recover(&err)
+		var m map[string]int
+		m["key"] = 1 // panics: assignment to entry in nil map
+		return nil
+	}
+
+	err := work()
+	assert.ErrorContains(t, err, "panic: assignment to entry in nil map")
+}
+
+func TestHandleErrorPanic(t *testing.T) {
+	logger := &MockLogger{}
+	recorder := httptest.NewRecorder()
+
+	HandleError(logger, recorder, Safe(func() error { panic("boom") }))
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.True(t, strings.HasPrefix(logger.Messages[0], "panic: boom"))
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package errors
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Google error detail types that carry retry advice.
+const (
+	retryInfoType = "type.googleapis.com/google.rpc.RetryInfo"
+	errorInfoType = "type.googleapis.com/google.rpc.ErrorInfo"
+)
+
+// RetryAdvice is the backoff guidance for a throttled or unavailable request.
+type RetryAdvice struct {
+	// RetryAfter is how long the client should wait before retrying.
+	RetryAfter time.Duration
+	// Limit is the quota limit that was exceeded, 0 when unknown.
+	Limit int64
+}
+
+// RetryAdviceFrom returns the retry advice of a 429 or 503 GoogleAPIError:
+// its RetryAfter field when set, otherwise the google.rpc.RetryInfo delay
+// and the quota_limit_value of google.rpc.ErrorInfo in the error body.
+func RetryAdviceFrom(e *GoogleAPIError) (RetryAdvice, bool) {
+	if e.StatusCode != http.StatusTooManyRequests && e.StatusCode != http.StatusServiceUnavailable {
+		return RetryAdvice{}, false
+	}
+
+	advice := RetryAdvice{RetryAfter: e.RetryAfter}
+	var body struct {
+		Error struct {
+			Details []struct {
+				Type       string            `json:"@type"`
+				RetryDelay string            `json:"retryDelay"`
+				Metadata   map[string]string `json:"metadata"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(e.Body), &body); err == nil {
+		for _, d := range body.Error.Details {
+			switch d.Type {
+			case retryInfoType:
+				if delay, err := time.ParseDuration(d.RetryDelay); err == nil && advice.RetryAfter == 0 {
+					advice.RetryAfter = delay
+				}
+			case errorInfoType:
+				if limit, err := strconv.ParseInt(d.Metadata["quota_limit_value"], 10, 64); err == nil {
+					advice.Limit = limit
+				}
+			}
+		}
+	}
+	return advice, advice.RetryAfter > 0 || advice.Limit > 0
+}
+
+// setRetryHeaders sets Retry-After and RateLimit-Limit from the retry advice of e.
+func setRetryHeaders(w http.ResponseWriter, e *GoogleAPIError) {
+	advice, ok := RetryAdviceFrom(e)
+	if !ok {
+		return
+	}
+	if advice.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(advice.RetryAfter.Seconds())), 10))
+	}
+	if advice.Limit > 0 {
+		w.Header().Set("RateLimit-Limit", strconv.FormatInt(advice.Limit, 10))
+	}
+}
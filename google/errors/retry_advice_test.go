@@ -0,0 +1,64 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package errors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const quotaErrorBody = `{"error": {"code": 429, "status": "RESOURCE_EXHAUSTED", "details": [
+	{"@type": "type.googleapis.com/google.rpc.ErrorInfo", "reason": "RATE_LIMIT_EXCEEDED", "metadata": {"quota_limit_value": "60"}},
+	{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "12.5s"}
+]}}`
+
+func TestRetryAdviceFrom(t *testing.T) {
+	advice, ok := RetryAdviceFrom(&GoogleAPIError{StatusCode: http.StatusTooManyRequests, Body: quotaErrorBody})
+	assert.True(t, ok)
+	assert.Equal(t, 12500*time.Millisecond, advice.RetryAfter)
+	assert.Equal(t, int64(60), advice.Limit)
+
+	advice, ok = RetryAdviceFrom(&GoogleAPIError{StatusCode: http.StatusServiceUnavailable, Body: quotaErrorBody, RetryAfter: time.Minute})
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, advice.RetryAfter, "an explicit RetryAfter wins")
+
+	_, ok = RetryAdviceFrom(&GoogleAPIError{StatusCode: http.StatusTooManyRequests, Body: "Too many requests"})
+	assert.False(t, ok)
+
+	_, ok = RetryAdviceFrom(&GoogleAPIError{StatusCode: http.StatusBadRequest, Body: quotaErrorBody})
+	assert.False(t, ok, "only 429 and 503 carry retry advice")
+}
+
+func TestHandleErrorRetryHeaders(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	HandleError(&MockLogger{}, recorder, &GoogleAPIError{StatusCode: http.StatusTooManyRequests, Body: quotaErrorBody})
+
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "13", recorder.Header().Get("Retry-After"))
+	assert.Equal(t, "60", recorder.Header().Get("RateLimit-Limit"))
+
+	recorder = httptest.NewRecorder()
+	HandleError(&MockLogger{}, recorder, &GoogleAPIError{StatusCode: http.StatusNotFound, Body: "Not Found"})
+	assert.Empty(t, recorder.Header().Get("Retry-After"))
+}
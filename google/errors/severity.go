@@ -0,0 +1,141 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Severities HandleError logs at, in increasing order.
+const (
+	SeverityError     = "ERROR"
+	SeverityCritical  = "CRITICAL"
+	SeverityAlert     = "ALERT"
+	SeverityEmergency = "EMERGENCY"
+)
+
+var severityRank = map[string]int{SeverityError: 0, SeverityCritical: 1, SeverityAlert: 2, SeverityEmergency: 3}
+
+// SeverityLogger is implemented by loggers that can log at a severity other
+// than ERROR. HandleError uses it for escalated errors; loggers implementing
+// only LogError log every error at ERROR.
+type SeverityLogger interface {
+	LogSeverity(severity, message string)
+}
+
+type escalation struct {
+	match    func(error) bool
+	severity string
+}
+
+var (
+	escalationsMu sync.RWMutex
+	escalations   []escalation
+)
+
+// EscalateSeverity makes HandleError log errors for which match returns true
+// at severity, one of SeverityCritical, SeverityAlert, or SeverityEmergency,
+// so alerting policies can page on severity rather than on message text:
+//
+//	errors.EscalateSeverity(errors.SeverityAlert, errors.MatchStatus(http.StatusUnauthorized, http.StatusForbidden))
+//
+// When several registrations match, the highest severity wins.
+func EscalateSeverity(severity string, match func(error) bool) {
+	if match == nil || severityRank[severity] == 0 {
+		return
+	}
+	escalationsMu.Lock()
+	defer escalationsMu.Unlock()
+	escalations = append(escalations, escalation{match: match, severity: severity})
+}
+
+// ResetSeverities removes all registrations made with EscalateSeverity.
+func ResetSeverities() {
+	escalationsMu.Lock()
+	defer escalationsMu.Unlock()
+	escalations = nil
+}
+
+// SeverityOf returns the severity HandleError logs err at: the highest
+// severity registered for it with EscalateSeverity, or SeverityError.
+func SeverityOf(err error) string {
+	escalationsMu.RLock()
+	defer escalationsMu.RUnlock()
+	severity := SeverityError
+	for _, e := range escalations {
+		if severityRank[e.severity] > severityRank[severity] && e.match(err) {
+			severity = e.severity
+		}
+	}
+	return severity
+}
+
+// MatchStatus matches errors whose status, as inferred by StatusFromError,
+// is one of codes.
+func MatchStatus(codes ...int) func(error) bool {
+	return func(err error) bool {
+		return slices.Contains(codes, StatusFromError(err))
+	}
+}
+
+// MatchErrorCode matches errors wrapping a *GoogleAPIError whose ErrorCode
+// is one of codes, for example "RESOURCE_EXHAUSTED".
+func MatchErrorCode(codes ...string) func(error) bool {
+	return func(err error) bool {
+		var apiErr *GoogleAPIError
+		return stderrors.As(err, &apiErr) && slices.Contains(codes, apiErr.ErrorCode)
+	}
+}
+
+// MatchClass matches errors whose ClassOf is one of classes.
+func MatchClass(classes ...string) func(error) bool {
+	return func(err error) bool {
+		return slices.Contains(classes, ClassOf(err))
+	}
+}
+
+// LabelLogger is implemented by loggers that can attach labels to an entry,
+// such as the adapter returned by logging.ErrorMessages. HandleError uses it
+// to log the error ID as the error_id label, so entries can be filtered on it.
+type LabelLogger interface {
+	LogSeverityLabels(severity, message string, labels map[string]string)
+}
+
+// logError logs err through logger at its severity, with errorID as the
+// error_id label, or appended to the message when logger does not implement
+// LabelLogger.
+func logError(logger interface{ LogError(string) }, err error, errorID string) {
+	severity := SeverityOf(err)
+	if l, ok := logger.(LabelLogger); ok {
+		l.LogSeverityLabels(severity, err.Error(), map[string]string{"error_id": errorID})
+		return
+	}
+	message := fmt.Sprintf("%s [error_id=%s]", err.Error(), errorID)
+	if severity != SeverityError {
+		if l, ok := logger.(SeverityLogger); ok {
+			l.LogSeverity(severity, message)
+			return
+		}
+	}
+	logger.LogError(message)
+}
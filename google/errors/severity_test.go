@@ -0,0 +1,69 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package errors
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// MockSeverityLogger records the severity of every message.
+type MockSeverityLogger struct {
+	MockLogger
+	Severities []string
+}
+
+func (ml *MockSeverityLogger) LogSeverity(severity, message string) {
+	ml.Severities = append(ml.Severities, severity)
+	ml.Messages = append(ml.Messages, message)
+}
+
+func TestSeverityOf(t *testing.T) {
+	defer ResetSeverities()
+	EscalateSeverity(SeverityCritical, MatchStatus(http.StatusUnauthorized, http.StatusForbidden))
+	EscalateSeverity(SeverityAlert, MatchErrorCode("RESOURCE_EXHAUSTED"))
+	EscalateSeverity(SeverityCritical, MatchClass(ClassPanic))
+
+	quota := &GoogleAPIError{StatusCode: http.StatusForbidden, ErrorCode: "RESOURCE_EXHAUSTED"}
+	assert.Equal(t, SeverityAlert, SeverityOf(fmt.Errorf("billing export: %w", quota)))
+	assert.Equal(t, SeverityCritical, SeverityOf(&GoogleAPIError{StatusCode: http.StatusUnauthorized}))
+	assert.Equal(t, SeverityCritical, SeverityOf(&PanicError{Value: "boom"}))
+	assert.Equal(t, SeverityError, SeverityOf(&GoogleAPIError{StatusCode: http.StatusNotFound}))
+}
+
+func TestHandleErrorEscalatesSeverity(t *testing.T) {
+	defer ResetSeverities()
+	EscalateSeverity(SeverityAlert, MatchStatus(http.StatusForbidden))
+
+	logger := &MockSeverityLogger{}
+	HandleError(logger, httptest.NewRecorder(), &GoogleAPIError{StatusCode: http.StatusForbidden, Body: "Not Authorized to access this resource/api"})
+	HandleError(logger, httptest.NewRecorder(), &GoogleAPIError{StatusCode: http.StatusNotFound, Body: "Not Found"})
+
+	assert.Equal(t, []string{SeverityAlert}, logger.Severities)
+	assert.Len(t, logger.Messages, 2)
+
+	plain := &MockLogger{}
+	HandleError(plain, httptest.NewRecorder(), &GoogleAPIError{StatusCode: http.StatusForbidden, Body: "Forbidden"})
+	assert.Len(t, plain.Messages, 1)
+}
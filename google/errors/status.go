@@ -0,0 +1,132 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package errors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StatusClientClosedRequest is the non-standard status for requests whose
+// client went away before a response was written.
+const StatusClientClosedRequest = 499
+
+// ValidationError reports invalid client input. It maps to 400 Bad Request,
+// and its message is safe to return to the client.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Message)
+}
+
+// StatusFromError infers the HTTP status for err by walking its wrap chain,
+// including errors joined with errors.Join. The outermost error of a known
+// kind decides:
+//
+//   - *GoogleAPIError: its StatusCode
+//   - *ValidationError: 400
+//   - errors with a StatusCode() int method, such as *PanicError: that status
+//   - context.DeadlineExceeded: 504, context.Canceled: 499
+//   - gRPC status errors: the HTTP equivalent of the code
+//
+// A nil error is 200 and an error of no known kind is 500.
+func StatusFromError(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	if code, ok := statusOf(err); ok {
+		return code
+	}
+	return http.StatusInternalServerError
+}
+
+// statusOf checks err and then its wrapped errors for a known kind.
+func statusOf(err error) (int, bool) {
+	switch e := err.(type) {
+	case *GoogleAPIError:
+		return e.StatusCode, true
+	case *ValidationError:
+		return http.StatusBadRequest, true
+	case interface{ StatusCode() int }:
+		return e.StatusCode(), true
+	case interface{ GRPCStatus() *status.Status }:
+		return httpStatusFromCode(e.GRPCStatus().Code()), true
+	}
+	switch err {
+	case context.DeadlineExceeded:
+		return http.StatusGatewayTimeout, true
+	case context.Canceled:
+		return StatusClientClosedRequest, true
+	}
+
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		if next := u.Unwrap(); next != nil {
+			return statusOf(next)
+		}
+	case interface{ Unwrap() []error }:
+		for _, next := range u.Unwrap() {
+			if code, ok := statusOf(next); ok {
+				return code, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// httpStatusFromCode maps a gRPC code to an HTTP status as the Google APIs do.
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return StatusClientClosedRequest
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusFromError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"Nil", nil, http.StatusOK},
+		{"Generic", errors.New("boom"), http.StatusInternalServerError},
+		{"GoogleAPIError", &GoogleAPIError{StatusCode: http.StatusNotFound}, http.StatusNotFound},
+		{"Wrapped GoogleAPIError", fmt.Errorf("loading user: %w", &GoogleAPIError{StatusCode: http.StatusForbidden}), http.StatusForbidden},
+		{"ValidationError", fmt.Errorf("decoding: %w", &ValidationError{Field: "email", Message: "is required"}), http.StatusBadRequest},
+		{"Deadline", fmt.Errorf("calling API: %w", context.DeadlineExceeded), http.StatusGatewayTimeout},
+		{"Canceled", context.Canceled, StatusClientClosedRequest},
+		{"gRPC", fmt.Errorf("rpc: %w", status.Error(codes.NotFound, "missing")), http.StatusNotFound},
+		{"gRPC unavailable", status.Error(codes.Unavailable, "down"), http.StatusServiceUnavailable},
+		{"Panic", &PanicError{Value: &GoogleAPIError{StatusCode: http.StatusNotFound}}, http.StatusInternalServerError},
+		{"Joined", errors.Join(errors.New("first"), &ValidationError{Message: "bad"}), http.StatusBadRequest},
+		{"Outermost wins", &wrappingAPIError{GoogleAPIError{StatusCode: http.StatusConflict}, context.DeadlineExceeded}, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, StatusFromError(tt.err))
+		})
+	}
+}
+
+// wrappingAPIError is an API error type that also wraps its cause.
+type wrappingAPIError struct {
+	GoogleAPIError
+	cause error
+}
+
+func (e *wrappingAPIError) Unwrap() error { return e.cause }
+
+func (e *wrappingAPIError) StatusCode() int { return e.GoogleAPIError.StatusCode }
+
+func TestHandleErrorWrappedErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode int
+		expectedBody string
+	}{
+		{"Wrapped GoogleAPIError", fmt.Errorf("loading user: %w", &GoogleAPIError{StatusCode: http.StatusNotFound, Body: "User not found"}), http.StatusNotFound, "User not found"},
+		{"ValidationError", &ValidationError{Field: "email", Message: "is required"}, http.StatusBadRequest, "invalid email: is required"},
+		{"Deadline", fmt.Errorf("calling API: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, "Gateway Timeout"},
+		{"Canceled", context.Canceled, StatusClientClosedRequest, "Client closed request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &MockLogger{}
+			recorder := httptest.NewRecorder()
+			HandleError(logger, recorder, tt.err)
+
+			result := recorder.Result()
+			defer result.Body.Close()
+			body, _ := io.ReadAll(result.Body)
+
+			assert.Equal(t, tt.expectedCode, result.StatusCode)
+			assert.True(t, strings.HasPrefix(string(body), tt.expectedBody), "unexpected body %q", body)
+			if assert.Len(t, logger.Messages, 1) {
+				assert.Contains(t, logger.Messages[0], tt.err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package flags
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// EnvSource reads flags from environment variables named Prefix + NAME, with
+// tenant overrides in Prefix + NAME + "__" + TENANT. Names are upper-cased and
+// characters other than letters and digits become underscores, so the flag
+// "new-ui" for tenant "acme" reads FLAG_NEW_UI__ACME with the default prefix.
+type EnvSource struct {
+	// Prefix defaults to "FLAG_".
+	Prefix string
+}
+
+var reEnvName = regexp.MustCompile(`[^A-Z0-9]`)
+
+// Lookup implements Source.
+func (s EnvSource) Lookup(_ context.Context, name, tenant string) (string, bool, error) {
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = "FLAG_"
+	}
+	key := prefix + envName(name)
+	if tenant != "" {
+		key += "__" + envName(tenant)
+	}
+	value, ok := os.LookupEnv(key)
+	return value, ok, nil
+}
+
+func envName(s string) string {
+	return reEnvName.ReplaceAllString(strings.ToUpper(s), "_")
+}
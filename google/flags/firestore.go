@@ -0,0 +1,162 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// firestoreBaseURL is the Firestore REST endpoint.
+const firestoreBaseURL = "https://firestore.googleapis.com/v1"
+
+// FirestoreSource reads flags from Firestore documents through the REST API.
+// Each flag is a document in Collection whose ID is the flag name, with a
+// "value" field for the global value and an optional "tenants" map field
+// holding per-tenant overrides:
+//
+//	flags/new-ui: {value: false, tenants: {acme: true}}
+type FirestoreSource struct {
+	// Client must be authorized for Firestore, e.g. from serviceaccount.NewHTTPClient.
+	Client    *http.Client
+	ProjectID string
+	// Database defaults to "(default)".
+	Database string
+	// Collection defaults to "flags".
+	Collection string
+	// BaseURL overrides the Firestore endpoint, e.g. for the emulator.
+	BaseURL string
+}
+
+// firestoreValue is a Firestore REST value; only scalar and map kinds are used.
+type firestoreValue struct {
+	StringValue  *string  `json:"stringValue"`
+	BooleanValue *bool    `json:"booleanValue"`
+	IntegerValue *string  `json:"integerValue"`
+	DoubleValue  *float64 `json:"doubleValue"`
+	MapValue     *struct {
+		Fields map[string]firestoreValue `json:"fields"`
+	} `json:"mapValue"`
+}
+
+// Lookup implements Source.
+func (s *FirestoreSource) Lookup(ctx context.Context, name, tenant string) (string, bool, error) {
+	fields, err := s.document(ctx, name)
+	if err != nil {
+		return "", false, err
+	}
+	return resolveField(fields, tenant)
+}
+
+// lookupWithGlobal resolves the tenant override and the global value from a
+// single read of the flag's document.
+func (s *FirestoreSource) lookupWithGlobal(ctx context.Context, name, tenant string) (override, global sourceResult) {
+	fields, err := s.document(ctx, name)
+	if err != nil {
+		return sourceResult{err: err}, sourceResult{err: err}
+	}
+	override.value, override.ok, override.err = resolveField(fields, tenant)
+	global.value, global.ok, global.err = resolveField(fields, "")
+	return override, global
+}
+
+// document reads the fields of the flag's document; a missing document has no fields.
+func (s *FirestoreSource) document(ctx context.Context, name string) (map[string]firestoreValue, error) {
+	baseURL, database, collection := s.BaseURL, s.Database, s.Collection
+	if baseURL == "" {
+		baseURL = firestoreBaseURL
+	}
+	if database == "" {
+		database = "(default)"
+	}
+	if collection == "" {
+		collection = "flags"
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	docURL := fmt.Sprintf("%s/projects/%s/databases/%s/documents/%s/%s",
+		baseURL, url.PathEscape(s.ProjectID), url.PathEscape(database), url.PathEscape(collection), url.PathEscape(name))
+	req, err := http.NewRequestWithContext(ctx, "GET", docURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating Firestore request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error reading flag %s from Firestore: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("Firestore request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var doc struct {
+		Fields map[string]firestoreValue `json:"fields"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("error decoding flag %s: %w", name, err)
+	}
+	return doc.Fields, nil
+}
+
+// resolveField returns the global value, or the tenant's entry of the tenants map.
+func resolveField(fields map[string]firestoreValue, tenant string) (string, bool, error) {
+	field, ok := fields["value"]
+	if tenant != "" {
+		tenants, hasTenants := fields["tenants"]
+		if !hasTenants || tenants.MapValue == nil {
+			return "", false, nil
+		}
+		field, ok = tenants.MapValue.Fields[tenant]
+	}
+	if !ok {
+		return "", false, nil
+	}
+	return field.scalar()
+}
+
+// scalar renders a scalar Firestore value as a flag string.
+func (v firestoreValue) scalar() (string, bool, error) {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue, true, nil
+	case v.BooleanValue != nil:
+		return strconv.FormatBool(*v.BooleanValue), true, nil
+	case v.IntegerValue != nil:
+		return *v.IntegerValue, true, nil
+	case v.DoubleValue != nil:
+		return strconv.FormatFloat(*v.DoubleValue, 'f', -1, 64), true, nil
+	default:
+		return "", false, fmt.Errorf("unsupported Firestore value type for flag")
+	}
+}
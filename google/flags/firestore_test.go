@@ -0,0 +1,89 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package flags
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFirestoreSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/projects/my-project/databases/(default)/documents/flags/new-ui":
+			w.Write([]byte(`{"fields": {"value": {"booleanValue": false}, "tenants": {"mapValue": {"fields": {"acme": {"booleanValue": true}}}}}}`))
+		case "/projects/my-project/databases/(default)/documents/flags/limit":
+			w.Write([]byte(`{"fields": {"value": {"integerValue": "25"}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	source := &FirestoreSource{Client: server.Client(), ProjectID: "my-project", BaseURL: server.URL}
+	f := New(WithSource(source))
+	ctx := context.Background()
+
+	if f.Bool(ctx, "new-ui", true) {
+		t.Errorf("Expected the global Firestore value")
+	}
+	if !f.Bool(WithTenant(ctx, "acme"), "new-ui", false) {
+		t.Errorf("Expected the tenant override from the tenants map")
+	}
+	if got := f.Int(ctx, "limit", 0); got != 25 {
+		t.Errorf("Expected limit 25, got %d", got)
+	}
+	if got := f.String(ctx, "missing", "default"); got != "default" {
+		t.Errorf("Expected the default for a missing document, got '%s'", got)
+	}
+}
+
+func TestFirestoreSourceTenantFallback(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"fields": {"value": {"stringValue": "global"}, "tenants": {"mapValue": {"fields": {"acme": {"stringValue": "acme"}}}}}}`))
+	}))
+	defer server.Close()
+
+	source := &FirestoreSource{Client: server.Client(), ProjectID: "my-project", BaseURL: server.URL}
+	f := New(WithSource(source), WithTTL(0))
+
+	if got := f.String(WithTenant(context.Background(), "other"), "mode", ""); got != "global" {
+		t.Errorf("Expected the global value for a tenant without an override, got '%s'", got)
+	}
+	if requests != 1 {
+		t.Errorf("Expected the document to be read once, got %d requests", requests)
+	}
+}
+
+func TestFirestoreSourceError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "permission denied", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	source := &FirestoreSource{Client: server.Client(), ProjectID: "my-project", BaseURL: server.URL}
+	if _, _, err := source.Lookup(context.Background(), "new-ui", ""); err == nil {
+		t.Errorf("Expected an error for a 403 response")
+	}
+}
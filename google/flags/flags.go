@@ -0,0 +1,273 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package flags
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is how long evaluated flag values are cached.
+const DefaultTTL = time.Minute
+
+// Source looks up raw flag values. Lookup returns ok=false when the source has
+// no value for the flag; tenant is empty for the global value.
+type Source interface {
+	Lookup(ctx context.Context, name, tenant string) (value string, ok bool, err error)
+}
+
+// Logger is the subset of the structured logger used to report flag evaluations.
+type Logger interface {
+	LogDebug(ctx context.Context, msg string, args ...any)
+	LogWarning(ctx context.Context, msg string, args ...any)
+}
+
+// Option configures Flags.
+type Option func(*Flags)
+
+// WithSource adds a source. Sources are consulted in the order they are added.
+func WithSource(source Source) Option {
+	return func(f *Flags) {
+		f.sources = append(f.sources, source)
+	}
+}
+
+// WithTTL sets how long evaluated values are cached. Zero disables caching.
+func WithTTL(ttl time.Duration) Option {
+	return func(f *Flags) {
+		f.ttl = ttl
+	}
+}
+
+// WithLogger logs every call of Bool, Int, and String at DEBUG, with the
+// value returned and whether it came from the cache, a source, or the
+// default, and logs source failures and invalid values at WARNING.
+func WithLogger(logger Logger) Option {
+	return func(f *Flags) {
+		f.logger = logger
+	}
+}
+
+// Flags evaluates typed feature flags with per-tenant overrides.
+type Flags struct {
+	sources []Source
+	ttl     time.Duration
+	logger  Logger
+
+	mu        sync.Mutex
+	cache     map[cacheKey]cacheEntry
+	nextPrune time.Time
+}
+
+type cacheKey struct {
+	name   string
+	tenant string
+}
+
+type cacheEntry struct {
+	value   string
+	ok      bool
+	expires time.Time
+}
+
+// New creates a Flags. Without sources, every lookup returns its default.
+func New(opts ...Option) *Flags {
+	f := &Flags{ttl: DefaultTTL, cache: make(map[cacheKey]cacheEntry)}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a context whose flag lookups use the tenant's overrides.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant set by WithTenant.
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// Origins of an evaluated value, as logged by WithLogger.
+const (
+	originCache   = "cache"
+	originSource  = "source"
+	originDefault = "default"
+)
+
+// Bool returns the flag as a bool, or def when unset or not a valid bool.
+func (f *Flags) Bool(ctx context.Context, name string, def bool) bool {
+	raw, origin, ok := f.lookup(ctx, name)
+	if !ok {
+		f.logEvaluation(ctx, name, def, originDefault)
+		return def
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		f.warnInvalid(ctx, name, raw, "bool")
+		f.logEvaluation(ctx, name, def, originDefault)
+		return def
+	}
+	f.logEvaluation(ctx, name, value, origin)
+	return value
+}
+
+// Int returns the flag as an int, or def when unset or not a valid int.
+func (f *Flags) Int(ctx context.Context, name string, def int) int {
+	raw, origin, ok := f.lookup(ctx, name)
+	if !ok {
+		f.logEvaluation(ctx, name, def, originDefault)
+		return def
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		f.warnInvalid(ctx, name, raw, "int")
+		f.logEvaluation(ctx, name, def, originDefault)
+		return def
+	}
+	f.logEvaluation(ctx, name, value, origin)
+	return value
+}
+
+// String returns the flag as a string, or def when unset.
+func (f *Flags) String(ctx context.Context, name string, def string) string {
+	raw, origin, ok := f.lookup(ctx, name)
+	if !ok {
+		f.logEvaluation(ctx, name, def, originDefault)
+		return def
+	}
+	f.logEvaluation(ctx, name, raw, origin)
+	return raw
+}
+
+// globalSource is implemented by sources that read a tenant override and the
+// global value together, such as FirestoreSource with its single document.
+type globalSource interface {
+	lookupWithGlobal(ctx context.Context, name, tenant string) (override, global sourceResult)
+}
+
+// sourceResult is the outcome of a lookup in one source.
+type sourceResult struct {
+	value string
+	ok    bool
+	err   error
+}
+
+// lookup resolves the tenant override first and falls back to the global
+// value. origin tells whether the value came from the cache or a source.
+func (f *Flags) lookup(ctx context.Context, name string) (value, origin string, ok bool) {
+	tenant := TenantFromContext(ctx)
+	if tenant == "" {
+		return f.cached(ctx, name, "", nil)
+	}
+	// globals holds the global values read along with the overrides, so a
+	// globalSource is not read a second time for the fallback.
+	globals := make([]*sourceResult, len(f.sources))
+	if value, origin, ok := f.cached(ctx, name, tenant, globals); ok {
+		return value, origin, true
+	}
+	return f.cached(ctx, name, "", globals)
+}
+
+// cached returns the cached value of the flag, evaluating the sources when it
+// is missing or expired. Results of an evaluation in which a source failed
+// are not cached, so the failure is retried on the next lookup.
+func (f *Flags) cached(ctx context.Context, name, tenant string, globals []*sourceResult) (string, string, bool) {
+	key := cacheKey{name: name, tenant: tenant}
+	now := time.Now()
+
+	f.mu.Lock()
+	entry, hit := f.cache[key]
+	f.mu.Unlock()
+	if hit && now.Before(entry.expires) {
+		return entry.value, originCache, entry.ok
+	}
+
+	value, ok, failed := f.fromSources(ctx, name, tenant, globals)
+	if f.ttl > 0 && !failed {
+		f.mu.Lock()
+		f.pruneLocked(now)
+		f.cache[key] = cacheEntry{value: value, ok: ok, expires: now.Add(f.ttl)}
+		f.mu.Unlock()
+	}
+	return value, originSource, ok
+}
+
+// pruneLocked removes expired entries, at most once per TTL, so flags and
+// tenants that are no longer looked up do not stay in the cache. f.mu must
+// be held.
+func (f *Flags) pruneLocked(now time.Time) {
+	if now.Before(f.nextPrune) {
+		return
+	}
+	for key, entry := range f.cache {
+		if !now.Before(entry.expires) {
+			delete(f.cache, key)
+		}
+	}
+	f.nextPrune = now.Add(f.ttl)
+}
+
+// fromSources returns the first value found in the sources, and whether a
+// source failed before it was found.
+func (f *Flags) fromSources(ctx context.Context, name, tenant string, globals []*sourceResult) (value string, ok, failed bool) {
+	for i, source := range f.sources {
+		var result sourceResult
+		switch gs, isGlobal := source.(globalSource); {
+		case tenant == "" && globals != nil && globals[i] != nil:
+			result = *globals[i]
+		case tenant != "" && globals != nil && isGlobal:
+			var global sourceResult
+			result, global = gs.lookupWithGlobal(ctx, name, tenant)
+			globals[i] = &global
+		default:
+			result.value, result.ok, result.err = source.Lookup(ctx, name, tenant)
+		}
+		if result.err != nil {
+			failed = true
+			if f.logger != nil {
+				f.logger.LogWarning(ctx, "Flag source lookup failed", "flag", name, "tenant", tenant, "error", result.err)
+			}
+			continue
+		}
+		if result.ok {
+			return result.value, true, failed
+		}
+	}
+	return "", false, failed
+}
+
+func (f *Flags) logEvaluation(ctx context.Context, name string, value any, origin string) {
+	if f.logger != nil {
+		f.logger.LogDebug(ctx, "Flag evaluated", "flag", name, "tenant", TenantFromContext(ctx), "value", value, "origin", origin)
+	}
+}
+
+func (f *Flags) warnInvalid(ctx context.Context, name, raw, kind string) {
+	if f.logger != nil {
+		f.logger.LogWarning(ctx, "Invalid flag value, using default", "flag", name, "value", raw, "type", kind)
+	}
+}
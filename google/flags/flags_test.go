@@ -0,0 +1,210 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mapSource serves values keyed by "name" or "name/tenant" and counts lookups.
+type mapSource struct {
+	values  map[string]string
+	err     error
+	lookups int
+}
+
+func (s *mapSource) Lookup(_ context.Context, name, tenant string) (string, bool, error) {
+	s.lookups++
+	if s.err != nil {
+		return "", false, s.err
+	}
+	key := name
+	if tenant != "" {
+		key += "/" + tenant
+	}
+	value, ok := s.values[key]
+	return value, ok, nil
+}
+
+type recordingLogger struct {
+	debug   []string
+	warning []string
+}
+
+func (l *recordingLogger) LogDebug(_ context.Context, msg string, args ...any) {
+	l.debug = append(l.debug, strings.TrimSpace(fmt.Sprintln(append([]any{msg}, args...)...)))
+}
+
+func (l *recordingLogger) LogWarning(_ context.Context, msg string, args ...any) {
+	l.warning = append(l.warning, strings.TrimSpace(fmt.Sprintln(append([]any{msg}, args...)...)))
+}
+
+func TestTypedLookups(t *testing.T) {
+	source := &mapSource{values: map[string]string{"enabled": "true", "limit": "42", "mode": "fast", "broken": "nope"}}
+	f := New(WithSource(source))
+	ctx := context.Background()
+
+	if !f.Bool(ctx, "enabled", false) {
+		t.Errorf("Expected enabled to be true")
+	}
+	if got := f.Int(ctx, "limit", 1); got != 42 {
+		t.Errorf("Expected limit 42, got %d", got)
+	}
+	if got := f.String(ctx, "mode", "slow"); got != "fast" {
+		t.Errorf("Expected mode 'fast', got '%s'", got)
+	}
+	if got := f.Int(ctx, "missing", 7); got != 7 {
+		t.Errorf("Expected default 7, got %d", got)
+	}
+	if got := f.Bool(ctx, "broken", true); !got {
+		t.Errorf("Expected default for an invalid bool, got %v", got)
+	}
+}
+
+func TestTenantOverrides(t *testing.T) {
+	source := &mapSource{values: map[string]string{"new-ui": "false", "new-ui/acme": "true"}}
+	f := New(WithSource(source))
+
+	if f.Bool(context.Background(), "new-ui", true) {
+		t.Errorf("Expected the global value without a tenant")
+	}
+	if !f.Bool(WithTenant(context.Background(), "acme"), "new-ui", false) {
+		t.Errorf("Expected the acme override")
+	}
+	if f.Bool(WithTenant(context.Background(), "other"), "new-ui", true) {
+		t.Errorf("Expected tenants without an override to get the global value")
+	}
+}
+
+func TestSourceOrder(t *testing.T) {
+	first := &mapSource{values: map[string]string{"mode": "first"}}
+	second := &mapSource{values: map[string]string{"mode": "second", "extra": "second"}}
+	f := New(WithSource(first), WithSource(second))
+
+	if got := f.String(context.Background(), "mode", ""); got != "first" {
+		t.Errorf("Expected 'first', got '%s'", got)
+	}
+	if got := f.String(context.Background(), "extra", ""); got != "second" {
+		t.Errorf("Expected fallback to the second source, got '%s'", got)
+	}
+}
+
+func TestCaching(t *testing.T) {
+	source := &mapSource{values: map[string]string{"mode": "fast"}}
+	f := New(WithSource(source), WithTTL(time.Hour))
+
+	for i := 0; i < 3; i++ {
+		f.String(context.Background(), "mode", "")
+		f.String(context.Background(), "missing", "")
+	}
+	if source.lookups != 2 {
+		t.Errorf("Expected 2 source lookups with caching, got %d", source.lookups)
+	}
+
+	uncached := New(WithSource(source), WithTTL(0))
+	source.lookups = 0
+	for i := 0; i < 3; i++ {
+		uncached.String(context.Background(), "mode", "")
+	}
+	if source.lookups != 3 {
+		t.Errorf("Expected 3 source lookups without caching, got %d", source.lookups)
+	}
+}
+
+func TestCachePruning(t *testing.T) {
+	source := &mapSource{values: map[string]string{}}
+	f := New(WithSource(source), WithTTL(time.Millisecond))
+
+	for i := 0; i < 10; i++ {
+		f.String(WithTenant(context.Background(), fmt.Sprintf("tenant-%d", i)), "mode", "")
+	}
+	time.Sleep(2 * time.Millisecond)
+	f.String(context.Background(), "other", "")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.cache) != 1 {
+		t.Errorf("Expected expired entries to be pruned, got %d entries", len(f.cache))
+	}
+}
+
+func TestFailedLookupsNotCached(t *testing.T) {
+	failing := &mapSource{err: errors.New("unavailable")}
+	fallback := &mapSource{values: map[string]string{"mode": "fallback"}}
+	f := New(WithSource(failing), WithSource(fallback), WithTTL(time.Hour))
+
+	for i := 0; i < 2; i++ {
+		if got := f.String(context.Background(), "mode", ""); got != "fallback" {
+			t.Errorf("Expected 'fallback', got '%s'", got)
+		}
+	}
+	if failing.lookups != 2 {
+		t.Errorf("Expected the failed source to be retried, got %d lookups", failing.lookups)
+	}
+
+	failing.err = nil
+	failing.values = map[string]string{"mode": "recovered"}
+	if got := f.String(context.Background(), "mode", ""); got != "recovered" {
+		t.Errorf("Expected the recovered value, got '%s'", got)
+	}
+}
+
+func TestEvaluationLogging(t *testing.T) {
+	logger := &recordingLogger{}
+	f := New(WithSource(&mapSource{values: map[string]string{"mode": "fast"}}), WithLogger(logger))
+	f.String(context.Background(), "mode", "")
+	f.String(context.Background(), "mode", "")
+	f.Int(context.Background(), "limit", 5)
+
+	expected := []string{
+		"Flag evaluated flag mode tenant  value fast origin source",
+		"Flag evaluated flag mode tenant  value fast origin cache",
+		"Flag evaluated flag limit tenant  value 5 origin default",
+	}
+	if fmt.Sprint(logger.debug) != fmt.Sprint(expected) {
+		t.Fatalf("Expected debug entries %q, got %q", expected, logger.debug)
+	}
+
+	failing := New(WithSource(&mapSource{err: errors.New("unavailable")}), WithLogger(logger))
+	if got := failing.String(context.Background(), "mode", "default"); got != "default" {
+		t.Errorf("Expected the default when the source fails, got '%s'", got)
+	}
+	if len(logger.warning) != 1 {
+		t.Errorf("Expected 1 warning for the failed source, got %d", len(logger.warning))
+	}
+}
+
+func TestEnvSource(t *testing.T) {
+	t.Setenv("FLAG_NEW_UI", "false")
+	t.Setenv("FLAG_NEW_UI__ACME_CORP", "true")
+	f := New(WithSource(EnvSource{}))
+
+	if f.Bool(context.Background(), "new-ui", true) {
+		t.Errorf("Expected FLAG_NEW_UI to be used")
+	}
+	if !f.Bool(WithTenant(context.Background(), "acme-corp"), "new-ui", false) {
+		t.Errorf("Expected FLAG_NEW_UI__ACME_CORP to be used")
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/duizendstra/go/google/flags
+
+go 1.23.2
@@ -0,0 +1,173 @@
+// async_writer.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultAsyncQueueSize is the number of entries an AsyncWriter buffers by default.
+const DefaultAsyncQueueSize = 4096
+
+// DropPolicy decides what an AsyncWriter does when its queue is full.
+type DropPolicy int
+
+const (
+	// DropNewest discards the entry being written (default).
+	DropNewest DropPolicy = iota
+	// DropOldest discards the oldest queued entry to make room.
+	DropOldest
+	// Block waits until there is room, applying backpressure to the caller.
+	Block
+)
+
+// AsyncWriter is an io.Writer that queues writes and flushes them to the
+// underlying writer from a background goroutine, so logging in hot paths does
+// not block on stderr. Pass it as the writer of NewStructuredLogger and call
+// Close on shutdown. Writes after Close go to the underlying writer directly.
+type AsyncWriter struct {
+	out    io.Writer
+	policy DropPolicy
+	queue  chan []byte
+	flush  chan chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+
+	mu      sync.RWMutex // guards closed against in-flight writes
+	closed  bool
+	outMu   sync.Mutex
+	dropped atomic.Int64
+}
+
+// NewAsyncWriter starts an AsyncWriter writing to out with a queue of
+// queueSize entries (DefaultAsyncQueueSize when zero or negative).
+func NewAsyncWriter(out io.Writer, queueSize int, policy DropPolicy) *AsyncWriter {
+	if queueSize <= 0 {
+		queueSize = DefaultAsyncQueueSize
+	}
+	w := &AsyncWriter{
+		out:    out,
+		policy: policy,
+		queue:  make(chan []byte, queueSize),
+		flush:  make(chan chan struct{}),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write queues a copy of p. It never fails; dropped entries are counted by Dropped.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return w.write(p)
+	}
+
+	entry := append([]byte(nil), p...)
+	switch w.policy {
+	case Block:
+		w.queue <- entry
+	case DropOldest:
+		for {
+			select {
+			case w.queue <- entry:
+				return len(p), nil
+			default:
+			}
+			select {
+			case <-w.queue:
+				w.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		select {
+		case w.queue <- entry:
+		default:
+			w.dropped.Add(1)
+		}
+	}
+	return len(p), nil
+}
+
+// Flush waits until the entries queued before the call are written, or ctx is done.
+func (w *AsyncWriter) Flush(ctx context.Context) error {
+	written := make(chan struct{})
+	select {
+	case w.flush <- written:
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-written:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close writes the queued entries and stops the background goroutine. It
+// waits until the queue is drained or ctx is done.
+func (w *AsyncWriter) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.stop)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Dropped returns the number of entries discarded because the queue was full.
+func (w *AsyncWriter) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+	for {
+		select {
+		case entry := <-w.queue:
+			w.write(entry)
+		case written := <-w.flush:
+			w.drain()
+			close(written)
+		case <-w.stop:
+			w.drain()
+			return
+		}
+	}
+}
+
+// drain writes the entries currently queued.
+func (w *AsyncWriter) drain() {
+	for {
+		select {
+		case entry := <-w.queue:
+			w.write(entry)
+		default:
+			return
+		}
+	}
+}
+
+func (w *AsyncWriter) write(p []byte) (int, error) {
+	w.outMu.Lock()
+	defer w.outMu.Unlock()
+	return w.out.Write(p)
+}
@@ -0,0 +1,80 @@
+// async_writer_test.go
+
+package structured
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// blockingWriter blocks every write until release is closed.
+type blockingWriter struct {
+	release chan struct{}
+	buf     syncBuffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return w.buf.Write(p)
+}
+
+func TestAsyncWriter(t *testing.T) {
+	var out syncBuffer
+	w := NewAsyncWriter(&out, 0, DropNewest)
+	sl := NewStructuredLogger("", "test-component", nil, w)
+
+	for i := 0; i < 10; i++ {
+		sl.LogInfo(context.Background(), "Queued entry", "i", i)
+	}
+	if err := w.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush returned unexpected error: %v", err)
+	}
+	if n := strings.Count(out.String(), "Queued entry"); n != 10 {
+		t.Errorf("Expected 10 entries after Flush, got %d", n)
+	}
+
+	if err := w.Close(context.Background()); err != nil {
+		t.Fatalf("Close returned unexpected error: %v", err)
+	}
+	sl.LogInfo(context.Background(), "After close")
+	if !strings.Contains(out.String(), "After close") {
+		t.Errorf("Expected writes after Close to go to the underlying writer")
+	}
+}
+
+func TestAsyncWriterDropPolicies(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   DropPolicy
+		expected []string
+	}{
+		{"Drop newest", DropNewest, []string{"0", "1", "2"}},
+		{"Drop oldest", DropOldest, []string{"0", "3", "4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &blockingWriter{release: make(chan struct{})}
+			w := NewAsyncWriter(out, 2, tt.policy)
+
+			w.Write([]byte("0\n"))
+			// Wait until the background goroutine is blocked writing entry 0.
+			for len(w.queue) != 0 {
+				runtime.Gosched()
+			}
+			for _, s := range []string{"1\n", "2\n", "3\n", "4\n"} {
+				w.Write([]byte(s))
+			}
+			if w.Dropped() != 2 {
+				t.Errorf("Expected 2 dropped entries, got %d", w.Dropped())
+			}
+
+			close(out.release)
+			w.Close(context.Background())
+			if got := strings.Fields(out.buf.String()); strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected entries %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+// baggage.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// WithBaggageLabels adds the OpenTelemetry baggage members with the given
+// keys as labels, so cross-service metadata such as an origin channel flows
+// into the logs of every service. The baggage is read from the context of
+// each call, falling back to the W3C baggage header of the request the logger
+// was created for. Logger labels and per-call labels take precedence.
+func WithBaggageLabels(keys ...string) Option {
+	return func(sl *StructuredLogger) {
+		sl.baggageKeys = append(sl.baggageKeys, keys...)
+	}
+}
+
+// baggageLabels prepends the allowed baggage members to the per-call labels.
+func (sl *StructuredLogger) baggageLabels(ctx context.Context, labels []LabelArg) []LabelArg {
+	bag := baggage.FromContext(ctx)
+	var found []LabelArg
+	for _, key := range sl.baggageKeys {
+		member := bag.Member(key)
+		if member.Key() == "" {
+			member = sl.requestBaggage.Member(key)
+		}
+		if member.Key() == "" {
+			continue
+		}
+		if _, ok := sl.labels[key]; ok {
+			continue
+		}
+		found = append(found, LabelArg{Key: key, Value: member.Value()})
+	}
+	if len(found) == 0 {
+		return labels
+	}
+	return append(found, labels...)
+}
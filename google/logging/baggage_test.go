@@ -0,0 +1,43 @@
+// baggage_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+func TestWithBaggageLabels(t *testing.T) {
+	r := httptest.NewRequest("GET", "/orders", nil)
+	r.Header.Set("baggage", "channel=mobile,tenant=acme,secret=hidden")
+
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", r, &buf, WithBaggageLabels("channel", "tenant", "campaign"))
+
+	campaign, _ := baggage.NewMember("campaign", "spring")
+	tenant, _ := baggage.NewMember("tenant", "globex")
+	bag, _ := baggage.New(campaign, tenant)
+	ctx := baggage.ContextWithBaggage(context.Background(), bag)
+
+	sl.LogInfo(ctx, "Order created")
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	labels, _ := loggedEntry[keyLabels].(map[string]interface{})
+	expected := map[string]string{"channel": "mobile", "tenant": "globex", "campaign": "spring"}
+	if len(labels) != len(expected) {
+		t.Errorf("Expected labels %v, got %v", expected, labels)
+	}
+	for key, value := range expected {
+		if labels[key] != value {
+			t.Errorf("Expected label %s '%s', got '%v'", key, value, labels[key])
+		}
+	}
+}
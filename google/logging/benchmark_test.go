@@ -0,0 +1,118 @@
+// benchmark_test.go
+
+// The allocation budgets do not hold under the race detector, which
+// allocates on every synchronized access.
+
+//go:build !race
+
+package structured
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+// Allocation budgets per call on the logging hot path. Raise them only with a
+// justification; they exist so regressions are caught in review.
+const (
+	maxAllocsSingleAttr = 4
+	maxAllocsTenAttrs   = 12
+	maxAllocsDisabled   = 0
+	maxAllocsSampledOut = 0
+)
+
+func newBenchmarkLogger() *StructuredLogger {
+	return NewStructuredLogger("test-project", "bench-component", nil, io.Discard)
+}
+
+var tenAttrs = []any{
+	"a1", "v", "a2", 2, "a3", true, "a4", 4.5, "a5", "v",
+	"a6", 6, "a7", false, "a8", 8.5, "a9", "v", "a10", 10,
+}
+
+func BenchmarkLogSingleAttr(b *testing.B) {
+	sl := newBenchmarkLogger()
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sl.LogInfo(ctx, "benchmark message", "key", "value")
+	}
+}
+
+func BenchmarkLogTenAttrs(b *testing.B) {
+	sl := newBenchmarkLogger()
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sl.LogInfo(ctx, "benchmark message", tenAttrs...)
+	}
+}
+
+func BenchmarkLogDisabledLevel(b *testing.B) {
+	sl := newBenchmarkLogger()
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sl.LogDebug(ctx, "benchmark message", "key", "value")
+	}
+}
+
+func BenchmarkLogSampled(b *testing.B) {
+	sl := NewStructuredLogger("test-project", "bench-component", nil, io.Discard,
+		WithSampler(NewSampler(map[slog.Level]int{slog.LevelInfo: 10})))
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sl.LogInfo(ctx, "benchmark message", "key", "value")
+	}
+}
+
+func BenchmarkLogError(b *testing.B) {
+	sl := newBenchmarkLogger()
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sl.LogError(ctx, "benchmark message", "key", "value")
+	}
+}
+
+func BenchmarkLogParallel(b *testing.B) {
+	sl := newBenchmarkLogger()
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			sl.LogInfo(ctx, "benchmark message", "key", "value")
+		}
+	})
+}
+
+func TestLogAllocations(t *testing.T) {
+	sl := newBenchmarkLogger()
+	// A rate this high drops every entry after the first.
+	sampled := NewStructuredLogger("test-project", "bench-component", nil, io.Discard,
+		WithSampler(NewSampler(map[slog.Level]int{slog.LevelInfo: 1 << 30})))
+	ctx := context.Background()
+	sampled.LogInfo(ctx, "message")
+
+	tests := []struct {
+		name      string
+		fn        func()
+		maxAllocs float64
+	}{
+		{"Single attribute", func() { sl.LogInfo(ctx, "message", "key", "value") }, maxAllocsSingleAttr},
+		{"Ten attributes", func() { sl.LogInfo(ctx, "message", tenAttrs...) }, maxAllocsTenAttrs},
+		{"Disabled level", func() { sl.Log(ctx, slog.LevelDebug, "message", "key", "value") }, maxAllocsDisabled},
+		{"Sampled out", func() { sampled.LogInfo(ctx, "message", "key", "value") }, maxAllocsSampledOut},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if allocs := testing.AllocsPerRun(100, tt.fn); allocs > tt.maxAllocs {
+				t.Errorf("Expected at most %.0f allocations per call, got %.1f", tt.maxAllocs, allocs)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+// caller.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+// WithCallerSkip skips n more stack frames when capturing the source location
+// of ERROR and above entries, so helpers built on the logger report their
+// caller rather than themselves. Use 1 for a helper that calls LogError
+// directly; options accumulate.
+func WithCallerSkip(n int) Option {
+	return func(sl *StructuredLogger) {
+		sl.callerSkip += n
+	}
+}
@@ -0,0 +1,74 @@
+// caller_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"runtime"
+	"testing"
+)
+
+// failJob is a helper of the kind users build on top of LogError.
+func failJob(ctx context.Context, sl *StructuredLogger, msg string) {
+	sl.LogError(ctx, msg, "job", "nightly")
+}
+
+func loggedFunction(t *testing.T, buf *bytes.Buffer) string {
+	t.Helper()
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	location, _ := loggedEntry["logging.googleapis.com/sourceLocation"].(map[string]interface{})
+	function, _ := location["function"].(string)
+	return function
+}
+
+func currentFunction() string {
+	pc, _, _, _ := runtime.Caller(1)
+	return runtime.FuncForPC(pc).Name()
+}
+
+func TestSourceLocation(t *testing.T) {
+	ctx := context.Background()
+	here := currentFunction()
+
+	t.Run("Log", func(t *testing.T) {
+		var buf bytes.Buffer
+		sl := NewStructuredLogger("", "test-component", nil, &buf)
+		sl.Log(ctx, slog.LevelError, "Job failed")
+		if got, want := loggedFunction(t, &buf), currentFunction(); got != want {
+			t.Errorf("Expected function %q, got %q", want, got)
+		}
+	})
+
+	t.Run("LogErrorf", func(t *testing.T) {
+		var buf bytes.Buffer
+		sl := NewStructuredLogger("", "test-component", nil, &buf)
+		sl.LogErrorf(ctx, "Job {job} failed", "job", "nightly")
+		if got, want := loggedFunction(t, &buf), currentFunction(); got != want {
+			t.Errorf("Expected function %q, got %q", want, got)
+		}
+	})
+
+	t.Run("Helper without skip", func(t *testing.T) {
+		var buf bytes.Buffer
+		sl := NewStructuredLogger("", "test-component", nil, &buf)
+		failJob(ctx, sl, "Job failed")
+		if got, want := loggedFunction(t, &buf), here[:len(here)-len("TestSourceLocation")]+"failJob"; got != want {
+			t.Errorf("Expected function %q, got %q", want, got)
+		}
+	})
+
+	t.Run("Helper with WithCallerSkip", func(t *testing.T) {
+		var buf bytes.Buffer
+		sl := NewStructuredLogger("", "test-component", nil, &buf, WithCallerSkip(1))
+		failJob(ctx, sl, "Job failed")
+		if got, want := loggedFunction(t, &buf), currentFunction(); got != want {
+			t.Errorf("Expected function %q, got %q", want, got)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+// clock.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Clock supplies the timestamps of log entries.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to Clock, e.g. to inject fixed times in tests.
+type ClockFunc func() time.Time
+
+// Now calls f.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// WithClock sets the clock the timestamps of entries are taken from. The
+// default clock reads the wall clock on every entry, so it follows clock
+// adjustments such as NTP corrections, but never returns the same or an
+// earlier time twice, so entries logged in a burst from several goroutines
+// keep a stable order.
+func WithClock(c Clock) Option {
+	return func(sl *StructuredLogger) {
+		sl.clock = c
+	}
+}
+
+// monotonicClock returns strictly increasing wall clock times. When the wall
+// clock steps back, it returns one nanosecond past the last time until the
+// wall clock catches up.
+type monotonicClock struct {
+	wall func() time.Time
+	last atomic.Int64
+}
+
+var defaultClock = newMonotonicClock()
+
+func newMonotonicClock() *monotonicClock {
+	return &monotonicClock{wall: time.Now}
+}
+
+func (c *monotonicClock) Now() time.Time {
+	now := c.wall().UnixNano()
+	for {
+		last := c.last.Load()
+		if now <= last {
+			now = last + 1
+		}
+		if c.last.CompareAndSwap(last, now) {
+			return time.Unix(0, now)
+		}
+	}
+}
+
+// timestampAttrs returns the timestampSeconds and timestampNanos fields that
+// Cloud Logging takes as the timestamp of a FormatJSON entry, with nanosecond
+// precision.
+func (sl *StructuredLogger) timestampAttrs(t time.Time) []slog.Attr {
+	if sl.format != FormatJSON {
+		return nil
+	}
+	return []slog.Attr{
+		slog.Int64("timestampSeconds", t.Unix()),
+		slog.Int("timestampNanos", t.Nanosecond()),
+	}
+}
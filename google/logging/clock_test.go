@@ -0,0 +1,90 @@
+// clock_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithClock(t *testing.T) {
+	fixed := time.Date(2024, 3, 1, 12, 0, 0, 123456789, time.UTC)
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf, WithClock(ClockFunc(func() time.Time { return fixed })))
+	sl.LogInfo(context.Background(), "Tick")
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if loggedEntry["time"] != "2024-03-01T12:00:00.123456789Z" {
+		t.Errorf("Expected the time of the clock, got %v", loggedEntry["time"])
+	}
+	if loggedEntry["timestampSeconds"] != float64(fixed.Unix()) || loggedEntry["timestampNanos"] != float64(123456789) {
+		t.Errorf("Expected timestampSeconds and timestampNanos of the clock, got %v and %v", loggedEntry["timestampSeconds"], loggedEntry["timestampNanos"])
+	}
+}
+
+func TestTimestampFieldsJSONOnly(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf, WithFormat(FormatPlain))
+	sl.LogInfo(context.Background(), "Tick")
+	if bytes.Contains(buf.Bytes(), []byte("timestampSeconds")) {
+		t.Errorf("Expected no Cloud Logging timestamp fields in FormatPlain, got %s", buf.String())
+	}
+}
+
+func TestMonotonicClock(t *testing.T) {
+	clock := newMonotonicClock()
+	const goroutines, perGoroutine = 8, 1000
+
+	var mu sync.Mutex
+	seen := make(map[int64]bool, goroutines*perGoroutine)
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			prev := time.Time{}
+			for j := 0; j < perGoroutine; j++ {
+				now := clock.Now()
+				if !now.After(prev) {
+					t.Errorf("Expected increasing times, got %v after %v", now, prev)
+					return
+				}
+				prev = now
+				mu.Lock()
+				seen[now.UnixNano()] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != goroutines*perGoroutine {
+		t.Errorf("Expected %d distinct timestamps, got %d", goroutines*perGoroutine, len(seen))
+	}
+}
+
+func TestMonotonicClockFollowsWallClock(t *testing.T) {
+	wall := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := newMonotonicClock()
+	clock.wall = func() time.Time { return wall }
+
+	if now := clock.Now(); !now.Equal(wall) {
+		t.Errorf("Expected the wall clock time %v, got %v", wall, now)
+	}
+	wall = wall.Add(time.Hour)
+	if now := clock.Now(); !now.Equal(wall) {
+		t.Errorf("Expected the adjusted wall clock time %v, got %v", wall, now)
+	}
+	stepped := wall
+	wall = wall.Add(-time.Second)
+	if now := clock.Now(); !now.Equal(stepped.Add(time.Nanosecond)) {
+		t.Errorf("Expected a wall clock step back not to go back in time, got %v", now)
+	}
+}
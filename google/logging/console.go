@@ -0,0 +1,253 @@
+// console.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FormatEnv is the environment variable selecting the default output format:
+// json, plain, gelf, ecs, auto, or console.
+const FormatEnv = "LOG_FORMAT"
+
+// defaultFormat returns the format named by LOG_FORMAT, FormatJSON when it is
+// unset or unknown. WithFormat overrides it.
+func defaultFormat() Format {
+	switch strings.ToLower(os.Getenv(FormatEnv)) {
+	case "plain":
+		return FormatPlain
+	case "gelf":
+		return FormatGELF
+	case "ecs":
+		return FormatECS
+	case "auto":
+		return FormatAuto
+	case "console":
+		return FormatConsole
+	default:
+		return FormatJSON
+	}
+}
+
+// ANSI color codes of the console format.
+const (
+	colorReset  = "\x1b[0m"
+	colorDim    = "\x1b[2m"
+	colorRed    = "\x1b[31m"
+	colorYellow = "\x1b[33m"
+	colorBlue   = "\x1b[34m"
+	colorCyan   = "\x1b[36m"
+)
+
+// consoleHandler writes one human-readable line per entry:
+//
+//	15:04:05.000 ERROR     [orders] Order failed order_id=o-1 (handler.go:42)
+//
+// Colors are used when the writer is a terminal and NO_COLOR is not set.
+type consoleHandler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	level  slog.Leveler
+	color  bool
+	bound  []boundAttr
+	groups []string
+}
+
+func newConsoleHandler(w io.Writer, level slog.Leveler) *consoleHandler {
+	if level == nil {
+		level = slog.LevelInfo
+	}
+	return &consoleHandler{mu: &sync.Mutex{}, w: w, level: level, color: isTerminal(w) && os.Getenv("NO_COLOR") == ""}
+}
+
+// isTerminal reports whether w is a character device such as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(h.paint(colorDim, t.Format("15:04:05.000")))
+	buf.WriteByte(' ')
+	buf.WriteString(h.paint(levelColor(r.Level), fmt.Sprintf("%-9s", severity(r.Level))))
+	buf.WriteByte(' ')
+
+	var component, location string
+	var fields bytes.Buffer
+	write := func(prefix []string, a slog.Attr) {
+		switch strings.Join(append(slices.Clip(prefix), a.Key), ".") {
+		case "component":
+			component = a.Value.String()
+			return
+		case keySourceLocation:
+			location = consoleLocation(a.Value.Resolve())
+			return
+		case keyTraceSampled:
+			return
+		}
+		h.writeAttr(&fields, prefix, a)
+	}
+	for _, b := range h.bound {
+		write(b.groups, b.attr)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		write(h.groups, a)
+		return true
+	})
+
+	if component != "" {
+		buf.WriteString(h.paint(colorCyan, "["+component+"]"))
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(r.Message)
+	buf.Write(fields.Bytes())
+	if location != "" {
+		buf.WriteString(h.paint(colorDim, " ("+location+")"))
+	}
+	buf.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+// writeAttr writes " key=value", flattening groups into dotted keys and
+// shortening the Cloud Logging keys.
+func (h *consoleHandler) writeAttr(buf *bytes.Buffer, prefix []string, a slog.Attr) {
+	v := a.Value.Resolve()
+	key := a.Key
+	switch key {
+	case keyTrace:
+		key, v = "trace", slog.StringValue(rawTraceID(v.String()))
+	case keySpanID:
+		key = "span"
+	case keyLabels:
+		key = "labels"
+	case keyOperation:
+		key = "operation"
+	}
+	if v.Kind() == slog.KindGroup {
+		if key != "" {
+			prefix = append(slices.Clip(prefix), key)
+		}
+		for _, ga := range v.Group() {
+			h.writeAttr(buf, prefix, ga)
+		}
+		return
+	}
+	if key == "" {
+		return
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(h.paint(colorDim, strings.Join(append(slices.Clip(prefix), key), ".")+"="))
+	buf.WriteString(consoleValue(v))
+}
+
+// consoleValue formats a value, quoting strings with spaces or quotes.
+func consoleValue(v slog.Value) string {
+	var s string
+	switch v.Kind() {
+	case slog.KindTime:
+		s = v.Time().Format(time.RFC3339Nano)
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			s = err.Error()
+		} else {
+			s = fmt.Sprint(v.Any())
+		}
+	default:
+		s = v.String()
+	}
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// consoleLocation formats a sourceLocation group as "file.go:42".
+func consoleLocation(v slog.Value) string {
+	if v.Kind() != slog.KindGroup {
+		return ""
+	}
+	var file, line string
+	for _, a := range v.Group() {
+		switch a.Key {
+		case "file":
+			file = filepath.Base(a.Value.String())
+		case "line":
+			line = a.Value.String()
+		}
+	}
+	if file == "" {
+		return ""
+	}
+	return file + ":" + line
+}
+
+// levelColor returns the color of a severity.
+func levelColor(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return colorRed
+	case level >= slog.LevelWarn:
+		return colorYellow
+	case level >= slog.LevelInfo:
+		return colorBlue
+	default:
+		return colorDim
+	}
+}
+
+// paint wraps s in color when colors are enabled.
+func (h *consoleHandler) paint(color, s string) string {
+	if !h.color {
+		return s
+	}
+	return color + s + colorReset
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.bound = append([]boundAttr(nil), h.bound...)
+	for _, a := range attrs {
+		clone.bound = append(clone.bound, boundAttr{groups: h.groups, attr: a})
+	}
+	return &clone
+}
+
+func (h *consoleHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.groups = append(append([]string(nil), h.groups...), name)
+	return &clone
+}
@@ -0,0 +1,70 @@
+// console_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConsoleFormat(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "orders", nil, &buf, WithFormat(FormatConsole))
+	sl.SetLogLevel("DEBUG")
+	ctx := context.Background()
+
+	sl.LogDebug(ctx, "Cache miss", "key", "user 42")
+	sl.LogError(ctx, "Order failed", "order_id", "o-1", "error", errors.New("quota exceeded"), Label("tenant", "acme"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", buf.String())
+	}
+	if !regexp.MustCompile(`^\d{2}:\d{2}:\d{2}\.\d{3} DEBUG     \[orders\] Cache miss key="user 42"$`).MatchString(lines[0]) {
+		t.Errorf("Unexpected debug line: %q", lines[0])
+	}
+	want := `ERROR     [orders] Order failed order_id=o-1 error="quota exceeded" labels.tenant=acme (console_test.go:`
+	if !strings.Contains(lines[1], want) {
+		t.Errorf("Expected %q in the error line, got %q", want, lines[1])
+	}
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Errorf("Expected no colors when writing to a buffer, got %q", buf.String())
+	}
+}
+
+func TestConsoleNestedGroupsConcurrent(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(newConsoleHandler(&buf, slog.LevelInfo)).WithGroup("a").WithGroup("b").WithGroup("c")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				logger.Info("Order shipped", "id", i, slog.Group("nested", "k", j))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if !strings.Contains(buf.String(), " a.b.c.id=") {
+		t.Errorf("Expected keys nested under a.b.c, got %q", strings.SplitN(buf.String(), "\n", 2)[0])
+	}
+}
+
+func TestFormatEnv(t *testing.T) {
+	t.Setenv(FormatEnv, "console")
+	if sl := NewStructuredLogger("", "test-component", nil, &bytes.Buffer{}); sl.format != FormatConsole {
+		t.Errorf("Expected FormatConsole from LOG_FORMAT, got %v", sl.format)
+	}
+	if sl := NewStructuredLogger("", "test-component", nil, &bytes.Buffer{}, WithFormat(FormatJSON)); sl.format != FormatJSON {
+		t.Errorf("Expected WithFormat to override LOG_FORMAT, got %v", sl.format)
+	}
+}
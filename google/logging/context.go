@@ -0,0 +1,36 @@
+// context.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"context"
+	"sync"
+)
+
+type loggerKey struct{}
+
+var (
+	defaultLogger     *StructuredLogger
+	defaultLoggerOnce sync.Once
+)
+
+// NewContext returns a copy of ctx carrying logger, so functions deep in the
+// call stack can retrieve the request-scoped logger with FromContext.
+func NewContext(ctx context.Context, logger *StructuredLogger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger stored by NewContext. When ctx carries no
+// logger, it returns a shared logger without trace context that writes to
+// stderr, so callers never need a nil check.
+func FromContext(ctx context.Context) *StructuredLogger {
+	if logger, ok := ctx.Value(loggerKey{}).(*StructuredLogger); ok && logger != nil {
+		return logger
+	}
+	defaultLoggerOnce.Do(func() {
+		defaultLogger = NewStructuredLogger("", "", nil, nil)
+	})
+	return defaultLogger
+}
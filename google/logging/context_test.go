@@ -0,0 +1,42 @@
+// context_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestNewContextFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b120001000/1;o=1")
+	sl := NewStructuredLogger("test-project", "test-component", r, &buf)
+
+	ctx := NewContext(context.Background(), sl)
+	if got := FromContext(ctx); got != sl {
+		t.Fatalf("Expected the stored logger, got %p", got)
+	}
+
+	FromContext(ctx).LogInfo(ctx, "deep in the stack")
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if loggedEntry["logging.googleapis.com/trace"] != "projects/test-project/traces/105445aa7843bc8bf206b120001000" {
+		t.Errorf("Expected the request trace, got '%v'", loggedEntry["logging.googleapis.com/trace"])
+	}
+}
+
+func TestFromContextDefault(t *testing.T) {
+	first := FromContext(context.Background())
+	if first == nil {
+		t.Fatalf("Expected a default logger")
+	}
+	if second := FromContext(context.Background()); second != first {
+		t.Errorf("Expected the default logger to be shared")
+	}
+}
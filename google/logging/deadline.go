@@ -0,0 +1,50 @@
+// deadline.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// WithDeadlineWarning enables CheckDeadline: a WARNING is emitted when a
+// request's context was cancelled before completion or has less than
+// threshold left before its deadline.
+func WithDeadlineWarning(threshold time.Duration) Option {
+	return func(sl *StructuredLogger) {
+		sl.deadlineThreshold = threshold
+	}
+}
+
+// CheckDeadline logs a WARNING with the elapsed time since start when ctx has
+// been cancelled or is within the configured threshold of its deadline. Call
+// it when a unit of work completes to diagnose timeout cascades. It does
+// nothing unless the logger was created with WithDeadlineWarning.
+func (sl *StructuredLogger) CheckDeadline(ctx context.Context, start time.Time) {
+	if sl.deadlineThreshold <= 0 {
+		return
+	}
+
+	elapsed := time.Since(start)
+	if err := ctx.Err(); err != nil {
+		sl.log(ctx, 1, slog.LevelWarn, "Context ended before completion",
+			"reason", err.Error(),
+			"elapsed_ms", elapsed.Milliseconds(),
+		)
+		return
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return
+	}
+	if remaining := time.Until(deadline); remaining < sl.deadlineThreshold {
+		sl.log(ctx, 1, slog.LevelWarn, "Context is close to its deadline",
+			"remaining_ms", remaining.Milliseconds(),
+			"elapsed_ms", elapsed.Milliseconds(),
+		)
+	}
+}
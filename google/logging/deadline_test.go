@@ -0,0 +1,95 @@
+// deadline_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestCheckDeadline(t *testing.T) {
+	tests := []struct {
+		name        string
+		ctx         func() (context.Context, context.CancelFunc)
+		expectedMsg string
+	}{
+		{
+			name: "No deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			expectedMsg: "",
+		},
+		{
+			name: "Far from deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), time.Hour)
+			},
+			expectedMsg: "",
+		},
+		{
+			name: "Close to deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			},
+			expectedMsg: "Context is close to its deadline",
+		},
+		{
+			name: "Cancelled",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, cancel
+			},
+			expectedMsg: "Context ended before completion",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			sl := NewStructuredLogger("", "test-component", nil, &buf, WithDeadlineWarning(time.Second))
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+			sl.CheckDeadline(ctx, time.Now().Add(-10*time.Millisecond))
+
+			if tt.expectedMsg == "" {
+				if buf.Len() != 0 {
+					t.Errorf("Expected no output, got %s", buf.String())
+				}
+				return
+			}
+
+			var loggedEntry map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+				t.Fatalf("Error unmarshaling log output: %v", err)
+			}
+			if loggedEntry["msg"] != tt.expectedMsg {
+				t.Errorf("Expected message '%s', got '%v'", tt.expectedMsg, loggedEntry["msg"])
+			}
+			if loggedEntry["level"] != "WARN" {
+				t.Errorf("Expected level 'WARN', got '%v'", loggedEntry["level"])
+			}
+			if elapsed, ok := loggedEntry["elapsed_ms"].(float64); !ok || elapsed < 10 {
+				t.Errorf("Expected elapsed_ms of at least 10, got '%v'", loggedEntry["elapsed_ms"])
+			}
+		})
+	}
+}
+
+func TestCheckDeadlineDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sl.CheckDeadline(ctx, time.Now())
+
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output without WithDeadlineWarning, got %s", buf.String())
+	}
+}
@@ -0,0 +1,52 @@
+// debug_header_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithDebugHeader(t *testing.T) {
+	tests := []struct {
+		name          string
+		header        string
+		expectedDebug bool
+	}{
+		{name: "No header", header: "", expectedDebug: false},
+		{name: "Wrong token", header: "guess", expectedDebug: false},
+		{name: "Valid token", header: "s3cret", expectedDebug: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com", nil)
+			if tt.header != "" {
+				req.Header.Set(DebugLogHeader, tt.header)
+			}
+
+			var buf bytes.Buffer
+			sl := NewStructuredLogger("test-project", "test-component", req, &buf, WithDebugHeader("s3cret"))
+			sl.LogDebug(context.Background(), "This is a debug message")
+
+			if got := buf.Len() > 0; got != tt.expectedDebug {
+				t.Errorf("Expected DEBUG output: %v, got: %v", tt.expectedDebug, got)
+			}
+		})
+	}
+}
+
+func TestWithDebugHeaderEmptySecret(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set(DebugLogHeader, "")
+
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("test-project", "test-component", req, &buf, WithDebugHeader(""))
+	sl.LogDebug(context.Background(), "This is a debug message")
+
+	if buf.Len() != 0 {
+		t.Errorf("Expected no DEBUG output when the secret is empty")
+	}
+}
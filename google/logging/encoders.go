@@ -0,0 +1,400 @@
+// encoders.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Format selects the output encoding of the logger.
+type Format int
+
+const (
+	// FormatJSON writes slog JSON with the Google Cloud Logging special fields (default).
+	FormatJSON Format = iota
+	// FormatGELF writes Graylog Extended Log Format 1.1 messages.
+	FormatGELF
+	// FormatECS writes Elastic Common Schema JSON documents.
+	FormatECS
+	// FormatPlain writes JSON without the logging.googleapis.com keys, for
+	// running outside Google Cloud.
+	FormatPlain
+	// FormatAuto selects FormatJSON on Google Cloud and FormatPlain elsewhere.
+	FormatAuto
+	// FormatConsole writes colorized human-readable lines for local development.
+	FormatConsole
+)
+
+// ecsVersion is the Elastic Common Schema version the ECS encoder targets.
+const ecsVersion = "8.11.0"
+
+// Google Cloud Logging keys written by Log that the alternative encoders remap.
+const (
+	keyTrace          = "logging.googleapis.com/trace"
+	keySpanID         = "logging.googleapis.com/spanId"
+	keyTraceSampled   = "logging.googleapis.com/trace_sampled"
+	keySourceLocation = "logging.googleapis.com/sourceLocation"
+)
+
+// Option configures a StructuredLogger at construction.
+type Option func(*StructuredLogger)
+
+// WithFormat selects the output encoding, for example FormatGELF or FormatECS
+// when the same logs are shipped to a non-GCP aggregation stack.
+func WithFormat(format Format) Option {
+	return func(sl *StructuredLogger) {
+		sl.format = format
+	}
+}
+
+// resolveFormat replaces FormatAuto with FormatJSON when a Google Cloud
+// platform was detected or the metadata server answers, and with FormatPlain
+// otherwise.
+func (sl *StructuredLogger) resolveFormat() {
+	if sl.format != FormatAuto {
+		return
+	}
+	if (sl.resource != nil && sl.resource.Platform != "") || metadataProject(context.Background()) != "" {
+		sl.format = FormatJSON
+		return
+	}
+	sl.format = FormatPlain
+}
+
+// newHandler returns the handler for entries of level and above, behind the
+// tail sampling buffer of a request when there is one.
+func (sl *StructuredLogger) newHandler(level slog.Leveler) slog.Handler {
+	if sl.tail != nil {
+		return &tailHandler{next: sl.newOutputHandler(slog.LevelDebug), level: level, buffer: sl.tail}
+	}
+	return sl.newOutputHandler(level)
+}
+
+// newOutputHandler returns the slog handler for the configured format, teed
+// to any additional handlers.
+func (sl *StructuredLogger) newOutputHandler(level slog.Leveler) slog.Handler {
+	primary := sl.newFormatHandler(level)
+	if len(sl.additional) == 0 {
+		return primary
+	}
+	return &teeHandler{primary: primary, others: sl.additional}
+}
+
+// newFormatHandler returns the handler writing the configured format to the writer.
+func (sl *StructuredLogger) newFormatHandler(level slog.Leveler) slog.Handler {
+	switch sl.format {
+	case FormatGELF:
+		host, _ := os.Hostname()
+		return newEncoderHandler(sl.writer, level, func(t time.Time, level slog.Level, msg string, fields map[string]any) map[string]any {
+			return encodeGELF(host, t, level, msg, fields)
+		})
+	case FormatECS:
+		return newEncoderHandler(sl.writer, level, encodeECS)
+	case FormatPlain:
+		return newEncoderHandler(sl.writer, level, encodePlain)
+	case FormatConsole:
+		return newConsoleHandler(sl.writer, level)
+	default:
+		return slog.NewJSONHandler(sl.writer, &slog.HandlerOptions{
+			Level:     level,
+			AddSource: false, // We'll add source manually for error levels
+		})
+	}
+}
+
+// severity returns the Cloud Logging severity name for a level.
+func severity(level slog.Level) string {
+	switch {
+	case level >= LevelEmergency:
+		return "EMERGENCY"
+	case level >= LevelAlert:
+		return "ALERT"
+	case level >= LevelCritical:
+		return "CRITICAL"
+	case level >= slog.LevelError:
+		return "ERROR"
+	case level >= slog.LevelWarn:
+		return "WARNING"
+	case level >= LevelNotice:
+		return "NOTICE"
+	case level >= slog.LevelInfo:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
+
+// syslogLevel returns the RFC 5424 severity number used by GELF.
+func syslogLevel(level slog.Level) int {
+	switch severity(level) {
+	case "EMERGENCY":
+		return 0
+	case "ALERT":
+		return 1
+	case "CRITICAL":
+		return 2
+	case "ERROR":
+		return 3
+	case "WARNING":
+		return 4
+	case "NOTICE":
+		return 5
+	case "INFO":
+		return 6
+	default:
+		return 7
+	}
+}
+
+// rawTraceID strips the "projects/<id>/traces/" prefix from a Cloud Logging trace.
+func rawTraceID(trace string) string {
+	if i := strings.LastIndex(trace, "/"); i >= 0 {
+		return trace[i+1:]
+	}
+	return trace
+}
+
+var reGELFKey = regexp.MustCompile(`[^\w.\-]`)
+
+// encodeGELF maps an entry onto a GELF 1.1 message.
+func encodeGELF(host string, t time.Time, level slog.Level, msg string, fields map[string]any) map[string]any {
+	out := map[string]any{
+		"version":       "1.1",
+		"host":          host,
+		"short_message": msg,
+		"timestamp":     float64(t.UnixNano()) / float64(time.Second),
+		"level":         syslogLevel(level),
+	}
+
+	for key, value := range fields {
+		switch key {
+		case keyTrace:
+			out["_trace_id"] = rawTraceID(fmt.Sprint(value))
+		case keySpanID:
+			out["_span_id"] = value
+		case keyTraceSampled:
+			out["_trace_sampled"] = value
+		case keySourceLocation:
+			flattenGELF(out, "_source", value)
+		case keyLabels:
+			flattenGELF(out, "_labels", value)
+		case keyOperation:
+			flattenGELF(out, "_operation", value)
+		default:
+			flattenGELF(out, "_"+key, value)
+		}
+	}
+	return out
+}
+
+// flattenGELF writes value under key, flattening nested groups because GELF
+// only allows scalar additional fields.
+func flattenGELF(out map[string]any, key string, value any) {
+	if group, ok := value.(map[string]any); ok {
+		for k, v := range group {
+			flattenGELF(out, key+"_"+k, v)
+		}
+		return
+	}
+	key = reGELFKey.ReplaceAllString(key, "_")
+	if key == "_id" {
+		key = "__id" // _id is reserved by the GELF specification
+	}
+	out[key] = value
+}
+
+// encodeECS maps an entry onto an Elastic Common Schema document.
+func encodeECS(t time.Time, level slog.Level, msg string, fields map[string]any) map[string]any {
+	out := map[string]any{
+		"@timestamp":  t.UTC().Format(time.RFC3339Nano),
+		"log.level":   strings.ToLower(severity(level)),
+		"message":     msg,
+		"ecs.version": ecsVersion,
+	}
+
+	for key, value := range fields {
+		switch key {
+		case "component":
+			out["service.name"] = value
+		case keyTrace:
+			out["trace.id"] = rawTraceID(fmt.Sprint(value))
+		case keySpanID:
+			out["span.id"] = value
+		case keyTraceSampled:
+			out["trace.sampled"] = value
+		case keyLabels:
+			out["labels"] = value
+		case keyOperation:
+			out["operation"] = value
+		case keySourceLocation:
+			if loc, ok := value.(map[string]any); ok {
+				out["log.origin.file.name"] = loc["file"]
+				out["log.origin.file.line"] = loc["line"]
+				out["log.origin.function"] = loc["function"]
+			}
+		default:
+			out[key] = value
+		}
+	}
+	return out
+}
+
+// encodePlain maps an entry onto a plain JSON object, with the Cloud Logging
+// special fields renamed and the severity name as the level.
+func encodePlain(t time.Time, level slog.Level, msg string, fields map[string]any) map[string]any {
+	out := map[string]any{
+		"time":  t.Format(time.RFC3339Nano),
+		"level": severity(level),
+		"msg":   msg,
+	}
+
+	for key, value := range fields {
+		switch key {
+		case keyTrace:
+			out["trace_id"] = rawTraceID(fmt.Sprint(value))
+		case keySpanID:
+			out["span_id"] = value
+		case keyTraceSampled:
+			out["trace_sampled"] = value
+		case keySourceLocation:
+			out["source"] = value
+		case keyLabels:
+			out["labels"] = value
+		case keyOperation:
+			out["operation"] = value
+		default:
+			out[key] = value
+		}
+	}
+	return out
+}
+
+// boundAttr is an attribute added through WithAttrs, remembered with the
+// groups that were open at the time.
+type boundAttr struct {
+	groups []string
+	attr   slog.Attr
+}
+
+// encoderHandler is a slog.Handler that collects an entry into a map and
+// writes the result of an encoder function as one JSON line.
+type encoderHandler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	level  slog.Leveler
+	encode func(t time.Time, level slog.Level, msg string, fields map[string]any) map[string]any
+	bound  []boundAttr
+	groups []string
+}
+
+func newEncoderHandler(w io.Writer, level slog.Leveler, encode func(time.Time, slog.Level, string, map[string]any) map[string]any) *encoderHandler {
+	if level == nil {
+		level = slog.LevelInfo
+	}
+	return &encoderHandler{mu: &sync.Mutex{}, w: w, level: level, encode: encode}
+}
+
+func (h *encoderHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *encoderHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := make(map[string]any)
+	for _, b := range h.bound {
+		addAttr(groupMap(fields, b.groups), b.attr)
+	}
+	target := groupMap(fields, h.groups)
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(target, a)
+		return true
+	})
+
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	line, err := json.Marshal(h.encode(t, r.Level, r.Message, fields))
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err = h.w.Write(append(line, '\n'))
+	return err
+}
+
+func (h *encoderHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.bound = append([]boundAttr(nil), h.bound...)
+	for _, a := range attrs {
+		clone.bound = append(clone.bound, boundAttr{groups: h.groups, attr: a})
+	}
+	return &clone
+}
+
+func (h *encoderHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.groups = append(append([]string(nil), h.groups...), name)
+	return &clone
+}
+
+// groupMap returns the nested map for the given group path, creating it as needed.
+func groupMap(m map[string]any, groups []string) map[string]any {
+	for _, g := range groups {
+		next, ok := m[g].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			m[g] = next
+		}
+		m = next
+	}
+	return m
+}
+
+// addAttr stores a resolved attribute value in m, expanding groups into nested maps.
+func addAttr(m map[string]any, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		attrs := v.Group()
+		if len(attrs) == 0 {
+			return
+		}
+		target := m
+		if a.Key != "" {
+			target = groupMap(m, []string{a.Key})
+		}
+		for _, ga := range attrs {
+			addAttr(target, ga)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	switch v.Kind() {
+	case slog.KindTime:
+		m[a.Key] = v.Time().Format(time.RFC3339Nano)
+	case slog.KindDuration:
+		m[a.Key] = v.Duration().String()
+	default:
+		if err, ok := v.Any().(error); ok {
+			m[a.Key] = err.Error()
+			return
+		}
+		m[a.Key] = v.Any()
+	}
+}
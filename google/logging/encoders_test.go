@@ -0,0 +1,144 @@
+// encoders_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGELFFormat(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf, WithFormat(FormatGELF))
+	sl.traceID = "projects/test-project/traces/105445aa7843bc8bf206b120001000"
+	sl.spanID = "1"
+
+	sl.LogError(context.Background(), "Test GELF message", "userID", 12345, "id", "abc")
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"version":       "1.1",
+		"short_message": "Test GELF message",
+		"level":         float64(3),
+		"_component":    "test-component",
+		"_trace_id":     "105445aa7843bc8bf206b120001000",
+		"_span_id":      "1",
+		"_userID":       float64(12345),
+		"__id":          "abc",
+	}
+	for key, value := range expected {
+		if loggedEntry[key] != value {
+			t.Errorf("Expected %s '%v', got '%v'", key, value, loggedEntry[key])
+		}
+	}
+	if _, ok := loggedEntry["timestamp"].(float64); !ok {
+		t.Errorf("Expected numeric timestamp, got '%v'", loggedEntry["timestamp"])
+	}
+	if _, ok := loggedEntry["_source_file"]; !ok {
+		t.Errorf("Expected flattened source location for error level")
+	}
+}
+
+func TestECSFormat(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf, WithFormat(FormatECS))
+	sl.traceID = "projects/test-project/traces/105445aa7843bc8bf206b120001000"
+	sl.SetLogLevel("DEBUG")
+
+	sl.LogNotice(context.Background(), "Test ECS message", "role", "admin")
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"message":      "Test ECS message",
+		"log.level":    "notice",
+		"ecs.version":  ecsVersion,
+		"service.name": "test-component",
+		"trace.id":     "105445aa7843bc8bf206b120001000",
+		"role":         "admin",
+	}
+	for key, value := range expected {
+		if loggedEntry[key] != value {
+			t.Errorf("Expected %s '%v', got '%v'", key, value, loggedEntry[key])
+		}
+	}
+	if _, ok := loggedEntry["@timestamp"].(string); !ok {
+		t.Errorf("Expected @timestamp, got '%v'", loggedEntry["@timestamp"])
+	}
+}
+
+func TestEncoderSetLogLevel(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf, WithFormat(FormatECS))
+	sl.SetLogLevel("WARNING")
+
+	sl.LogInfo(context.Background(), "This is an info message")
+
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output for INFO level when log level is WARNING")
+	}
+}
+
+func TestPlainFormat(t *testing.T) {
+	r := httptest.NewRequest("GET", "/orders", nil)
+	r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b120001000/1;o=1")
+
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("test-project", "test-component", r, &buf, WithFormat(FormatPlain), WithLabels(map[string]string{"tenant": "acme"}))
+	sl.LogError(context.Background(), "Order failed", "order_id", "o-1")
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	for key := range loggedEntry {
+		if strings.HasPrefix(key, "logging.googleapis.com/") {
+			t.Errorf("Expected no Cloud Logging keys, got '%s'", key)
+		}
+	}
+	if loggedEntry["level"] != "ERROR" || loggedEntry["msg"] != "Order failed" || loggedEntry["order_id"] != "o-1" {
+		t.Errorf("Unexpected plain entry: %v", loggedEntry)
+	}
+	if loggedEntry["trace_id"] != "105445aa7843bc8bf206b120001000" || loggedEntry["span_id"] != "1" {
+		t.Errorf("Expected renamed trace fields, got %v", loggedEntry)
+	}
+	if _, ok := loggedEntry["source"].(map[string]interface{}); !ok {
+		t.Errorf("Expected the source location under 'source', got %v", loggedEntry["source"])
+	}
+}
+
+func TestEncodersNonStringTrace(t *testing.T) {
+	// A trace logged by the caller under the Cloud Logging key need not be a string.
+	fields := map[string]any{keyTrace: 42}
+	now := time.Now()
+
+	if got := encodeGELF("host", now, slog.LevelInfo, "msg", fields)["_trace_id"]; got != "42" {
+		t.Errorf("Expected GELF trace '42', got '%v'", got)
+	}
+	if got := encodeECS(now, slog.LevelInfo, "msg", fields)["trace.id"]; got != "42" {
+		t.Errorf("Expected ECS trace '42', got '%v'", got)
+	}
+	if got := encodePlain(now, slog.LevelInfo, "msg", fields)["trace_id"]; got != "42" {
+		t.Errorf("Expected plain trace '42', got '%v'", got)
+	}
+}
+
+func TestAutoFormat(t *testing.T) {
+	sl := NewStructuredLogger("", "test-component", nil, &bytes.Buffer{}, WithFormat(FormatAuto), WithResource(Resource{Platform: PlatformCloudRun, Service: "orders"}))
+	if sl.format != FormatJSON {
+		t.Errorf("Expected FormatJSON on Cloud Run, got %v", sl.format)
+	}
+}
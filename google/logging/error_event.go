@@ -0,0 +1,69 @@
+// error_event.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+)
+
+// ReportedErrorEventType is the @type that makes Cloud Error Reporting pick
+// up a log entry as an error event.
+const ReportedErrorEventType = "type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent"
+
+// maxStackFrames caps the number of frames captured by LogErrorErr.
+const maxStackFrames = 64
+
+// LogErrorErr logs an ERROR entry for err that Cloud Error Reporting picks up
+// automatically. The entry carries the ReportedErrorEvent @type, the error
+// text under "error", the messages of the wrapped errors under "error_chain",
+// and a stack trace of the caller under "stack_trace" in the Go format Error
+// Reporting parses.
+func (sl *StructuredLogger) LogErrorErr(ctx context.Context, msg string, err error, args ...any) {
+	if err == nil {
+		sl.log(ctx, 1, slog.LevelError, msg, args...)
+		return
+	}
+	eventArgs := []any{
+		"@type", ReportedErrorEventType,
+		"error", err.Error(),
+		"error_chain", errorChain(err),
+		"stack_trace", stackTrace(msg, err, 1+sl.callerSkip),
+	}
+	sl.log(ctx, 1, slog.LevelError, msg, append(eventArgs, args...)...)
+}
+
+// errorChain returns the messages of err and the errors it wraps, outermost first.
+func errorChain(err error) []string {
+	var chain []string
+	for ; err != nil; err = errors.Unwrap(err) {
+		chain = append(chain, err.Error())
+	}
+	return chain
+}
+
+// stackTrace formats the current stack like a Go panic, so Error Reporting
+// can group the events. skip is the number of frames to omit above the caller
+// of stackTrace.
+func stackTrace(msg string, err error, skip int) string {
+	pcs := make([]uintptr, maxStackFrames)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s\n\ngoroutine 1 [running]:\n", msg, err.Error())
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "%s(...)\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
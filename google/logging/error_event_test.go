@@ -0,0 +1,52 @@
+// error_event_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLogErrorErr(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+
+	root := errors.New("connection refused")
+	err := fmt.Errorf("load order: %w", fmt.Errorf("query database: %w", root))
+	sl.LogErrorErr(context.Background(), "Failed to load order", err, "order_id", "o-1")
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+
+	if loggedEntry["@type"] != ReportedErrorEventType {
+		t.Errorf("Expected @type '%s', got '%v'", ReportedErrorEventType, loggedEntry["@type"])
+	}
+	if loggedEntry["error"] != err.Error() || loggedEntry["order_id"] != "o-1" {
+		t.Errorf("Expected error and order_id fields, got %v", loggedEntry)
+	}
+
+	chain, _ := loggedEntry["error_chain"].([]interface{})
+	if len(chain) != 3 || chain[2] != "connection refused" {
+		t.Errorf("Expected an error chain of 3 ending with the root cause, got %v", chain)
+	}
+
+	stack, _ := loggedEntry["stack_trace"].(string)
+	if !strings.HasPrefix(stack, "Failed to load order: load order: ") || !strings.Contains(stack, "goroutine 1 [running]:") {
+		t.Errorf("Expected a Go-formatted stack trace, got %q", stack)
+	}
+	if !strings.Contains(stack, "TestLogErrorErr") || strings.Contains(stack, "stackTrace") {
+		t.Errorf("Expected the stack to start at the caller, got %q", stack)
+	}
+
+	location, _ := loggedEntry["logging.googleapis.com/sourceLocation"].(map[string]interface{})
+	if fn, _ := location["function"].(string); !strings.HasSuffix(fn, "TestLogErrorErr") {
+		t.Errorf("Expected the source location of the caller, got '%v'", location["function"])
+	}
+}
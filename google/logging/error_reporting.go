@@ -0,0 +1,215 @@
+// error_reporting.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/duizendstra/go/google/logging/internal/batch"
+)
+
+// Defaults of ErrorReporterConfig.
+const (
+	DefaultErrorReportingEndpoint = "https://clouderrorreporting.googleapis.com/v1beta1"
+	DefaultErrorReportBatchSize   = 20
+	DefaultErrorReportInterval    = 5 * time.Second
+	DefaultErrorReportQueueSize   = 1000
+)
+
+// ErrorReporterConfig configures an ErrorReporter.
+type ErrorReporterConfig struct {
+	// ProjectID is the project the events are reported to. Defaults to
+	// DetectProjectID.
+	ProjectID string
+	// Service and Version form the service context Error Reporting groups by,
+	// for example the Cloud Run service and revision.
+	Service string
+	Version string
+	// HTTPClient must be authorized for the Error Reporting API, for example
+	// one returned by google.DefaultClient. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// Endpoint defaults to DefaultErrorReportingEndpoint.
+	Endpoint string
+	// BatchSize is the number of queued events that triggers a send.
+	BatchSize int
+	// FlushInterval is the longest an event waits in the queue.
+	FlushInterval time.Duration
+	// QueueSize bounds the queue; events beyond it are dropped.
+	QueueSize int
+}
+
+// ErrorReporter reports ERROR and higher entries to the Cloud Error Reporting
+// API. Report only queues the event; a background goroutine sends the queue
+// in batches of cfg.BatchSize, and events that do not fit in it are counted
+// by Dropped. It is safe for concurrent use.
+type ErrorReporter struct {
+	cfg     ErrorReporterConfig
+	batcher *batch.Batcher[reportedErrorEvent]
+}
+
+// reportedErrorEvent is the ReportedErrorEvent of the Error Reporting API.
+type reportedErrorEvent struct {
+	EventTime      string              `json:"eventTime"`
+	ServiceContext errorServiceContext `json:"serviceContext"`
+	Message        string              `json:"message"`
+	Context        *errorContext       `json:"context,omitempty"`
+}
+
+type errorServiceContext struct {
+	Service string `json:"service"`
+	Version string `json:"version,omitempty"`
+}
+
+type errorContext struct {
+	ReportLocation *errorReportLocation `json:"reportLocation,omitempty"`
+}
+
+type errorReportLocation struct {
+	FilePath     string `json:"filePath"`
+	LineNumber   int    `json:"lineNumber"`
+	FunctionName string `json:"functionName"`
+}
+
+// NewErrorReporter starts an ErrorReporter. Call Close on shutdown to send
+// the queued events.
+func NewErrorReporter(cfg ErrorReporterConfig) *ErrorReporter {
+	if cfg.ProjectID == "" {
+		cfg.ProjectID = DetectProjectID(context.Background())
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = DefaultErrorReportingEndpoint
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultErrorReportBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultErrorReportInterval
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultErrorReportQueueSize
+	}
+
+	r := &ErrorReporter{cfg: cfg}
+	r.batcher = batch.New(cfg.BatchSize, cfg.FlushInterval, cfg.QueueSize, r.sendAll)
+	return r
+}
+
+// WithErrorReporting reports the ERROR and higher entries of the logger to
+// Cloud Error Reporting through r, in addition to writing them.
+func WithErrorReporting(r *ErrorReporter) Option {
+	return func(sl *StructuredLogger) {
+		sl.reporters = append(sl.reporters, r)
+		for _, level := range []slog.Level{slog.LevelError, LevelCritical, LevelAlert, LevelEmergency} {
+			sl.RegisterHook(level, r.Report)
+		}
+	}
+}
+
+// Report queues an entry without blocking. It drops the entry when the queue
+// is full or the reporter is closed.
+func (r *ErrorReporter) Report(e Entry) {
+	r.batcher.Add(r.event(e))
+}
+
+// Dropped returns the number of events that were dropped or failed to send.
+func (r *ErrorReporter) Dropped() int64 {
+	return r.batcher.Dropped()
+}
+
+// Flush sends the queued events and waits until they are sent or ctx is done.
+func (r *ErrorReporter) Flush(ctx context.Context) error {
+	return r.batcher.Flush(ctx)
+}
+
+// Close sends the queued events and stops the reporter.
+func (r *ErrorReporter) Close(ctx context.Context) error {
+	return r.batcher.Close(ctx)
+}
+
+// sendAll sends a batch of events one by one, as the API reports a single
+// event per call, and returns the number that failed.
+func (r *ErrorReporter) sendAll(events []reportedErrorEvent) (failed int) {
+	for _, event := range events {
+		if err := r.send(event); err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// send reports a single event with projects.events.report.
+func (r *ErrorReporter) send(event reportedErrorEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	url := fmt.Sprintf("%s/projects/%s/events:report", r.cfg.Endpoint, r.cfg.ProjectID)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error report failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// event converts an entry into a ReportedErrorEvent. The message is the stack
+// trace of LogErrorErr when present, otherwise the message and error text,
+// located by the source location of the entry.
+func (r *ErrorReporter) event(e Entry) reportedErrorEvent {
+	event := reportedErrorEvent{
+		EventTime:      e.Time.UTC().Format(time.RFC3339Nano),
+		ServiceContext: errorServiceContext{Service: r.cfg.Service, Version: r.cfg.Version},
+		Message:        e.Message,
+	}
+	if event.ServiceContext.Service == "" {
+		event.ServiceContext.Service = e.Component
+	}
+
+	var stack, errText string
+	for _, a := range e.Attrs {
+		switch a.Key {
+		case "stack_trace":
+			stack = a.Value.String()
+		case "error":
+			errText = fmt.Sprint(a.Value.Any())
+		}
+	}
+	switch {
+	case stack != "":
+		event.Message = stack
+	case errText != "":
+		event.Message = e.Message + ": " + errText
+	}
+	if e.Source.File != "" {
+		event.Context = &errorContext{ReportLocation: &errorReportLocation{
+			FilePath:     e.Source.File,
+			LineNumber:   e.Source.Line,
+			FunctionName: e.Source.Function,
+		}}
+	}
+	return event
+}
@@ -0,0 +1,85 @@
+// error_reporting_test.go
+
+package structured
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestErrorReporting(t *testing.T) {
+	var mu sync.Mutex
+	var events []map[string]interface{}
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var event map[string]interface{}
+		json.Unmarshal(body, &event)
+		mu.Lock()
+		events = append(events, event)
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.Write([]byte("{}"))
+	}))
+	defer ts.Close()
+
+	reporter := NewErrorReporter(ErrorReporterConfig{
+		ProjectID:     "test-project",
+		Service:       "orders",
+		Version:       "orders-00042",
+		Endpoint:      ts.URL,
+		FlushInterval: time.Hour,
+	})
+	sl := NewStructuredLogger("test-project", "test-component", nil, io.Discard, WithErrorReporting(reporter))
+	ctx := context.Background()
+
+	sl.LogWarning(ctx, "Not reported")
+	sl.LogError(ctx, "Payment failed", "error", errors.New("card declined"))
+	sl.LogErrorErr(ctx, "Save failed", errors.New("disk full"))
+
+	if err := reporter.Close(ctx); err != nil {
+		t.Fatalf("Close returned unexpected error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 reported events, got %d", len(events))
+	}
+	if paths[0] != "/projects/test-project/events:report" {
+		t.Errorf("Expected the events:report path, got '%s'", paths[0])
+	}
+
+	service, _ := events[0]["serviceContext"].(map[string]interface{})
+	if service["service"] != "orders" || service["version"] != "orders-00042" {
+		t.Errorf("Expected the configured service context, got %v", service)
+	}
+	if events[0]["message"] != "Payment failed: card declined" {
+		t.Errorf("Expected message 'Payment failed: card declined', got '%v'", events[0]["message"])
+	}
+	location, _ := events[0]["context"].(map[string]interface{})["reportLocation"].(map[string]interface{})
+	if fn, _ := location["functionName"].(string); !strings.HasSuffix(fn, "TestErrorReporting") {
+		t.Errorf("Expected the report location of the caller, got %v", location)
+	}
+	if msg, _ := events[1]["message"].(string); !strings.Contains(msg, "goroutine 1 [running]:") {
+		t.Errorf("Expected the stack trace as message, got '%s'", msg)
+	}
+}
+
+func TestErrorReporterDropsAfterClose(t *testing.T) {
+	reporter := NewErrorReporter(ErrorReporterConfig{ProjectID: "test-project", Endpoint: "http://127.0.0.1:0", QueueSize: 1, BatchSize: 100, FlushInterval: time.Hour})
+	reporter.Close(context.Background())
+
+	reporter.Report(Entry{Message: "after close"})
+	if reporter.Dropped() != 1 {
+		t.Errorf("Expected 1 dropped event, got %d", reporter.Dropped())
+	}
+}
@@ -0,0 +1,64 @@
+// filters.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"net/url"
+)
+
+// Filter post-processes an entry before it is written. It returns the entry
+// to write, possibly with a rewritten message, level, or attributes, and
+// false to drop the entry. Attrs holds the additional attributes of the call;
+// the component and trace fields are not filtered.
+type Filter func(Entry) (Entry, bool)
+
+// WithFilter appends filters to the chain applied to every entry before it is
+// written, in order. A dropped entry is not written and does not fire hooks.
+func WithFilter(filters ...Filter) Option {
+	return func(sl *StructuredLogger) {
+		for _, f := range filters {
+			if f != nil {
+				sl.filters = append(sl.filters, f)
+			}
+		}
+	}
+}
+
+// applyFilters runs the filter chain, stopping at the first filter that drops the entry.
+func (sl *StructuredLogger) applyFilters(e Entry) (Entry, bool) {
+	for _, f := range sl.filters {
+		var keep bool
+		if e, keep = f(e); !keep {
+			return e, false
+		}
+	}
+	return e, true
+}
+
+// DropPaths returns a filter that drops the entries Middleware writes for
+// requests to the given paths, such as health checks.
+func DropPaths(paths ...string) Filter {
+	drop := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		drop[p] = true
+	}
+	return func(e Entry) (Entry, bool) {
+		for _, a := range e.Attrs {
+			switch a.Key {
+			case "path":
+				if path, ok := a.Value.Any().(string); ok && drop[path] {
+					return e, false
+				}
+			case "httpRequest":
+				req, _ := a.Value.Any().(map[string]any)
+				raw, _ := req["requestUrl"].(string)
+				if u, err := url.Parse(raw); err == nil && drop[u.Path] {
+					return e, false
+				}
+			}
+		}
+		return e, true
+	}
+}
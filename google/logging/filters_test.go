@@ -0,0 +1,88 @@
+// filters_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithFilter(t *testing.T) {
+	var buf bytes.Buffer
+	redact := func(e Entry) (Entry, bool) {
+		for i, a := range e.Attrs {
+			if a.Key == "email" {
+				e.Attrs[i] = slog.String("email", "[REDACTED]")
+			}
+		}
+		return e, true
+	}
+	dropDebug := func(e Entry) (Entry, bool) {
+		return e, !strings.HasPrefix(e.Message, "noisy")
+	}
+	sl := NewStructuredLogger("", "test-component", nil, &buf, WithFilter(redact, dropDebug))
+
+	var hooked []string
+	sl.RegisterHook(slog.LevelInfo, func(e Entry) { hooked = append(hooked, e.Message) })
+
+	sl.LogInfo(context.Background(), "noisy entry")
+	sl.LogInfo(context.Background(), "User created", "email", "alice@example.com")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 entry, got %d: %v", len(lines), lines)
+	}
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if loggedEntry["email"] != "[REDACTED]" {
+		t.Errorf("Expected email '[REDACTED]', got '%v'", loggedEntry["email"])
+	}
+	if len(hooked) != 1 || hooked[0] != "User created" {
+		t.Errorf("Expected hooks to fire for the kept entry only, got %v", hooked)
+	}
+}
+
+func TestFilterRewritesLevel(t *testing.T) {
+	var buf bytes.Buffer
+	escalate := func(e Entry) (Entry, bool) {
+		e.Level = slog.LevelWarn
+		e.Message = "[escalated] " + e.Message
+		return e, true
+	}
+	sl := NewStructuredLogger("", "test-component", nil, &buf, WithFilter(escalate))
+	sl.LogInfo(context.Background(), "Slow query")
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if loggedEntry["level"] != "WARN" || loggedEntry["msg"] != "[escalated] Slow query" {
+		t.Errorf("Expected the rewritten level and message, got %v", loggedEntry)
+	}
+}
+
+func TestDropPaths(t *testing.T) {
+	var buf bytes.Buffer
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	mw := Middleware("test-project", "test-component", func(sl *StructuredLogger) {
+		sl.writer = &buf
+	}, WithFilter(DropPaths("/healthz")))(handler)
+
+	mw.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/healthz", nil))
+	if buf.Len() != 0 {
+		t.Errorf("Expected health check entries to be dropped, got %s", buf.String())
+	}
+
+	mw.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders?id=1", nil))
+	if !strings.Contains(buf.String(), "Request finished") {
+		t.Errorf("Expected the request to /orders to be logged, got %s", buf.String())
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/duizendstra/go/google/logging
 
 go 1.23.2
+
+require go.opentelemetry.io/otel v1.28.0
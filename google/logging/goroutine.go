@@ -0,0 +1,34 @@
+// goroutine.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Go runs fn in a new goroutine for background work spawned from a request.
+// The context passed to fn keeps the values of ctx, such as the
+// request-scoped logger of Middleware with its trace and the OpenTelemetry
+// baggage, so the entries of the goroutine stay correlated with the request.
+// It is not canceled with ctx, so the work can outlive the handler.
+//
+// A panic in fn is recovered and logged at ERROR through FromContext(ctx),
+// with a stack trace that Cloud Error Reporting picks up, instead of crashing
+// the process.
+func Go(ctx context.Context, fn func(ctx context.Context)) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer recoverGoroutine(ctx)
+		fn(ctx)
+	}()
+}
+
+// recoverGoroutine logs a recovered panic. It must be deferred directly.
+func recoverGoroutine(ctx context.Context) {
+	if p := recover(); p != nil {
+		logPanic(ctx, slog.LevelError, "Panic in background goroutine", p)
+	}
+}
@@ -0,0 +1,78 @@
+// goroutine_test.go
+
+package structured
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chanWriter sends every write on a channel.
+type chanWriter chan []byte
+
+func (w chanWriter) Write(p []byte) (int, error) {
+	w <- append([]byte(nil), p...)
+	return len(p), nil
+}
+
+func panicInJob() {
+	panic("nil job")
+}
+
+func TestGoRecoversPanic(t *testing.T) {
+	out := make(chanWriter, 1)
+	logger := NewStructuredLogger("", "test-component", nil, out)
+	ctx := NewContext(context.Background(), logger)
+
+	Go(ctx, func(ctx context.Context) {
+		panicInJob()
+	})
+
+	var line []byte
+	select {
+	case line = <-out:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the panic to be logged")
+	}
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(line, &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if loggedEntry["level"] != "ERROR" || loggedEntry["error"] != "nil job" {
+		t.Errorf("Expected an ERROR entry for the panic, got %v", loggedEntry)
+	}
+	if loggedEntry["@type"] != ReportedErrorEventType {
+		t.Errorf("Expected @type %q, got %v", ReportedErrorEventType, loggedEntry["@type"])
+	}
+	stack, _ := loggedEntry["stack_trace"].(string)
+	if !strings.Contains(stack, "panicInJob") {
+		t.Errorf("Expected the stack trace to contain the panicking function, got %q", stack)
+	}
+	location, _ := loggedEntry["logging.googleapis.com/sourceLocation"].(map[string]interface{})
+	if function, _ := location["function"].(string); !strings.HasSuffix(function, ".panicInJob") {
+		t.Errorf("Expected the source location of the panic, got %v", location)
+	}
+}
+
+func TestGoKeepsContext(t *testing.T) {
+	logger := NewStructuredLogger("", "test-component", nil, nil)
+	ctx, cancel := context.WithCancel(NewContext(context.Background(), logger))
+
+	done := make(chan context.Context)
+	Go(ctx, func(ctx context.Context) {
+		done <- ctx
+	})
+	cancel()
+
+	child := <-done
+	if FromContext(child) != logger {
+		t.Error("Expected the child context to carry the request logger")
+	}
+	if child.Err() != nil {
+		t.Errorf("Expected the child context not to be canceled with the parent, got %v", child.Err())
+	}
+}
@@ -0,0 +1,101 @@
+// hooks.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Entry is a snapshot of a single log entry as it was written by the logger.
+type Entry struct {
+	Time         time.Time
+	Level        slog.Level
+	Message      string
+	Component    string
+	TraceID      string
+	SpanID       string
+	TraceSampled bool
+	// Source is the caller location, set for ERROR and higher.
+	Source slog.Source
+	Attrs  []slog.Attr
+}
+
+// hookRegistry holds the hooks registered per severity and the entry hooks
+// that run before serialization.
+type hookRegistry struct {
+	mu      sync.RWMutex
+	hooks   map[slog.Level][]func(Entry)
+	entries []func(context.Context, *Entry) error
+}
+
+// RegisterHook registers a function that is called for every entry written at
+// exactly the given level. Hooks run synchronously after the entry is written,
+// so long-running side effects should be dispatched to a goroutine.
+func (sl *StructuredLogger) RegisterHook(level slog.Level, hook func(Entry)) {
+	if hook == nil {
+		return
+	}
+	sl.hooks.mu.Lock()
+	defer sl.hooks.mu.Unlock()
+	if sl.hooks.hooks == nil {
+		sl.hooks.hooks = make(map[slog.Level][]func(Entry))
+	}
+	sl.hooks.hooks[level] = append(sl.hooks.hooks[level], hook)
+}
+
+// fire calls the hooks registered for the entry's level.
+func (r *hookRegistry) fire(e Entry) {
+	r.mu.RLock()
+	hooks := r.hooks[e.Level]
+	r.mu.RUnlock()
+	for _, hook := range hooks {
+		hook(e)
+	}
+}
+
+// has reports whether any hook is registered for the level.
+func (r *hookRegistry) has(level slog.Level) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.hooks[level]) > 0
+}
+
+// AddHook registers a function that runs for every entry before it is
+// serialized, after the filters. The hook may enrich the entry, for example
+// by appending attributes such as the region or build SHA, or rewrite its
+// message and level. Returning an error vetoes the entry: it is not written
+// and does not fire the hooks registered with RegisterHook. Hooks run in the
+// order they were added and are shared with loggers derived through With.
+func (sl *StructuredLogger) AddHook(hook func(ctx context.Context, e *Entry) error) {
+	if hook == nil {
+		return
+	}
+	sl.hooks.mu.Lock()
+	defer sl.hooks.mu.Unlock()
+	sl.hooks.entries = append(sl.hooks.entries, hook)
+}
+
+// hasEntryHooks reports whether any entry hook is registered.
+func (r *hookRegistry) hasEntryHooks() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.entries) > 0
+}
+
+// runEntryHooks runs the entry hooks, stopping at the first one that vetoes the entry.
+func (r *hookRegistry) runEntryHooks(ctx context.Context, e *Entry) bool {
+	r.mu.RLock()
+	hooks := r.entries
+	r.mu.RUnlock()
+	for _, hook := range hooks {
+		if hook(ctx, e) != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+// hooks_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestRegisterHook(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+
+	var errors, emergencies []Entry
+	sl.RegisterHook(slog.LevelError, func(e Entry) { errors = append(errors, e) })
+	sl.RegisterHook(LevelEmergency, func(e Entry) { emergencies = append(emergencies, e) })
+
+	ctx := context.Background()
+	sl.LogInfo(ctx, "info message")
+	sl.LogError(ctx, "error message", "userID", 42)
+	sl.LogEmergency(ctx, "emergency message")
+
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 ERROR hook call, got %d", len(errors))
+	}
+	if errors[0].Message != "error message" {
+		t.Errorf("Expected message 'error message', got '%s'", errors[0].Message)
+	}
+	if errors[0].Component != "test-component" {
+		t.Errorf("Expected component 'test-component', got '%s'", errors[0].Component)
+	}
+	if len(errors[0].Attrs) != 1 || errors[0].Attrs[0].Key != "userID" {
+		t.Errorf("Expected only the userID attribute, got %v", errors[0].Attrs)
+	}
+	if len(emergencies) != 1 {
+		t.Fatalf("Expected 1 EMERGENCY hook call, got %d", len(emergencies))
+	}
+}
+
+func TestRegisterHookDisabledLevel(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+	sl.SetLogLevel("ERROR")
+
+	called := false
+	sl.RegisterHook(slog.LevelWarn, func(Entry) { called = true })
+
+	sl.LogWarning(context.Background(), "filtered warning")
+
+	if called {
+		t.Errorf("Expected hook not to be called for a level below the minimum log level")
+	}
+}
+
+func TestAddHook(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+
+	type ctxKey struct{}
+	sl.AddHook(func(ctx context.Context, e *Entry) error {
+		e.Attrs = append(e.Attrs, slog.String("region", ctx.Value(ctxKey{}).(string)))
+		return nil
+	})
+	sl.AddHook(func(ctx context.Context, e *Entry) error {
+		if e.Message == "noisy" {
+			return errors.New("vetoed")
+		}
+		return nil
+	})
+
+	var fired int
+	sl.RegisterHook(slog.LevelInfo, func(e Entry) { fired++ })
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "europe-west1")
+	sl.LogInfo(ctx, "noisy")
+	if buf.Len() != 0 || fired != 0 {
+		t.Fatalf("Expected the vetoed entry to be dropped, got %q and %d hook calls", buf.String(), fired)
+	}
+
+	sl.LogInfo(ctx, "Order shipped")
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if loggedEntry["region"] != "europe-west1" {
+		t.Errorf("Expected the hook to add the region, got %v", loggedEntry)
+	}
+	if fired != 1 {
+		t.Errorf("Expected 1 hook call, got %d", fired)
+	}
+}
@@ -0,0 +1,138 @@
+// batch.go
+
+// [License Header Omitted for Brevity]
+
+// Package batch queues items and hands them to a send function in batches
+// from a background goroutine, for the exporters of the logging packages.
+package batch
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Batcher queues items without blocking and sends them when a batch is full,
+// the flush interval has passed, or Flush is called. Items that do not fit in
+// the queue, or arrive after Close, are dropped. It is safe for concurrent use.
+type Batcher[T any] struct {
+	size     int
+	interval time.Duration
+	send     func(batch []T) (failed int)
+
+	queue   chan T
+	flush   chan chan struct{}
+	done    chan struct{}
+	closed  sync.Once
+	dropped atomic.Int64
+}
+
+// New starts a Batcher sending batches of up to size items, at least every
+// interval, with a queue of queueSize items. send returns the number of items
+// it failed to deliver, which are counted as dropped. send owns the batch it
+// is given.
+func New[T any](size int, interval time.Duration, queueSize int, send func(batch []T) (failed int)) *Batcher[T] {
+	b := &Batcher[T]{
+		size:     size,
+		interval: interval,
+		send:     send,
+		queue:    make(chan T, queueSize),
+		flush:    make(chan chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// Add queues item, dropping it when the queue is full or b is closed.
+func (b *Batcher[T]) Add(item T) {
+	select {
+	case <-b.done:
+		b.dropped.Add(1)
+		return
+	default:
+	}
+	select {
+	case b.queue <- item:
+	default:
+		b.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of items that were dropped or failed to send.
+func (b *Batcher[T]) Dropped() int64 {
+	return b.dropped.Load()
+}
+
+// Flush sends the queued items and waits until they are sent or ctx is done.
+func (b *Batcher[T]) Flush(ctx context.Context) error {
+	sent := make(chan struct{})
+	select {
+	case b.flush <- sent:
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-sent:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close sends the queued items and stops the background goroutine.
+func (b *Batcher[T]) Close(ctx context.Context) error {
+	err := b.Flush(ctx)
+	b.closed.Do(func() { close(b.done) })
+	return err
+}
+
+func (b *Batcher[T]) run() {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	batch := make([]T, 0, b.size)
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if failed := b.send(batch); failed > 0 {
+			b.dropped.Add(int64(failed))
+		}
+		batch = make([]T, 0, b.size)
+	}
+	drain := func() {
+		for {
+			select {
+			case item := <-b.queue:
+				batch = append(batch, item)
+				if len(batch) >= b.size {
+					send()
+				}
+			default:
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case item := <-b.queue:
+			batch = append(batch, item)
+			if len(batch) >= b.size {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case sent := <-b.flush:
+			drain()
+			send()
+			close(sent)
+		case <-b.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// batch_test.go
+
+package batch
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatcher(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]int
+	b := New(2, time.Hour, 10, func(batch []int) int {
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, batch)
+		return 0
+	})
+
+	for i := 1; i <= 3; i++ {
+		b.Add(i)
+	}
+	if err := b.Close(context.Background()); err != nil {
+		t.Fatalf("Close returned unexpected error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Errorf("Expected a full batch and the flushed remainder, got %v", batches)
+	}
+	b.Add(4)
+	if b.Dropped() != 1 {
+		t.Errorf("Expected items added after Close to be dropped, got %d", b.Dropped())
+	}
+}
+
+func TestBatcherDropsWhenFull(t *testing.T) {
+	release := make(chan struct{})
+	b := New(1, time.Hour, 1, func(batch []int) int {
+		<-release
+		return len(batch)
+	})
+
+	// The first item blocks the sender, the second fills the queue.
+	b.Add(1)
+	time.Sleep(10 * time.Millisecond)
+	b.Add(2)
+	b.Add(3)
+	if b.Dropped() != 1 {
+		t.Errorf("Expected the item beyond the queue to be dropped, got %d", b.Dropped())
+	}
+	close(release)
+	b.Close(context.Background())
+	if b.Dropped() != 3 {
+		t.Errorf("Expected the failed items to be counted, got %d", b.Dropped())
+	}
+}
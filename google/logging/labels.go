@@ -0,0 +1,59 @@
+// labels.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"log/slog"
+	"maps"
+	"slices"
+)
+
+// keyLabels is the Cloud Logging special field holding the entry labels.
+const keyLabels = "logging.googleapis.com/labels"
+
+// LabelArg is a per-call label, created with Label and passed among the
+// key-value arguments of a Log call.
+type LabelArg struct {
+	Key   string
+	Value string
+}
+
+// Label returns a label for a single entry, for example
+//
+//	logger.LogInfo(ctx, "Order shipped", structured.Label("tenant", tenant), "order_id", id)
+//
+// Per-call labels override logger labels with the same key.
+func Label(key, value string) LabelArg {
+	return LabelArg{Key: key, Value: value}
+}
+
+// WithLabels adds labels to every entry of the logger. Labels are written to
+// logging.googleapis.com/labels, which Cloud Logging indexes for filtering.
+func WithLabels(labels map[string]string) Option {
+	return func(sl *StructuredLogger) {
+		if sl.labels == nil {
+			sl.labels = make(map[string]string, len(labels))
+		}
+		maps.Copy(sl.labels, labels)
+	}
+}
+
+// labelsAttr merges the logger labels with the per-call labels into the
+// labels group, sorted by key. It returns false when there are no labels.
+func (sl *StructuredLogger) labelsAttr(call []LabelArg) (slog.Attr, bool) {
+	if len(sl.labels) == 0 && len(call) == 0 {
+		return slog.Attr{}, false
+	}
+	merged := make(map[string]string, len(sl.labels)+len(call))
+	maps.Copy(merged, sl.labels)
+	for _, l := range call {
+		merged[l.Key] = l.Value
+	}
+	attrs := make([]any, 0, len(merged))
+	for _, k := range slices.Sorted(maps.Keys(merged)) {
+		attrs = append(attrs, slog.String(k, merged[k]))
+	}
+	return slog.Group(keyLabels, attrs...), true
+}
@@ -0,0 +1,67 @@
+// labels_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestWithLabels(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf, WithLabels(map[string]string{"env": "prod", "tenant": "acme"}))
+	sl.LogInfo(context.Background(), "Order shipped", Label("tenant", "globex"), "order_id", "o-1", Label("region", "eu"))
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+
+	labels, ok := loggedEntry[keyLabels].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected labels object, got %v", loggedEntry[keyLabels])
+	}
+	expected := map[string]string{"env": "prod", "tenant": "globex", "region": "eu"}
+	if len(labels) != len(expected) {
+		t.Errorf("Expected %d labels, got %v", len(expected), labels)
+	}
+	for key, value := range expected {
+		if labels[key] != value {
+			t.Errorf("Expected label %s '%s', got '%v'", key, value, labels[key])
+		}
+	}
+	if loggedEntry["order_id"] != "o-1" {
+		t.Errorf("Expected order_id 'o-1', got '%v'", loggedEntry["order_id"])
+	}
+}
+
+func TestNoLabels(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+	sl.LogInfo(context.Background(), "No labels")
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if _, ok := loggedEntry[keyLabels]; ok {
+		t.Errorf("Expected no labels field, got %v", loggedEntry[keyLabels])
+	}
+}
+
+func TestLabelsECS(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf, WithFormat(FormatECS), WithLabels(map[string]string{"env": "prod"}))
+	sl.LogInfo(context.Background(), "ECS labels")
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	labels, _ := loggedEntry["labels"].(map[string]interface{})
+	if labels["env"] != "prod" {
+		t.Errorf("Expected ECS labels.env 'prod', got '%v'", loggedEntry["labels"])
+	}
+}
@@ -0,0 +1,32 @@
+// legacy.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"log/slog"
+	"time"
+)
+
+// WithLegacyFields writes the field names of the legacy loggers next to the
+// slog ones until the given time: "message" alongside "msg" and "severity"
+// alongside "level". Dashboards and log-based metrics built on the old schema
+// keep working while services migrate, and the duplicates stop on their own
+// once the migration window has passed. It applies to FormatJSON only.
+func WithLegacyFields(until time.Time) Option {
+	return func(sl *StructuredLogger) {
+		sl.legacyUntil = until
+	}
+}
+
+// legacyAttrs returns the legacy fields for an entry while the compatibility window is open.
+func (sl *StructuredLogger) legacyAttrs(level slog.Level, msg string) []slog.Attr {
+	if sl.format != FormatJSON || sl.legacyUntil.IsZero() || !time.Now().Before(sl.legacyUntil) {
+		return nil
+	}
+	return []slog.Attr{
+		slog.String("message", msg),
+		slog.String("severity", severity(level)),
+	}
+}
@@ -0,0 +1,66 @@
+// legacy_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestWithLegacyFields(t *testing.T) {
+	tests := []struct {
+		name           string
+		until          time.Time
+		expectedLegacy bool
+	}{
+		{"Within window", time.Now().Add(time.Hour), true},
+		{"Window passed", time.Now().Add(-time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			sl := NewStructuredLogger("", "test-component", nil, &buf, WithLegacyFields(tt.until))
+			sl.LogWarning(context.Background(), "Disk almost full")
+
+			var loggedEntry map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+				t.Fatalf("Error unmarshaling log output: %v", err)
+			}
+			if loggedEntry["msg"] != "Disk almost full" || loggedEntry["level"] != "WARN" {
+				t.Errorf("Expected the slog fields to be kept, got %v", loggedEntry)
+			}
+
+			_, hasMessage := loggedEntry["message"]
+			_, hasSeverity := loggedEntry["severity"]
+			if hasMessage != tt.expectedLegacy || hasSeverity != tt.expectedLegacy {
+				t.Errorf("Expected legacy fields present=%v, got message=%v severity=%v", tt.expectedLegacy, hasMessage, hasSeverity)
+			}
+			if tt.expectedLegacy && (loggedEntry["message"] != "Disk almost full" || loggedEntry["severity"] != "WARNING") {
+				t.Errorf("Unexpected legacy fields: message='%v' severity='%v'", loggedEntry["message"], loggedEntry["severity"])
+			}
+		})
+	}
+}
+
+func TestWithLegacyFieldsAfterFilters(t *testing.T) {
+	var buf bytes.Buffer
+	escalate := func(e Entry) (Entry, bool) {
+		e.Level, e.Message = slog.LevelError, "Disk full"
+		return e, true
+	}
+	sl := NewStructuredLogger("", "test-component", nil, &buf, WithLegacyFields(time.Now().Add(time.Hour)), WithFilter(escalate))
+	sl.LogWarning(context.Background(), "Disk almost full")
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if loggedEntry["message"] != "Disk full" || loggedEntry["severity"] != "ERROR" {
+		t.Errorf("Expected the legacy fields of the filtered entry, got message='%v' severity='%v'", loggedEntry["message"], loggedEntry["severity"])
+	}
+}
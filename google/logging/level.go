@@ -0,0 +1,85 @@
+// level.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// LevelEnv is the environment variable with the initial default level.
+const LevelEnv = "LOG_LEVEL"
+
+var (
+	levelOnce sync.Once
+	levelVar  slog.LevelVar
+)
+
+// defaultLevel returns the level shared by all loggers that have not been
+// given a level with SetLogLevel, initialized from LOG_LEVEL (INFO when unset
+// or unknown).
+func defaultLevel() *slog.LevelVar {
+	levelOnce.Do(func() {
+		if level, ok := ParseLevel(os.Getenv(LevelEnv)); ok {
+			levelVar.Set(level)
+		}
+	})
+	return &levelVar
+}
+
+// SetDefaultLevel changes the default level at runtime. It applies to all
+// loggers, including the ones already created, except loggers with a level
+// set by WithLevel, SetLogLevel, or a debug header. It returns false for an
+// unknown name.
+func SetDefaultLevel(level string) bool {
+	l, ok := ParseLevel(level)
+	if ok {
+		defaultLevel().Set(l)
+	}
+	return ok
+}
+
+// DefaultLevel returns the severity name of the default level.
+func DefaultLevel() string {
+	return severity(defaultLevel().Level())
+}
+
+// LevelHandler returns an admin handler for the default level, so operators
+// can switch a running service to DEBUG without redeploying. GET returns
+// {"level":"INFO"}; PUT or POST sets the level from the "level" query
+// parameter or a JSON body of the same shape. Mount it on an internal or
+// authenticated route only.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			level := r.URL.Query().Get("level")
+			if level == "" {
+				var body struct {
+					Level string `json:"level"`
+				}
+				if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(&body); err != nil {
+					http.Error(w, "Expected a level parameter or a JSON body with a level", http.StatusBadRequest)
+					return
+				}
+				level = body.Level
+			}
+			if !SetDefaultLevel(level) {
+				http.Error(w, "Unknown level "+level, http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"level": DefaultLevel()})
+	})
+}
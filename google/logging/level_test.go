@@ -0,0 +1,59 @@
+// level_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLevelHandler(t *testing.T) {
+	defer SetDefaultLevel("INFO")
+
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+	sl.LogDebug(context.Background(), "Hidden")
+
+	handler := LevelHandler()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("PUT", "/admin/log-level", strings.NewReader(`{"level":"debug"}`)))
+	if recorder.Code != http.StatusOK || strings.TrimSpace(recorder.Body.String()) != `{"level":"DEBUG"}` {
+		t.Fatalf("Expected the level to be set to DEBUG, got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	// The change applies to loggers that already exist.
+	sl.LogDebug(context.Background(), "Visible")
+	if strings.Contains(buf.String(), "Hidden") || !strings.Contains(buf.String(), "Visible") {
+		t.Errorf("Expected only the entry after the change, got %s", buf.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/admin/log-level?level=verbose", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown level, got %d", http.StatusBadRequest, recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/admin/log-level", nil))
+	if strings.TrimSpace(recorder.Body.String()) != `{"level":"DEBUG"}` {
+		t.Errorf("Expected GET to return the current level, got %s", recorder.Body.String())
+	}
+}
+
+func TestSetLogLevelOverridesDefault(t *testing.T) {
+	defer SetDefaultLevel("INFO")
+
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+	sl.SetLogLevel("ERROR")
+	SetDefaultLevel("DEBUG")
+
+	sl.LogInfo(context.Background(), "Hidden")
+	if buf.Len() != 0 {
+		t.Errorf("Expected a logger with its own level to ignore the default, got %s", buf.String())
+	}
+}
@@ -0,0 +1,101 @@
+// lifecycle.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// flushCloser is implemented by AsyncWriter and ErrorReporter.
+type flushCloser interface {
+	Flush(ctx context.Context) error
+	Close(ctx context.Context) error
+}
+
+// flushClosers returns the destinations of sl that buffer entries: the
+// ErrorReporters of WithErrorReporting, additional handlers that implement
+// Flush and Close, and the writer when it is an AsyncWriter.
+func (sl *StructuredLogger) flushClosers() []flushCloser {
+	var fcs []flushCloser
+	for _, r := range sl.reporters {
+		fcs = append(fcs, r)
+	}
+	for _, h := range sl.additional {
+		if fc, ok := h.(flushCloser); ok {
+			fcs = append(fcs, fc)
+		}
+	}
+	if fc, ok := sl.writer.(flushCloser); ok {
+		fcs = append(fcs, fc)
+	}
+	return fcs
+}
+
+// Flush waits until the entries logged so far have reached their
+// destinations, or ctx is done. It is a no-op for a synchronous writer.
+func (sl *StructuredLogger) Flush(ctx context.Context) error {
+	var errs []error
+	for _, fc := range sl.flushClosers() {
+		errs = append(errs, fc.Flush(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// Close flushes the logger and stops its background goroutines, such as those
+// of an AsyncWriter and of the ErrorReporters, which loggers created with the
+// same options share. Call it once on shutdown, after the last entry.
+func (sl *StructuredLogger) Close(ctx context.Context) error {
+	var errs []error
+	for _, fc := range sl.flushClosers() {
+		errs = append(errs, fc.Close(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// CloseOnSignal closes sl, waiting at most timeout, when the process receives
+// one of signals, SIGTERM by default, and then lets the signal terminate the
+// process as it would have without the handler. Cloud Run sends SIGTERM ten
+// seconds before stopping an instance; this keeps the final entries from
+// being lost. Servers that shut down gracefully on SIGTERM should call Close
+// after their shutdown instead. The returned function removes the handler.
+func (sl *StructuredLogger) CloseOnSignal(timeout time.Duration, signals ...os.Signal) (stop func()) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGTERM}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	done := make(chan struct{})
+	go sl.closeOn(ch, done, timeout, reraise)
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
+
+// closeOn closes sl when a signal arrives on ch and then calls exit with it.
+func (sl *StructuredLogger) closeOn(ch <-chan os.Signal, done <-chan struct{}, timeout time.Duration, exit func(os.Signal)) {
+	select {
+	case sig := <-ch:
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		sl.Close(ctx)
+		cancel()
+		exit(sig)
+	case <-done:
+	}
+}
+
+// reraise restores the default handling of sig and sends it to the process.
+func reraise(sig os.Signal) {
+	signal.Reset(sig)
+	if p, err := os.FindProcess(os.Getpid()); err == nil && p.Signal(sig) == nil {
+		return
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,88 @@
+// lifecycle_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFlushAndClose(t *testing.T) {
+	var reports atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reports.Add(1)
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	w := NewAsyncWriter(&buf, 0, Block)
+	reporter := NewErrorReporter(ErrorReporterConfig{ProjectID: "test-project", Endpoint: ts.URL, FlushInterval: time.Hour})
+	sl := NewStructuredLogger("", "test-component", nil, w, WithErrorReporting(reporter))
+
+	sl.LogError(context.Background(), "Export failed")
+	if err := sl.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush returned unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Export failed") {
+		t.Errorf("Expected the entry to be written after Flush, got %q", buf.String())
+	}
+	if reports.Load() != 1 {
+		t.Errorf("Expected 1 error report after Flush, got %d", reports.Load())
+	}
+
+	if err := sl.Close(context.Background()); err != nil {
+		t.Fatalf("Close returned unexpected error: %v", err)
+	}
+	sl.LogError(context.Background(), "After close")
+	if reporter.Dropped() != 1 {
+		t.Errorf("Expected reports after Close to be dropped, got %d dropped", reporter.Dropped())
+	}
+}
+
+func TestFlushSyncWriter(t *testing.T) {
+	sl := NewStructuredLogger("", "test-component", nil, &bytes.Buffer{})
+	if err := sl.Flush(context.Background()); err != nil {
+		t.Errorf("Expected Flush to be a no-op, got %v", err)
+	}
+	if err := sl.Close(context.Background()); err != nil {
+		t.Errorf("Expected Close to be a no-op, got %v", err)
+	}
+}
+
+func TestCloseOnSignal(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewAsyncWriter(&buf, 0, Block)
+	sl := NewStructuredLogger("", "test-component", nil, w)
+	sl.LogInfo(context.Background(), "Last entry")
+
+	ch := make(chan os.Signal, 1)
+	exited := make(chan os.Signal, 1)
+	go sl.closeOn(ch, make(chan struct{}), time.Second, func(sig os.Signal) { exited <- sig })
+	ch <- syscall.SIGTERM
+
+	select {
+	case sig := <-exited:
+		if sig != syscall.SIGTERM {
+			t.Errorf("Expected exit with SIGTERM, got %v", sig)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the process to exit after the signal")
+	}
+	if !strings.Contains(buf.String(), "Last entry") {
+		t.Errorf("Expected the queued entry to be written before exit, got %q", buf.String())
+	}
+}
+
+func TestCloseOnSignalStop(t *testing.T) {
+	sl := NewStructuredLogger("", "test-component", nil, &bytes.Buffer{})
+	stop := sl.CloseOnSignal(time.Second)
+	stop()
+}
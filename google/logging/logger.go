@@ -0,0 +1,120 @@
+// logger.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+)
+
+// Logger is the leveled logging API of StructuredLogger. Libraries should
+// accept a Logger rather than a *StructuredLogger, so callers can pass a
+// StructuredLogger, an adapted *slog.Logger, or a test double.
+type Logger interface {
+	Log(ctx context.Context, level slog.Level, msg string, args ...any)
+	LogDebug(ctx context.Context, msg string, args ...any)
+	LogInfo(ctx context.Context, msg string, args ...any)
+	LogWarning(ctx context.Context, msg string, args ...any)
+	LogError(ctx context.Context, msg string, args ...any)
+}
+
+var _ Logger = (*StructuredLogger)(nil)
+
+// FromSlog adapts a *slog.Logger to Logger, for code that already logs
+// through slog and is migrating incrementally.
+func FromSlog(logger *slog.Logger) Logger {
+	return slogLogger{logger}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l slogLogger) Log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	l.logger.Log(ctx, level, msg, args...)
+}
+
+func (l slogLogger) LogDebug(ctx context.Context, msg string, args ...any) {
+	l.logger.Log(ctx, slog.LevelDebug, msg, args...)
+}
+
+func (l slogLogger) LogInfo(ctx context.Context, msg string, args ...any) {
+	l.logger.Log(ctx, slog.LevelInfo, msg, args...)
+}
+
+func (l slogLogger) LogWarning(ctx context.Context, msg string, args ...any) {
+	l.logger.Log(ctx, slog.LevelWarn, msg, args...)
+}
+
+func (l slogLogger) LogError(ctx context.Context, msg string, args ...any) {
+	l.logger.Log(ctx, slog.LevelError, msg, args...)
+}
+
+// MessageLogger is the single-method logger of APIs that log plain
+// messages, such as errors.HandleError.
+type MessageLogger interface {
+	LogError(msg string)
+}
+
+// ErrorMessages adapts logger to MessageLogger, logging each message at
+// ERROR with ctx, so the trace context of a request is kept. The adapter
+// also logs at the severity of errors escalated with errors.EscalateSeverity,
+// and logs the error ID of errors.HandleError as the error_id label:
+//
+//	errors.HandleError(structured.ErrorMessages(r.Context(), logger), w, err)
+func ErrorMessages(ctx context.Context, logger Logger) MessageLogger {
+	return messageLogger{ctx: ctx, logger: logger}
+}
+
+type messageLogger struct {
+	ctx    context.Context
+	logger Logger
+}
+
+func (l messageLogger) LogError(msg string) {
+	l.log(slog.LevelError, msg)
+}
+
+// LogSeverity logs msg at the level named by severity, such as "CRITICAL",
+// for errors escalated with errors.EscalateSeverity.
+func (l messageLogger) LogSeverity(severity, msg string) {
+	level, ok := ParseLevel(severity)
+	if !ok {
+		level = slog.LevelError
+	}
+	l.log(level, msg)
+}
+
+// LogSeverityLabels logs msg at the level named by severity with labels,
+// such as the error_id label of errors.HandleError.
+func (l messageLogger) LogSeverityLabels(severity, msg string, labels map[string]string) {
+	level, ok := ParseLevel(severity)
+	if !ok {
+		level = slog.LevelError
+	}
+	_, structured := l.logger.(*StructuredLogger)
+	var args []any
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		if structured {
+			args = append(args, Label(key, labels[key]))
+		} else {
+			// Other loggers have no labels; log them as attributes
+			args = append(args, key, labels[key])
+		}
+	}
+	l.log(level, msg, args...)
+}
+
+// log reports the caller of the adapter as the source location when logger
+// is a *StructuredLogger.
+func (l messageLogger) log(level slog.Level, msg string, args ...any) {
+	if sl, ok := l.logger.(*StructuredLogger); ok {
+		sl.log(l.ctx, 2, level, msg, args...)
+		return
+	}
+	l.logger.Log(l.ctx, level, msg, args...)
+}
@@ -0,0 +1,70 @@
+// logger_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestFromSlog(t *testing.T) {
+	var buf bytes.Buffer
+	var logger Logger = FromSlog(slog.New(slog.NewJSONHandler(&buf, nil)))
+	logger.LogWarning(context.Background(), "Quota low", "remaining", 3)
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if loggedEntry["level"] != "WARN" || loggedEntry["msg"] != "Quota low" || loggedEntry["remaining"] != float64(3) {
+		t.Errorf("Expected the warning through slog, got %v", loggedEntry)
+	}
+}
+
+func TestErrorMessages(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+	ErrorMessages(context.Background(), sl).LogError("API request failed")
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if loggedEntry["level"] != "ERROR" || loggedEntry["msg"] != "API request failed" {
+		t.Errorf("Expected an ERROR entry, got %v", loggedEntry)
+	}
+}
+
+func TestErrorMessagesSeverity(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+	ErrorMessages(context.Background(), sl).(interface{ LogSeverity(string, string) }).LogSeverity("ALERT", "Quota exhausted")
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if loggedEntry["level"] != "ERROR+2" {
+		t.Errorf("Expected an ALERT entry, got %v", loggedEntry)
+	}
+}
+
+func TestErrorMessagesLabels(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+	ErrorMessages(context.Background(), sl).(interface {
+		LogSeverityLabels(string, string, map[string]string)
+	}).LogSeverityLabels("ERROR", "API request failed", map[string]string{"error_id": "1a2b3c4d"})
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	labels, _ := loggedEntry["logging.googleapis.com/labels"].(map[string]interface{})
+	if loggedEntry["msg"] != "API request failed" || labels["error_id"] != "1a2b3c4d" {
+		t.Errorf("Expected the error_id label, got %v", loggedEntry)
+	}
+}
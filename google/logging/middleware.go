@@ -0,0 +1,149 @@
+// middleware.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Middleware returns HTTP middleware that creates a request-scoped logger
+// from the X-Cloud-Trace-Context (or traceparent) header, stores it in the
+// request context for FromContext, and logs the start of each request at
+// DEBUG and its completion with latency, status, and a Cloud Logging
+// httpRequest field. Completion is logged at INFO, WARNING for 4xx, and
+// ERROR for 5xx, followed by CheckDeadline.
+func Middleware(projectID, component string, opts ...Option) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			logger := NewStructuredLogger(projectID, component, r, nil, opts...)
+			ctx := NewContext(r.Context(), logger)
+
+			logger.LogDebug(ctx, "Request started", "method", r.Method, "path", r.URL.Path)
+
+			rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r.WithContext(ctx))
+
+			latency := time.Since(start)
+			if logger.tail != nil {
+				logger.tail.finish(rw.status, latency)
+			}
+			level := slog.LevelInfo
+			switch {
+			case rw.status >= 500:
+				level = slog.LevelError
+			case rw.status >= 400:
+				level = slog.LevelWarn
+			}
+			logger.Log(ctx, level, "Request finished",
+				"httpRequest", httpRequest(r, rw.status, rw.size, latency, logger.trustedProxyCount()),
+				"latency_ms", latency.Milliseconds(),
+				"status", rw.status,
+			)
+			logger.CheckDeadline(ctx, start)
+		})
+	}
+}
+
+// DefaultTrustedProxies is the number of proxies assumed to append to
+// X-Forwarded-For: the Google Front End in front of Cloud Run and App Engine.
+const DefaultTrustedProxies = 1
+
+// WithTrustedProxies sets the number of proxies in front of the service that
+// append the address they received the request from to X-Forwarded-For, such
+// as 2 behind an external Application Load Balancer and the Google Front End.
+// Middleware logs the entry the outermost trusted proxy appended as the
+// remote IP, since the entries before it are set by the client. Zero ignores
+// the header and logs the peer address. Defaults to DefaultTrustedProxies.
+func WithTrustedProxies(n int) Option {
+	return func(sl *StructuredLogger) {
+		sl.trustedProxies = &n
+	}
+}
+
+func (sl *StructuredLogger) trustedProxyCount() int {
+	if sl.trustedProxies != nil {
+		return *sl.trustedProxies
+	}
+	return DefaultTrustedProxies
+}
+
+// httpRequest builds the Cloud Logging HttpRequest structure, taking the
+// remote IP from the X-Forwarded-For entry of the outermost of trustedProxies.
+func httpRequest(r *http.Request, status int, size int64, latency time.Duration, trustedProxies int) map[string]any {
+	remoteIP := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		remoteIP = host
+	}
+	if forwarded := r.Header.Values("X-Forwarded-For"); trustedProxies > 0 && len(forwarded) > 0 {
+		entries := strings.Split(strings.Join(forwarded, ","), ",")
+		if client := strings.TrimSpace(entries[max(0, len(entries)-trustedProxies)]); client != "" {
+			remoteIP = client
+		}
+	}
+	req := map[string]any{
+		"requestMethod": r.Method,
+		"requestUrl":    r.URL.String(),
+		"status":        status,
+		"responseSize":  fmt.Sprint(size),
+		"userAgent":     r.UserAgent(),
+		"remoteIp":      remoteIP,
+		"protocol":      r.Proto,
+		"latency":       fmt.Sprintf("%.9fs", latency.Seconds()),
+	}
+	if referer := r.Referer(); referer != "" {
+		req["referer"] = referer
+	}
+	return req
+}
+
+// statusRecorder captures the status code and size of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	size        int64
+	wroteHeader bool
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher for streaming handlers.
+func (w *statusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker for websocket handlers.
+func (w *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, fmt.Errorf("response writer does not support hijacking")
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
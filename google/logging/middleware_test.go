@@ -0,0 +1,98 @@
+// middleware_test.go
+
+package structured
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	handler := Middleware("test-project", "test-component")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := FromContext(r.Context())
+		if logger.traceID != "projects/test-project/traces/105445aa7843bc8bf206b120001000" {
+			t.Errorf("Expected a request-scoped logger with the trace, got '%s'", logger.traceID)
+		}
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	}))
+
+	r := httptest.NewRequest("GET", "/tea?cup=1", nil)
+	r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b120001000/1;o=1")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, r)
+
+	if recorder.Code != http.StatusTeapot {
+		t.Errorf("Expected status %d, got %d", http.StatusTeapot, recorder.Code)
+	}
+}
+
+func TestHTTPRequestField(t *testing.T) {
+	r := httptest.NewRequest("POST", "/orders", nil)
+	r.Header.Set("User-Agent", "test-agent")
+	req := httpRequest(r, http.StatusCreated, 42, 1500000, DefaultTrustedProxies)
+
+	if req["requestMethod"] != "POST" || req["status"] != http.StatusCreated || req["responseSize"] != "42" {
+		t.Errorf("Unexpected httpRequest field: %v", req)
+	}
+	if req["latency"] != "0.001500000s" {
+		t.Errorf("Expected latency '0.001500000s', got '%v'", req["latency"])
+	}
+	if req["userAgent"] != "test-agent" || req["remoteIp"] != "192.0.2.1" {
+		t.Errorf("Unexpected client fields: %v", req)
+	}
+}
+
+func TestHTTPRequestForwardedFor(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies int
+		expected       string
+	}{
+		{"Google Front End", 1, "35.191.0.1"},
+		{"Load balancer and Google Front End", 2, "10.0.0.2"},
+		{"More proxies than entries", 5, "203.0.113.7"},
+		{"Header ignored", 0, "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("X-Forwarded-For", " 203.0.113.7 , 10.0.0.2, 35.191.0.1")
+			req := httpRequest(r, http.StatusOK, 0, 0, tt.trustedProxies)
+
+			if req["remoteIp"] != tt.expected {
+				t.Errorf("Expected remote IP '%s', got '%v'", tt.expected, req["remoteIp"])
+			}
+		})
+	}
+}
+
+func TestMiddlewareTrustedProxies(t *testing.T) {
+	var buf bytes.Buffer
+	handler := Middleware("test-project", "test-component", WithWriter(&buf), WithTrustedProxies(2))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Forwarded-For", "198.51.100.9, 203.0.113.7, 35.191.0.1")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	if !strings.Contains(buf.String(), `"remoteIp":"203.0.113.7"`) {
+		t.Errorf("Expected the address seen by the outermost trusted proxy, got %s", buf.String())
+	}
+}
+
+func TestStatusRecorder(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	rw := &statusRecorder{ResponseWriter: recorder, status: http.StatusOK}
+	rw.Write([]byte("hello"))
+	rw.WriteHeader(http.StatusInternalServerError)
+
+	if rw.status != http.StatusOK {
+		t.Errorf("Expected an implicit 200 after Write, got %d", rw.status)
+	}
+	if rw.size != 5 {
+		t.Errorf("Expected size 5, got %d", rw.size)
+	}
+}
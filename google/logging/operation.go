@@ -0,0 +1,75 @@
+// operation.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// keyOperation is the Cloud Logging special field grouping the entries of a
+// long-running operation.
+const keyOperation = "logging.googleapis.com/operation"
+
+// operation is the logging.googleapis.com/operation of a logger.
+type operation struct {
+	id       string
+	producer string
+	last     bool
+	started  atomic.Bool
+	ended    *atomic.Bool
+}
+
+// StartOperation returns a logger that adds logging.googleapis.com/operation
+// with the given ID and producer to every entry, so the steps of a batch job
+// are grouped in Cloud Logging. The first entry is marked first; finish the
+// operation with EndOperation, which writes the entry marked last:
+//
+//	op := logger.StartOperation(jobID, "github.com/acme/exporter")
+//	op.LogInfo(ctx, "Export started")
+//	...
+//	op.EndOperation(ctx, "Export finished", "rows", n)
+func (sl *StructuredLogger) StartOperation(id, producer string) *StructuredLogger {
+	clone := *sl
+	clone.operation = &operation{id: id, producer: producer, ended: &atomic.Bool{}}
+	return &clone
+}
+
+// EndOperation writes an INFO entry marked as the last entry of the operation
+// started with StartOperation. Later entries of the logger carry no operation.
+// Without an operation it logs a plain INFO entry.
+func (sl *StructuredLogger) EndOperation(ctx context.Context, msg string, args ...any) {
+	if op := sl.operation; op != nil && !op.ended.Swap(true) {
+		final := *sl
+		final.operation = &operation{id: op.id, producer: op.producer, last: true, ended: &atomic.Bool{}}
+		final.operation.started.Store(op.started.Load())
+		final.log(ctx, 1, slog.LevelInfo, msg, args...)
+		return
+	}
+	sl.log(ctx, 1, slog.LevelInfo, msg, args...)
+}
+
+// operationAttr returns the operation field for the next entry, marking it
+// first if no entry of the operation was written yet. It is called once the
+// entry is known to be written. It returns false when the logger has no open
+// operation.
+func (sl *StructuredLogger) operationAttr() (slog.Attr, bool) {
+	op := sl.operation
+	if op == nil || (!op.last && op.ended.Load()) {
+		return slog.Attr{}, false
+	}
+	attrs := []any{
+		slog.String("id", op.id),
+		slog.String("producer", op.producer),
+	}
+	if !op.started.Swap(true) {
+		attrs = append(attrs, slog.Bool("first", true))
+	}
+	if op.last {
+		attrs = append(attrs, slog.Bool("last", true))
+	}
+	return slog.Group(keyOperation, attrs...), true
+}
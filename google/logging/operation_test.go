@@ -0,0 +1,93 @@
+// operation_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestOperation(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+	ctx := context.Background()
+
+	op := sl.StartOperation("job-42", "github.com/acme/exporter")
+	op.LogInfo(ctx, "Export started")
+	op.LogInfo(ctx, "Exported batch")
+	op.EndOperation(ctx, "Export finished")
+	op.LogInfo(ctx, "After the operation")
+	sl.LogInfo(ctx, "Parent logger")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 entries, got %d", len(lines))
+	}
+
+	expected := []map[string]interface{}{
+		{"id": "job-42", "producer": "github.com/acme/exporter", "first": true},
+		{"id": "job-42", "producer": "github.com/acme/exporter"},
+		{"id": "job-42", "producer": "github.com/acme/exporter", "last": true},
+		nil,
+		nil,
+	}
+	for i, line := range lines {
+		var loggedEntry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &loggedEntry); err != nil {
+			t.Fatalf("Error unmarshaling log output: %v", err)
+		}
+		got, hasOperation := loggedEntry[keyOperation].(map[string]interface{})
+		if expected[i] == nil {
+			if hasOperation {
+				t.Errorf("Entry %d: expected no operation, got %v", i, got)
+			}
+			continue
+		}
+		if len(got) != len(expected[i]) {
+			t.Errorf("Entry %d: expected operation %v, got %v", i, expected[i], got)
+		}
+		for key, value := range expected[i] {
+			if got[key] != value {
+				t.Errorf("Entry %d: expected %s '%v', got '%v'", i, key, value, got[key])
+			}
+		}
+	}
+}
+
+func TestEndOperationOnly(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+	sl.StartOperation("job-1", "exporter").EndOperation(context.Background(), "Nothing to export")
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	got, _ := loggedEntry[keyOperation].(map[string]interface{})
+	if got["first"] != true || got["last"] != true {
+		t.Errorf("Expected a single entry marked first and last, got %v", got)
+	}
+}
+
+func TestOperationFirstAfterFilter(t *testing.T) {
+	var buf bytes.Buffer
+	dropNoise := func(e Entry) (Entry, bool) {
+		return e, e.Message != "Noise"
+	}
+	sl := NewStructuredLogger("", "test-component", nil, &buf, WithFilter(dropNoise))
+
+	op := sl.StartOperation("job-42", "github.com/acme/exporter")
+	op.LogInfo(context.Background(), "Noise")
+	op.LogInfo(context.Background(), "Export started")
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if got, _ := loggedEntry[keyOperation].(map[string]interface{}); got["first"] != true {
+		t.Errorf("Expected the first written entry to be marked first, got %v", got)
+	}
+}
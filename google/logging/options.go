@@ -0,0 +1,52 @@
+// options.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// WithProjectID sets the project of the trace resource names. When it is not
+// set, the project is resolved with DetectProjectID when a trace is present.
+func WithProjectID(projectID string) Option {
+	return func(sl *StructuredLogger) {
+		sl.projectID = projectID
+	}
+}
+
+// WithComponent sets the component of every entry. When it is not set, it
+// defaults to the detected service.
+func WithComponent(component string) Option {
+	return func(sl *StructuredLogger) {
+		sl.component = component
+	}
+}
+
+// WithRequest takes the trace context, baggage, and debug header of r. A nil
+// request is ignored.
+func WithRequest(r *http.Request) Option {
+	return func(sl *StructuredLogger) {
+		sl.request = r
+	}
+}
+
+// WithWriter sets the destination of the entries. A nil writer is replaced
+// with os.Stderr.
+func WithWriter(w io.Writer) Option {
+	return func(sl *StructuredLogger) {
+		sl.writer = w
+	}
+}
+
+// WithLevel sets the minimum level of the logger instead of the default
+// level. Pass a *slog.LevelVar to change it at runtime. A debug header still
+// lowers it to DEBUG.
+func WithLevel(level slog.Leveler) Option {
+	return func(sl *StructuredLogger) {
+		sl.level = level
+	}
+}
@@ -0,0 +1,71 @@
+// options_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b120001000/1;o=1")
+
+	var buf bytes.Buffer
+	logger := New(
+		WithProjectID("test-project"),
+		WithComponent("test-component"),
+		WithRequest(req),
+		WithWriter(&buf),
+		WithFormat(FormatJSON),
+	)
+	logger.LogInfo(context.Background(), "Test message")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to parse log output: %v", err)
+	}
+	if entry["component"] != "test-component" {
+		t.Errorf("Expected component test-component, got %v", entry["component"])
+	}
+	expectedTraceID := "projects/test-project/traces/105445aa7843bc8bf206b120001000"
+	if entry["logging.googleapis.com/trace"] != expectedTraceID {
+		t.Errorf("Expected trace %s, got %v", expectedTraceID, entry["logging.googleapis.com/trace"])
+	}
+	if logger.request != nil {
+		t.Errorf("Expected the request not to be retained")
+	}
+}
+
+func TestNewWithLevel(t *testing.T) {
+	var buf bytes.Buffer
+	level := &slog.LevelVar{}
+	level.Set(slog.LevelWarn)
+	logger := New(WithWriter(&buf), WithLevel(level), WithFormat(FormatJSON))
+
+	logger.LogInfo(context.Background(), "Dropped")
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output below WARNING, got %s", buf.String())
+	}
+
+	level.Set(slog.LevelInfo)
+	logger.LogInfo(context.Background(), "Written")
+	if !bytes.Contains(buf.Bytes(), []byte("Written")) {
+		t.Errorf("Expected the entry after lowering the level, got %s", buf.String())
+	}
+}
+
+func TestNewStructuredLoggerOptionsOverride(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewStructuredLogger("test-project", "positional", nil, nil, WithComponent("option"), WithWriter(&buf))
+	if logger.component != "option" {
+		t.Errorf("Expected component option, got %s", logger.component)
+	}
+	if logger.writer != &buf {
+		t.Errorf("Expected the writer of the option")
+	}
+}
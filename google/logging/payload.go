@@ -0,0 +1,205 @@
+// payload.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultPayloadMaxBytes is the default size cap of a logged payload.
+const DefaultPayloadMaxBytes = 4096
+
+// defaultPayloadTypes are the media types logged when PayloadOptions.ContentTypes is empty.
+var defaultPayloadTypes = []string{"application/json", "application/x-www-form-urlencoded", "application/xml", "text/"}
+
+// PayloadOptions controls how request and response bodies are logged.
+type PayloadOptions struct {
+	// MaxBytes caps the logged body; longer bodies are truncated with a
+	// marker. Defaults to DefaultPayloadMaxBytes.
+	MaxBytes int
+	// ContentTypes lists the media types, or prefixes ending in "/", whose
+	// bodies are logged. Other bodies are logged with their size only.
+	// Defaults to JSON, form, XML, and text types.
+	ContentTypes []string
+}
+
+func (o PayloadOptions) maxBytes() int {
+	if o.MaxBytes > 0 {
+		return o.MaxBytes
+	}
+	return DefaultPayloadMaxBytes
+}
+
+// allowed reports whether bodies of contentType are logged.
+func (o PayloadOptions) allowed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	types := o.ContentTypes
+	if len(types) == 0 {
+		types = defaultPayloadTypes
+	}
+	for _, t := range types {
+		if mediaType == t || (strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// PayloadAttr returns an attribute describing a payload: its content type,
+// its size, and the body capped to opts.MaxBytes, or "omitted" when the
+// content type is filtered out.
+func PayloadAttr(key string, body []byte, contentType string, opts PayloadOptions) slog.Attr {
+	return payloadAttr(key, body, int64(len(body)), contentType, opts)
+}
+
+// payloadAttr describes a payload of size bytes of which prefix was read.
+// A negative size means unknown.
+func payloadAttr(key string, prefix []byte, size int64, contentType string, opts PayloadOptions) slog.Attr {
+	attrs := []any{slog.String("content_type", contentType)}
+	if size >= 0 {
+		attrs = append(attrs, slog.Int64("size", size))
+	}
+	if !opts.allowed(contentType) {
+		return slog.Group(key, append(attrs, slog.Bool("omitted", true))...)
+	}
+
+	max := opts.maxBytes()
+	if len(prefix) <= max && (size < 0 || size <= int64(max)) {
+		return slog.Group(key, append(attrs, slog.String("body", string(prefix)))...)
+	}
+	marker := "…[truncated]"
+	if size > int64(max) {
+		marker = fmt.Sprintf("…[truncated %d bytes]", size-int64(max))
+	}
+	if len(prefix) > max {
+		prefix = prefix[:max]
+	}
+	return slog.Group(key, append(attrs, slog.String("body", string(prefix)+marker), slog.Bool("truncated", true))...)
+}
+
+// PayloadTransport returns a RoundTripper that logs every outbound request
+// and its response with their payloads at DEBUG, for debugging API
+// integrations. Only the first opts.MaxBytes of each body are kept; the
+// bodies reach the server and the caller unchanged. The entry is written when
+// the caller reaches the end of the response body or closes it, so streamed
+// responses are not held back; event streams are logged without their body.
+// The URL is logged without its query, which often carries API keys. base
+// defaults to http.DefaultTransport.
+func (sl *StructuredLogger) PayloadTransport(base http.RoundTripper, opts PayloadOptions) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &payloadTransport{logger: sl, base: base, opts: opts}
+}
+
+type payloadTransport struct {
+	logger *StructuredLogger
+	base   http.RoundTripper
+	opts   PayloadOptions
+}
+
+func (t *payloadTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !t.logger.logger.Enabled(ctx, slog.LevelDebug) {
+		return t.base.RoundTrip(req)
+	}
+
+	logged := *req.URL
+	logged.RawQuery, logged.ForceQuery, logged.Fragment, logged.User = "", false, "", nil
+	args := []any{"method", req.Method, "url", logged.String()}
+	if req.Body != nil && req.Body != http.NoBody {
+		prefix, body, err := peekBody(req.Body, t.opts.maxBytes())
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(ctx)
+		req.Body = body
+		args = append(args, payloadAttr("request", prefix, req.ContentLength, req.Header.Get("Content-Type"), t.opts))
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	args = append(args, "latency_ms", time.Since(start).Milliseconds())
+	if err != nil {
+		t.logger.LogDebug(ctx, "API call failed", append(args, "error", err)...)
+		return nil, err
+	}
+
+	args = append(args, "status", resp.StatusCode)
+	contentType := resp.Header.Get("Content-Type")
+	if resp.Body == nil || resp.Body == http.NoBody {
+		t.logger.LogDebug(ctx, "API call", args...)
+		return resp, nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "text/event-stream" {
+		t.logger.LogDebug(ctx, "API call", append(args, slog.Group("response",
+			slog.String("content_type", contentType), slog.Bool("omitted", true)))...)
+		return resp, nil
+	}
+	resp.Body = &capturedBody{ReadCloser: resp.Body, max: t.opts.maxBytes(), length: resp.ContentLength,
+		done: func(prefix []byte, size int64) {
+			t.logger.LogDebug(ctx, "API call", append(args, payloadAttr("response", prefix, size, contentType, t.opts))...)
+		}}
+	return resp, nil
+}
+
+// peekBody reads up to max+1 bytes of body and returns them with a body that
+// replays them before the rest.
+func peekBody(body io.ReadCloser, max int) ([]byte, io.ReadCloser, error) {
+	prefix, err := io.ReadAll(io.LimitReader(body, int64(max)+1))
+	if err != nil {
+		body.Close()
+		return nil, nil, fmt.Errorf("error reading body for logging: %w", err)
+	}
+	return prefix, struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), body), body}, nil
+}
+
+// capturedBody keeps the first max+1 bytes the caller reads from a response
+// body and calls done once, at EOF or on Close, with them and the body size.
+type capturedBody struct {
+	io.ReadCloser
+	max    int
+	length int64
+	prefix []byte
+	read   int64
+	once   sync.Once
+	done   func(prefix []byte, size int64)
+}
+
+func (b *capturedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if keep := b.max + 1 - len(b.prefix); keep > 0 {
+		b.prefix = append(b.prefix, p[:min(n, keep)]...)
+	}
+	b.read += int64(n)
+	if err == io.EOF {
+		b.finish(b.read)
+	}
+	return n, err
+}
+
+// Close logs a body closed before EOF with its Content-Length, if known.
+func (b *capturedBody) Close() error {
+	b.finish(b.length)
+	return b.ReadCloser.Close()
+}
+
+func (b *capturedBody) finish(size int64) {
+	b.once.Do(func() { b.done(b.prefix, size) })
+}
@@ -0,0 +1,146 @@
+// payload_test.go
+
+package structured
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPayloadAttr(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+	opts := PayloadOptions{MaxBytes: 10}
+
+	sl.LogInfo(context.Background(), "Payloads",
+		PayloadAttr("short", []byte(`{"a":1}`), "application/json", opts),
+		PayloadAttr("long", []byte(`{"name":"a long value"}`), "application/json; charset=utf-8", opts),
+		PayloadAttr("binary", []byte{0x89, 0x50, 0x4e, 0x47}, "image/png", opts),
+	)
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	short, _ := loggedEntry["short"].(map[string]interface{})
+	long, _ := loggedEntry["long"].(map[string]interface{})
+	binary, _ := loggedEntry["binary"].(map[string]interface{})
+	if short["body"] != `{"a":1}` || short["size"] != float64(7) {
+		t.Errorf("Expected the short body in full, got %v", short)
+	}
+	if long["body"] != `{"name":"a…[truncated 13 bytes]` || long["truncated"] != true {
+		t.Errorf("Expected the long body truncated with a marker, got %v", long)
+	}
+	if _, ok := binary["body"]; ok || binary["omitted"] != true {
+		t.Errorf("Expected the binary body to be omitted, got %v", binary)
+	}
+}
+
+func TestPayloadTransport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(append([]byte(`{"echo":`), append(body, '}')...))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+	sl.SetLogLevel("DEBUG")
+	client := &http.Client{Transport: sl.PayloadTransport(nil, PayloadOptions{MaxBytes: 8})}
+
+	resp, err := client.Post(ts.URL+"/items", "application/json", strings.NewReader(`{"id":"item-1"}`))
+	if err != nil {
+		t.Fatalf("Post returned unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != `{"echo":{"id":"item-1"}}` {
+		t.Errorf("Expected the bodies to pass through unchanged, got %s", body)
+	}
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	request, _ := loggedEntry["request"].(map[string]interface{})
+	response, _ := loggedEntry["response"].(map[string]interface{})
+	if request["body"] != `{"id":"i…[truncated 7 bytes]` {
+		t.Errorf("Expected the truncated request body, got %v", request)
+	}
+	if response["truncated"] != true || loggedEntry["status"] != float64(200) {
+		t.Errorf("Expected the truncated response body and status, got %v", loggedEntry)
+	}
+}
+
+func TestPayloadTransportStreaming(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Write([]byte("{\"n\":1}\n"))
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write([]byte("{\"n\":2}\n"))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+	sl.SetLogLevel("DEBUG")
+	client := &http.Client{Transport: sl.PayloadTransport(nil, PayloadOptions{ContentTypes: []string{"application/x-ndjson"}})}
+
+	resp, err := client.Get(ts.URL + "/stream?key=secret-api-key")
+	if err != nil {
+		t.Fatalf("Get returned unexpected error: %v", err)
+	}
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || line != "{\"n\":1}\n" {
+		t.Fatalf("Expected the first line before the stream ends, got %q, %v", line, err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected the entry to wait for the end of the body, got %s", buf.String())
+	}
+	close(release)
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if response, _ := loggedEntry["response"].(map[string]interface{}); response["body"] != "{\"n\":1}\n{\"n\":2}\n" {
+		t.Errorf("Expected the streamed body, got %v", loggedEntry["response"])
+	}
+	if strings.Contains(buf.String(), "secret-api-key") {
+		t.Errorf("Expected the query to be left out of the URL, got %v", loggedEntry["url"])
+	}
+}
+
+func TestPayloadTransportEventStream(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: hello\n\n"))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+	sl.SetLogLevel("DEBUG")
+	client := &http.Client{Transport: sl.PayloadTransport(nil, PayloadOptions{})}
+
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("Get returned unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if !strings.Contains(buf.String(), `"omitted":true`) {
+		t.Errorf("Expected the event stream to be logged without its body on arrival, got %s", buf.String())
+	}
+}
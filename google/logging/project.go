@@ -0,0 +1,41 @@
+// project.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"context"
+	"os"
+	"sync"
+)
+
+var (
+	metadataProjectID     string
+	metadataProjectIDOnce sync.Once
+)
+
+// DetectProjectID returns the project the process runs in, from the
+// GOOGLE_CLOUD_PROJECT or GCLOUD_PROJECT environment variables, or else from
+// the metadata server. The metadata server is asked once per process, also
+// when it is unreachable, so the lookup is cheap after the first call. The
+// lookup ignores the cancellation of ctx and is bounded by its own timeout,
+// so a canceled request context cannot leave the project undetected. It
+// returns an empty string when the project cannot be determined.
+func DetectProjectID(ctx context.Context) string {
+	for _, key := range []string{"GOOGLE_CLOUD_PROJECT", "GCLOUD_PROJECT"} {
+		if projectID := os.Getenv(key); projectID != "" {
+			return projectID
+		}
+	}
+	return metadataProject(ctx)
+}
+
+// metadataProject returns the project ID from the metadata server, asked
+// once with the values but not the cancellation of ctx.
+func metadataProject(ctx context.Context) string {
+	metadataProjectIDOnce.Do(func() {
+		metadataProjectID, _ = metadataValue(context.WithoutCancel(ctx), "project/project-id")
+	})
+	return metadataProjectID
+}
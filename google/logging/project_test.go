@@ -0,0 +1,86 @@
+// project_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestDetectProjectID(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/project/project-id" || r.Header.Get("Metadata-Flavor") != "Google" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("metadata-project"))
+	}))
+	defer ts.Close()
+
+	originalURL := metadataURL
+	metadataURL = ts.URL + "/"
+	metadataProjectIDOnce = sync.Once{}
+	defer func() {
+		metadataURL = originalURL
+		metadataProjectIDOnce = sync.Once{}
+		metadataProjectID = ""
+	}()
+
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+	t.Setenv("GCLOUD_PROJECT", "")
+	if got := DetectProjectID(context.Background()); got != "metadata-project" {
+		t.Errorf("Expected the project from the metadata server, got '%s'", got)
+	}
+
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "env-project")
+	if got := DetectProjectID(context.Background()); got != "env-project" {
+		t.Errorf("Expected the project from GOOGLE_CLOUD_PROJECT, got '%s'", got)
+	}
+}
+
+func TestNewStructuredLoggerDetectsProjectID(t *testing.T) {
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "env-project")
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b120001000/1;o=1")
+
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", r, &buf)
+	sl.LogInfo(context.Background(), "Request handled")
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if loggedEntry["logging.googleapis.com/trace"] != "projects/env-project/traces/105445aa7843bc8bf206b120001000" {
+		t.Errorf("Expected the trace in the detected project, got '%v'", loggedEntry["logging.googleapis.com/trace"])
+	}
+}
+
+func TestDetectProjectIDCanceledContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("metadata-project"))
+	}))
+	defer ts.Close()
+
+	originalURL := metadataURL
+	metadataURL = ts.URL + "/"
+	metadataProjectIDOnce = sync.Once{}
+	defer func() {
+		metadataURL = originalURL
+		metadataProjectIDOnce = sync.Once{}
+		metadataProjectID = ""
+	}()
+
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+	t.Setenv("GCLOUD_PROJECT", "")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := DetectProjectID(ctx); got != "metadata-project" {
+		t.Errorf("Expected the project despite a canceled context, got '%s'", got)
+	}
+}
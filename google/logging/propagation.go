@@ -0,0 +1,130 @@
+// propagation.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// reTraceID matches a trace ID that can be sent in a traceparent header.
+var reTraceID = regexp.MustCompile(`^[a-f\d]{32}$`)
+
+// InjectTrace sets the X-Cloud-Trace-Context and traceparent headers of h,
+// and tracestate when the logger has one, from the trace context of the
+// logger, so a downstream service logs into the same trace. Headers that are
+// already set are kept; nothing is set when the logger has no trace.
+func (sl *StructuredLogger) InjectTrace(h http.Header) {
+	traceID := sl.traceIDHex()
+	if traceID == "" {
+		return
+	}
+	spanID, ok := parseSpanID(sl.spanID, sl.spanHex)
+	if !ok {
+		return
+	}
+
+	if h.Get("X-Cloud-Trace-Context") == "" {
+		sampled := 0
+		if sl.traceSampled {
+			sampled = 1
+		}
+		h.Set("X-Cloud-Trace-Context", fmt.Sprintf("%s/%d;o=%d", traceID, spanID, sampled))
+	}
+	if h.Get("traceparent") == "" && reTraceID.MatchString(traceID) && spanID != 0 {
+		flags := "00"
+		if sl.traceSampled {
+			flags = "01"
+		}
+		h.Set("traceparent", fmt.Sprintf("00-%s-%016x-%s", traceID, spanID, flags))
+		if sl.traceState != "" && h.Get("tracestate") == "" {
+			h.Set("tracestate", sl.traceState)
+		}
+	}
+}
+
+// traceIDHex returns the trace ID of the logger without the
+// projects/<project>/traces/ prefix.
+func (sl *StructuredLogger) traceIDHex() string {
+	if i := strings.LastIndex(sl.traceID, "/traces/"); i >= 0 {
+		return sl.traceID[i+len("/traces/"):]
+	}
+	return sl.traceID
+}
+
+// SpanContext returns the trace and span ID of the logger in the hexadecimal
+// form of traceparent, and whether the trace is sampled. The IDs are "" when
+// the logger has no valid trace context.
+func (sl *StructuredLogger) SpanContext() (traceID, spanID string, sampled bool) {
+	traceID = sl.traceIDHex()
+	id, ok := parseSpanID(sl.spanID, sl.spanHex)
+	if !reTraceID.MatchString(traceID) || !ok || id == 0 {
+		return "", "", false
+	}
+	return traceID, fmt.Sprintf("%016x", id), sl.traceSampled
+}
+
+// WithSpan returns a derived logger whose entries belong to span spanID of
+// trace traceID, both hexadecimal as in traceparent, for example a span
+// started by a tracer. The trace is named after the project of the logger,
+// or the detected project when it has none.
+func (sl *StructuredLogger) WithSpan(traceID, spanID string, sampled bool) *StructuredLogger {
+	clone := *sl
+	if traceID != sl.traceIDHex() {
+		projectID := sl.projectID
+		if projectID == "" {
+			projectID = DetectProjectID(context.Background())
+		}
+		clone.traceID = fmt.Sprintf("projects/%s/traces/%s", projectID, traceID)
+		clone.traceState = ""
+	}
+	clone.spanID = spanID
+	clone.spanHex = true
+	clone.traceSampled = sampled
+	return &clone
+}
+
+// parseSpanID parses a decimal X-Cloud-Trace-Context or hexadecimal
+// traceparent span ID.
+func parseSpanID(s string, hex bool) (uint64, bool) {
+	base := 10
+	if hex {
+		base = 16
+	}
+	id, err := strconv.ParseUint(s, base, 64)
+	return id, err == nil
+}
+
+// TraceTransport returns a RoundTripper that injects the trace context of
+// the logger in the context of each request, as returned by FromContext,
+// before passing it to base. A nil base means http.DefaultTransport. Use it
+// for the clients of services that call other Cloud Run services:
+//
+//	client := &http.Client{Transport: structured.TraceTransport(nil)}
+func TraceTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &traceTransport{base: base}
+}
+
+type traceTransport struct {
+	base http.RoundTripper
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	logger := FromContext(req.Context())
+	if logger.traceID == "" {
+		return t.base.RoundTrip(req)
+	}
+	// A RoundTripper must not modify the request it was given
+	req = req.Clone(req.Context())
+	logger.InjectTrace(req.Header)
+	return t.base.RoundTrip(req)
+}
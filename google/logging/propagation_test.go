@@ -0,0 +1,111 @@
+// propagation_test.go
+
+package structured
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInjectTraceFromCloudTraceContext(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("X-Cloud-Trace-Context", "4bf92f3577b34da6a3ce929d0e0e4736/1234;o=1")
+	logger := NewStructuredLogger("test-project", "test-component", req, nil)
+
+	h := http.Header{}
+	logger.InjectTrace(h)
+
+	if got := h.Get("X-Cloud-Trace-Context"); got != "4bf92f3577b34da6a3ce929d0e0e4736/1234;o=1" {
+		t.Errorf("Expected X-Cloud-Trace-Context 4bf92f3577b34da6a3ce929d0e0e4736/1234;o=1, got %s", got)
+	}
+	if got := h.Get("traceparent"); got != "00-4bf92f3577b34da6a3ce929d0e0e4736-00000000000004d2-01" {
+		t.Errorf("Expected traceparent 00-4bf92f3577b34da6a3ce929d0e0e4736-00000000000004d2-01, got %s", got)
+	}
+}
+
+func TestInjectTraceFromTraceparent(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	req.Header.Set("tracestate", "vendor=value")
+	logger := NewStructuredLogger("test-project", "test-component", req, nil)
+
+	h := http.Header{}
+	logger.InjectTrace(h)
+
+	if got := h.Get("traceparent"); got != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00" {
+		t.Errorf("Expected the traceparent of the request, got %s", got)
+	}
+	if got := h.Get("X-Cloud-Trace-Context"); got != "4bf92f3577b34da6a3ce929d0e0e4736/67667974448284343;o=0" {
+		t.Errorf("Expected X-Cloud-Trace-Context 4bf92f3577b34da6a3ce929d0e0e4736/67667974448284343;o=0, got %s", got)
+	}
+	if got := h.Get("tracestate"); got != "vendor=value" {
+		t.Errorf("Expected tracestate vendor=value, got %s", got)
+	}
+}
+
+func TestInjectTraceWithoutTrace(t *testing.T) {
+	logger := NewStructuredLogger("test-project", "test-component", nil, nil)
+
+	h := http.Header{}
+	logger.InjectTrace(h)
+	if len(h) != 0 {
+		t.Errorf("Expected no headers without a trace, got %v", h)
+	}
+}
+
+func TestTraceTransport(t *testing.T) {
+	var got http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer ts.Close()
+
+	in := httptest.NewRequest("GET", "http://example.com", nil)
+	in.Header.Set("X-Cloud-Trace-Context", "4bf92f3577b34da6a3ce929d0e0e4736/1234;o=1")
+	ctx := NewContext(in.Context(), NewStructuredLogger("test-project", "test-component", in, nil))
+
+	out, _ := http.NewRequestWithContext(ctx, "GET", ts.URL, nil)
+	client := &http.Client{Transport: TraceTransport(nil)}
+	resp, err := client.Do(out)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if got.Get("traceparent") != "00-4bf92f3577b34da6a3ce929d0e0e4736-00000000000004d2-01" {
+		t.Errorf("Expected the traceparent to be propagated, got %s", got.Get("traceparent"))
+	}
+	if out.Header.Get("traceparent") != "" {
+		t.Errorf("Expected the outgoing request not to be modified")
+	}
+}
+
+func TestSpanContextAndWithSpan(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("X-Cloud-Trace-Context", "4bf92f3577b34da6a3ce929d0e0e4736/1234;o=1")
+	logger := NewStructuredLogger("test-project", "test-component", req, nil)
+
+	traceID, spanID, sampled := logger.SpanContext()
+	if traceID != "4bf92f3577b34da6a3ce929d0e0e4736" || spanID != "00000000000004d2" || !sampled {
+		t.Errorf("Expected 4bf92f3577b34da6a3ce929d0e0e4736, 00000000000004d2, true, got %s, %s, %v", traceID, spanID, sampled)
+	}
+
+	child := logger.WithSpan(traceID, "00f067aa0ba902b7", true)
+	if child.spanID != "00f067aa0ba902b7" || child.traceID != logger.traceID {
+		t.Errorf("Expected span 00f067aa0ba902b7 in trace %s, got %s in %s", logger.traceID, child.spanID, child.traceID)
+	}
+	h := http.Header{}
+	child.InjectTrace(h)
+	if got := h.Get("traceparent"); got != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Errorf("Expected the traceparent of the span, got %s", got)
+	}
+
+	root := NewStructuredLogger("test-project", "test-component", nil, nil).WithSpan("0af7651916cd43dd8448eb211c80319c", "b7ad6b7169203331", false)
+	if root.traceID != "projects/test-project/traces/0af7651916cd43dd8448eb211c80319c" {
+		t.Errorf("Expected the trace of the span, got %s", root.traceID)
+	}
+	if traceID, _, _ := NewStructuredLogger("test-project", "test-component", nil, nil).SpanContext(); traceID != "" {
+		t.Errorf("Expected no span context without a trace, got %s", traceID)
+	}
+}
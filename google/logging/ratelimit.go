@@ -0,0 +1,142 @@
+// ratelimit.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// DefaultLogLimitWindow is the window of the limiter used by loggers without
+// WithLogLimiter.
+const DefaultLogLimitWindow = time.Minute
+
+// maxLimitedKeys bounds the keys a LogLimiter tracks; expired keys are pruned beyond it.
+const maxLimitedKeys = 10000
+
+// LogLimiter suppresses repeated entries with the same key within a window,
+// for example the same failure logged on every iteration of a retry loop.
+// The first entry of a window is written; when the window closes, a summary
+// entry reports how many duplicates were suppressed. It is safe for
+// concurrent use and can be shared between loggers.
+type LogLimiter struct {
+	window time.Duration
+
+	mu   sync.Mutex
+	keys map[string]*limitedKey
+}
+
+// limitedKey is the current window of one key. pc is the call site of the
+// entry that opened the window, reported as the source of the summary.
+type limitedKey struct {
+	until      time.Time
+	suppressed int
+	timer      *time.Timer
+	pc         uintptr
+}
+
+var defaultLogLimiter = NewLogLimiter(DefaultLogLimitWindow)
+
+// NewLogLimiter returns a LogLimiter with the given window.
+func NewLogLimiter(window time.Duration) *LogLimiter {
+	if window <= 0 {
+		window = DefaultLogLimitWindow
+	}
+	return &LogLimiter{window: window, keys: make(map[string]*limitedKey)}
+}
+
+// WithLogLimiter sets the limiter of the Log*RateLimited methods. Without it,
+// loggers share a limiter with DefaultLogLimitWindow.
+func WithLogLimiter(l *LogLimiter) Option {
+	return func(sl *StructuredLogger) {
+		sl.limiter = l
+	}
+}
+
+// LogRateLimited logs a message at level unless an entry with the same key
+// was logged within the limiter window.
+func (sl *StructuredLogger) LogRateLimited(ctx context.Context, level slog.Level, key, msg string, args ...any) {
+	if sl.allowRateLimited(ctx, level, key, msg) {
+		sl.log(ctx, 1, level, msg, args...)
+	}
+}
+
+// LogInfoRateLimited logs an info message rate-limited by key.
+func (sl *StructuredLogger) LogInfoRateLimited(ctx context.Context, key, msg string, args ...any) {
+	if sl.allowRateLimited(ctx, slog.LevelInfo, key, msg) {
+		sl.log(ctx, 1, slog.LevelInfo, msg, args...)
+	}
+}
+
+// LogWarningRateLimited logs a warning message rate-limited by key.
+func (sl *StructuredLogger) LogWarningRateLimited(ctx context.Context, key, msg string, args ...any) {
+	if sl.allowRateLimited(ctx, slog.LevelWarn, key, msg) {
+		sl.log(ctx, 1, slog.LevelWarn, msg, args...)
+	}
+}
+
+// LogErrorRateLimited logs an error message rate-limited by key.
+func (sl *StructuredLogger) LogErrorRateLimited(ctx context.Context, key, msg string, args ...any) {
+	if sl.allowRateLimited(ctx, slog.LevelError, key, msg) {
+		sl.log(ctx, 1, slog.LevelError, msg, args...)
+	}
+}
+
+// allowRateLimited reports whether an entry with key is written. On the first
+// suppressed duplicate of a window it schedules the summary entry.
+func (sl *StructuredLogger) allowRateLimited(ctx context.Context, level slog.Level, key, msg string) bool {
+	l := sl.limiter
+	if l == nil {
+		l = defaultLogLimiter
+	}
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	k, ok := l.keys[key]
+	if !ok || !now.Before(k.until) {
+		if len(l.keys) >= maxLimitedKeys {
+			l.prune(now)
+		}
+		k = &limitedKey{until: now.Add(l.window)}
+		if level >= slog.LevelError {
+			// Skip runtime.Callers, allowRateLimited, and the Log*RateLimited method.
+			var pcs [1]uintptr
+			runtime.Callers(3+sl.callerSkip, pcs[:])
+			k.pc = pcs[0]
+		}
+		l.keys[key] = k
+		return true
+	}
+
+	k.suppressed++
+	if k.timer == nil {
+		ctx = context.WithoutCancel(ctx)
+		k.timer = time.AfterFunc(k.until.Sub(now), func() {
+			l.mu.Lock()
+			n := k.suppressed
+			if l.keys[key] == k {
+				delete(l.keys, key)
+			}
+			l.mu.Unlock()
+			sl.logPC(ctx, k.pc, level, fmt.Sprintf("Suppressed %d duplicates of %q", n, msg), "rate_limit_key", key, "suppressed", n)
+		})
+	}
+	return false
+}
+
+// prune removes the expired keys without a pending summary. The caller must hold l.mu.
+func (l *LogLimiter) prune(now time.Time) {
+	for key, k := range l.keys {
+		if k.timer == nil && !now.Before(k.until) {
+			delete(l.keys, key)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// ratelimit_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for the summary written from a timer.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestLogRateLimited(t *testing.T) {
+	var buf syncBuffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf, WithLogLimiter(NewLogLimiter(50*time.Millisecond)))
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		sl.LogWarningRateLimited(ctx, "fetch-orders", "Retrying fetch", "attempt", i)
+	}
+	sl.LogWarningRateLimited(ctx, "fetch-users", "Retrying fetch")
+
+	if n := strings.Count(buf.String(), "\n"); n != 2 {
+		t.Fatalf("Expected 2 entries within the window, got %d: %s", n, buf.String())
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(buf.String(), "Suppressed") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a single summary entry, got %d entries: %s", len(lines), buf.String())
+	}
+
+	var summary map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[2]), &summary); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if summary["msg"] != `Suppressed 4 duplicates of "Retrying fetch"` || summary["suppressed"] != float64(4) || summary["level"] != "WARN" {
+		t.Errorf("Unexpected summary entry: %v", summary)
+	}
+
+	// After the window, the key is logged again.
+	sl.LogWarningRateLimited(ctx, "fetch-orders", "Retrying fetch")
+	if n := strings.Count(buf.String(), "\n"); n != 4 {
+		t.Errorf("Expected the key to be logged after the window, got %d entries", n)
+	}
+}
+
+func TestLogRateLimitedSummarySource(t *testing.T) {
+	var buf syncBuffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf, WithLogLimiter(NewLogLimiter(20*time.Millisecond)))
+	for i := 0; i < 2; i++ {
+		sl.LogErrorRateLimited(context.Background(), "charge", "Charge failed")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(buf.String(), "Suppressed") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected the entry and its summary, got %d entries: %s", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		var loggedEntry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &loggedEntry); err != nil {
+			t.Fatalf("Error unmarshaling log output: %v", err)
+		}
+		source, _ := loggedEntry["logging.googleapis.com/sourceLocation"].(map[string]interface{})
+		if source["function"] != "github.com/duizendstra/go/google/logging.TestLogRateLimitedSummarySource" {
+			t.Errorf("Expected the source location of the rate-limited call, got %v", source["function"])
+		}
+	}
+}
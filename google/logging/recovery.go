@@ -0,0 +1,63 @@
+// recovery.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// RecoveryMiddleware recovers panics in next, logs them at CRITICAL through
+// FromContext with the stack trace in the format Cloud Error Reporting picks
+// up, and answers 500 with a generic body that reveals nothing about the
+// panic. Install it inside Middleware, so the entry carries the trace of the
+// request, is reported by WithErrorReporting, and is followed by a
+// "Request finished" entry with status 500:
+//
+//	handler := structured.Middleware(projectID, "orders-api")(structured.RecoveryMiddleware(mux))
+//
+// Panics with http.ErrAbortHandler are passed on, as net/http expects.
+func RecoveryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+			logPanic(r.Context(), LevelCritical, "Panic in HTTP handler", p,
+				"method", r.Method, "path", r.URL.Path)
+			if !rw.wroteHeader {
+				http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// logPanic logs the recovered value p through FromContext(ctx) as an error
+// event. It must be called from the function deferred to recover, so the
+// stack trace and source location start at the function that panicked;
+// WithCallerSkip does not apply here.
+func logPanic(ctx context.Context, level slog.Level, msg string, p any, args ...any) {
+	err, ok := p.(error)
+	if !ok {
+		err = fmt.Errorf("%v", p)
+	}
+	sl := FromContext(ctx)
+	// Skip the deferred function and runtime.gopanic.
+	eventArgs := []any{
+		"@type", ReportedErrorEventType,
+		"error", err.Error(),
+		"error_chain", errorChain(err),
+		"stack_trace", stackTrace(msg, err, 3),
+	}
+	sl.log(ctx, 3-sl.callerSkip, level, msg, append(eventArgs, args...)...)
+}
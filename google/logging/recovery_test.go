@@ -0,0 +1,77 @@
+// recovery_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewStructuredLogger("", "test-component", nil, &buf)
+	var reported []Entry
+	logger.RegisterHook(LevelCritical, func(e Entry) { reported = append(reported, e) })
+
+	handler := RecoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("database password is hunter2")
+	}))
+	r := httptest.NewRequest("GET", "/orders", nil)
+	r = r.WithContext(NewContext(context.Background(), logger))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, r)
+
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, recorder.Code)
+	}
+	if strings.Contains(recorder.Body.String(), "hunter2") {
+		t.Errorf("Expected a safe body, got %q", recorder.Body.String())
+	}
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if loggedEntry["level"] != "ERROR+1" || loggedEntry["path"] != "/orders" {
+		t.Errorf("Expected a CRITICAL entry with the path, got %v", loggedEntry)
+	}
+	if stack, _ := loggedEntry["stack_trace"].(string); !strings.Contains(stack, "TestRecoveryMiddleware") {
+		t.Errorf("Expected the stack trace of the handler, got %q", stack)
+	}
+	if len(reported) != 1 {
+		t.Errorf("Expected the panic to reach the CRITICAL hooks once, got %d", len(reported))
+	}
+}
+
+func TestRecoveryMiddlewareAfterWrite(t *testing.T) {
+	logger := NewStructuredLogger("", "test-component", nil, &bytes.Buffer{})
+	handler := RecoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("late")
+	}))
+	r := httptest.NewRequest("GET", "/orders", nil)
+	r = r.WithContext(NewContext(context.Background(), logger))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, r)
+
+	if recorder.Code != http.StatusAccepted {
+		t.Errorf("Expected the written status %d to be kept, got %d", http.StatusAccepted, recorder.Code)
+	}
+}
+
+func TestRecoveryMiddlewareAbortHandler(t *testing.T) {
+	handler := RecoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("Expected http.ErrAbortHandler to be passed on, got %v", p)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
@@ -0,0 +1,172 @@
+// resource.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Platforms reported by DetectResource.
+const (
+	PlatformCloudRun       = "cloud_run"
+	PlatformCloudRunJob    = "cloud_run_job"
+	PlatformCloudFunctions = "cloud_functions"
+	PlatformAppEngine      = "app_engine"
+	PlatformGKE            = "gke"
+)
+
+// metadataURL is the base URL of the GCE metadata server.
+var metadataURL = "http://metadata.google.internal/computeMetadata/v1/"
+
+// Resource describes the environment the process runs in.
+type Resource struct {
+	// Platform is one of the Platform constants, or empty outside Google Cloud.
+	Platform string
+	// Service is the service, function, or job name.
+	Service string
+	// Revision is the revision, version, or execution.
+	Revision string
+	// Cluster and Namespace are set on GKE.
+	Cluster   string
+	Namespace string
+}
+
+// Labels returns the non-empty fields of r as entry labels.
+func (r Resource) Labels() map[string]string {
+	labels := make(map[string]string)
+	for key, value := range map[string]string{
+		"service":   r.Service,
+		"revision":  r.Revision,
+		"cluster":   r.Cluster,
+		"namespace": r.Namespace,
+	} {
+		if value != "" {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// DetectResource detects the platform from the environment variables set by
+// Cloud Run (K_SERVICE, CLOUD_RUN_JOB), Cloud Functions (FUNCTION_TARGET),
+// and App Engine (GAE_SERVICE), and on GKE (KUBERNETES_SERVICE_HOST) reads
+// the cluster name from the metadata server. OTEL_SERVICE_NAME overrides the
+// detected service. The environment is detected once per process and the
+// result reused, so creating loggers does not repeat the file and metadata
+// lookups.
+func DetectResource() Resource {
+	detectedOnce.Do(func() {
+		detectedResource = detectResource(os.Getenv, gkeCluster)
+	})
+	return detectedResource
+}
+
+var (
+	detectedResource Resource
+	detectedOnce     sync.Once
+)
+
+func detectResource(getenv func(string) string, cluster func() string) Resource {
+	var r Resource
+	switch {
+	case getenv("FUNCTION_TARGET") != "":
+		r = Resource{Platform: PlatformCloudFunctions, Service: getenv("K_SERVICE"), Revision: getenv("K_REVISION")}
+		if r.Service == "" {
+			r.Service, r.Revision = getenv("FUNCTION_NAME"), getenv("X_GOOGLE_FUNCTION_VERSION")
+		}
+	case getenv("K_SERVICE") != "":
+		r = Resource{Platform: PlatformCloudRun, Service: getenv("K_SERVICE"), Revision: getenv("K_REVISION")}
+	case getenv("CLOUD_RUN_JOB") != "":
+		r = Resource{Platform: PlatformCloudRunJob, Service: getenv("CLOUD_RUN_JOB"), Revision: getenv("CLOUD_RUN_EXECUTION")}
+	case getenv("GAE_SERVICE") != "":
+		r = Resource{Platform: PlatformAppEngine, Service: getenv("GAE_SERVICE"), Revision: getenv("GAE_VERSION")}
+	case getenv("KUBERNETES_SERVICE_HOST") != "":
+		r = Resource{Platform: PlatformGKE, Cluster: cluster(), Namespace: getenv("POD_NAMESPACE")}
+		if r.Namespace == "" {
+			if data, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace"); err == nil {
+				r.Namespace = strings.TrimSpace(string(data))
+			}
+		}
+	}
+	if service := getenv("OTEL_SERVICE_NAME"); service != "" {
+		r.Service = service
+	}
+	return r
+}
+
+var (
+	gkeClusterName string
+	gkeClusterOnce sync.Once
+)
+
+// gkeCluster returns the cluster name from the metadata server, looked up once.
+func gkeCluster() string {
+	gkeClusterOnce.Do(func() {
+		gkeClusterName, _ = metadataValue(context.Background(), "instance/attributes/cluster-name")
+	})
+	return gkeClusterName
+}
+
+// metadataValue reads a value from the metadata server, giving up after a
+// second so that code outside Google Cloud is not held up.
+func metadataValue(ctx context.Context, path string) (string, bool) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", metadataURL+path, nil)
+	if err != nil {
+		return "", false
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", false
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(data)), true
+}
+
+// WithResource sets the resource used for the default component and labels
+// instead of detecting it. Pass a zero Resource to disable the defaults.
+func WithResource(r Resource) Option {
+	return func(sl *StructuredLogger) {
+		sl.resource = &r
+	}
+}
+
+// applyResource defaults the component to the resource's service and adds
+// its labels, without overriding labels set with WithLabels.
+func (sl *StructuredLogger) applyResource() {
+	r := sl.resource
+	if r == nil {
+		detected := DetectResource()
+		r = &detected
+		sl.resource = r
+	}
+	if sl.component == "" {
+		sl.component = r.Service
+	}
+	for key, value := range r.Labels() {
+		if _, ok := sl.labels[key]; ok {
+			continue
+		}
+		if sl.labels == nil {
+			sl.labels = make(map[string]string)
+		}
+		sl.labels[key] = value
+	}
+}
@@ -0,0 +1,61 @@
+// resource_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestDetectResource(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want Resource
+	}{
+		{"none", map[string]string{}, Resource{}},
+		{"cloud run", map[string]string{"K_SERVICE": "orders", "K_REVISION": "orders-00042"}, Resource{Platform: PlatformCloudRun, Service: "orders", Revision: "orders-00042"}},
+		{"cloud run job", map[string]string{"CLOUD_RUN_JOB": "import", "CLOUD_RUN_EXECUTION": "import-abc"}, Resource{Platform: PlatformCloudRunJob, Service: "import", Revision: "import-abc"}},
+		{"cloud functions", map[string]string{"FUNCTION_TARGET": "Handle", "K_SERVICE": "handle-fn", "K_REVISION": "handle-fn-00003"}, Resource{Platform: PlatformCloudFunctions, Service: "handle-fn", Revision: "handle-fn-00003"}},
+		{"app engine", map[string]string{"GAE_SERVICE": "default", "GAE_VERSION": "20241014t120000"}, Resource{Platform: PlatformAppEngine, Service: "default", Revision: "20241014t120000"}},
+		{"gke", map[string]string{"KUBERNETES_SERVICE_HOST": "10.0.0.1", "POD_NAMESPACE": "shop", "OTEL_SERVICE_NAME": "cart"}, Resource{Platform: PlatformGKE, Service: "cart", Cluster: "prod-cluster", Namespace: "shop"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectResource(func(key string) string { return tt.env[key] }, func() string { return "prod-cluster" })
+			if got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDetectResourceCached(t *testing.T) {
+	first := DetectResource()
+	t.Setenv("K_SERVICE", "changed-after-detection")
+	if got := DetectResource(); got != first {
+		t.Errorf("Expected the detected resource to be reused, got %+v after %+v", got, first)
+	}
+}
+
+func TestResourceDefaults(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "", nil, &buf,
+		WithResource(Resource{Platform: PlatformCloudRun, Service: "orders", Revision: "orders-00042"}),
+		WithLabels(map[string]string{"revision": "canary"}))
+	sl.LogInfo(context.Background(), "Order shipped")
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if loggedEntry["component"] != "orders" {
+		t.Errorf("Expected the service as default component, got '%v'", loggedEntry["component"])
+	}
+	labels, _ := loggedEntry[keyLabels].(map[string]interface{})
+	if labels["service"] != "orders" || labels["revision"] != "canary" {
+		t.Errorf("Expected resource labels without overriding WithLabels, got %v", labels)
+	}
+}
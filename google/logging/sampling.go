@@ -0,0 +1,67 @@
+// sampling.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+// Sampler keeps 1 in N entries of high-volume levels and counts the entries
+// it drops. ERROR and higher entries are never sampled. Create one Sampler per
+// service and share it between request-scoped loggers, so the rate applies to
+// the service as a whole. It is safe for concurrent use.
+type Sampler struct {
+	levels map[slog.Level]*sampledLevel
+}
+
+// sampledLevel holds the rate and counters of one level.
+type sampledLevel struct {
+	n       uint64
+	seen    atomic.Uint64
+	dropped atomic.Uint64
+}
+
+// NewSampler returns a Sampler keeping 1 in rates[level] entries per level,
+// for example map[slog.Level]int{slog.LevelDebug: 100, slog.LevelInfo: 10}.
+// Rates of 1 or less, and rates for ERROR and higher, are ignored.
+func NewSampler(rates map[slog.Level]int) *Sampler {
+	s := &Sampler{levels: make(map[slog.Level]*sampledLevel, len(rates))}
+	for level, n := range rates {
+		if n > 1 && level < slog.LevelError {
+			s.levels[level] = &sampledLevel{n: uint64(n)}
+		}
+	}
+	return s
+}
+
+// WithSampler samples the entries of the logger with s.
+func WithSampler(s *Sampler) Option {
+	return func(sl *StructuredLogger) {
+		sl.sampler = s
+	}
+}
+
+// keep reports whether the next entry at level is written. The first entry
+// of every N is kept, so a rare event is not lost entirely.
+func (s *Sampler) keep(level slog.Level) bool {
+	l, ok := s.levels[level]
+	if !ok {
+		return true
+	}
+	if (l.seen.Add(1)-1)%l.n == 0 {
+		return true
+	}
+	l.dropped.Add(1)
+	return false
+}
+
+// Dropped returns the number of entries at level dropped by sampling.
+func (s *Sampler) Dropped(level slog.Level) uint64 {
+	if l, ok := s.levels[level]; ok {
+		return l.dropped.Load()
+	}
+	return 0
+}
@@ -0,0 +1,39 @@
+// sampling_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSampler(t *testing.T) {
+	var buf bytes.Buffer
+	sampler := NewSampler(map[slog.Level]int{slog.LevelInfo: 10, slog.LevelError: 10})
+	ctx := context.Background()
+
+	// Request-scoped loggers share the sampler.
+	for i := 0; i < 5; i++ {
+		sl := NewStructuredLogger("", "test-component", nil, &buf, WithSampler(sampler))
+		for j := 0; j < 10; j++ {
+			sl.LogInfo(ctx, "Cache hit")
+		}
+		sl.LogError(ctx, "Cache unavailable")
+	}
+
+	if n := strings.Count(buf.String(), "Cache hit"); n != 5 {
+		t.Errorf("Expected 5 sampled INFO entries, got %d", n)
+	}
+	if n := strings.Count(buf.String(), "Cache unavailable"); n != 5 {
+		t.Errorf("Expected all 5 ERROR entries, got %d", n)
+	}
+	if dropped := sampler.Dropped(slog.LevelInfo); dropped != 45 {
+		t.Errorf("Expected 45 dropped INFO entries, got %d", dropped)
+	}
+	if dropped := sampler.Dropped(slog.LevelError); dropped != 0 {
+		t.Errorf("Expected no dropped ERROR entries, got %d", dropped)
+	}
+}
@@ -0,0 +1,121 @@
+// startup.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// redactedValue replaces secret configuration values in the startup entry.
+const redactedValue = "[REDACTED]"
+
+// reSecretKey matches configuration keys whose values must not be logged.
+var reSecretKey = regexp.MustCompile(`(?i)(passw(or)?d|secret|token|api_?key|private_?key|credential|auth)`)
+
+// cgroupMemoryMax is where cgroup v2 (as used on Cloud Run) exposes the memory limit.
+const cgroupMemoryMax = "/sys/fs/cgroup/memory.max"
+
+// LogStartup emits a single NOTICE entry describing what is running: cfg
+// (encoded as JSON, with values under secret-looking keys such as "password"
+// or "apiKey" redacted), the build info of the binary, the Go version, the
+// resource limits, and the Cloud Run service and revision when set. Call it
+// once at boot.
+func (sl *StructuredLogger) LogStartup(ctx context.Context, cfg any) {
+	args := []any{
+		"go", map[string]any{
+			"version": runtime.Version(),
+			"os":      runtime.GOOS,
+			"arch":    runtime.GOARCH,
+		},
+		"build", buildInfo(),
+		"resources", resourceLimits(),
+	}
+	if cfg != nil {
+		args = append(args, "config", redactConfig(cfg))
+	}
+	if service := os.Getenv("K_SERVICE"); service != "" {
+		args = append(args, "cloud_run", map[string]any{
+			"service":       service,
+			"revision":      os.Getenv("K_REVISION"),
+			"configuration": os.Getenv("K_CONFIGURATION"),
+		})
+	}
+	sl.log(ctx, 1, LevelNotice, "Service starting", args...)
+}
+
+// redactConfig returns cfg as generic JSON values with secrets redacted.
+func redactConfig(cfg any) any {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return "unencodable config: " + err.Error()
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return "unencodable config: " + err.Error()
+	}
+	return redact(v)
+}
+
+func redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if reSecretKey.MatchString(key) {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = redact(value)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = redact(value)
+		}
+	}
+	return v
+}
+
+// buildInfo returns the module and VCS details embedded in the binary.
+func buildInfo() map[string]any {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return map[string]any{}
+	}
+	out := map[string]any{
+		"path":    info.Main.Path,
+		"version": info.Main.Version,
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			out["revision"] = s.Value
+		case "vcs.time":
+			out["revision_time"] = s.Value
+		case "vcs.modified":
+			out["modified"] = s.Value == "true"
+		}
+	}
+	return out
+}
+
+// resourceLimits returns the CPU and memory limits visible to the process.
+func resourceLimits() map[string]any {
+	out := map[string]any{
+		"num_cpu":    runtime.NumCPU(),
+		"gomaxprocs": runtime.GOMAXPROCS(0),
+	}
+	if limit := debug.SetMemoryLimit(-1); limit != int64(^uint64(0)>>1) {
+		out["gomemlimit_bytes"] = limit
+	}
+	if data, err := os.ReadFile(cgroupMemoryMax); err == nil {
+		out["memory_limit"] = strings.TrimSpace(string(data))
+	}
+	return out
+}
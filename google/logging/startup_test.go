@@ -0,0 +1,64 @@
+// startup_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"runtime"
+	"testing"
+)
+
+func TestLogStartup(t *testing.T) {
+	t.Setenv("K_SERVICE", "orders")
+	t.Setenv("K_REVISION", "orders-00042")
+
+	cfg := struct {
+		Port       int    `json:"port"`
+		DBPassword string `json:"dbPassword"`
+		APIKey     string `json:"apiKey"`
+		Upstream   struct {
+			URL   string `json:"url"`
+			Token string `json:"token"`
+		} `json:"upstream"`
+	}{Port: 8080, DBPassword: "hunter2", APIKey: "abc"}
+	cfg.Upstream.URL = "https://example.com"
+	cfg.Upstream.Token = "secret-token"
+
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+	sl.LogStartup(context.Background(), cfg)
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if loggedEntry["level"] != "INFO+1" {
+		t.Errorf("Expected NOTICE level 'INFO+1', got '%v'", loggedEntry["level"])
+	}
+	if bytes.Contains(buf.Bytes(), []byte("hunter2")) || bytes.Contains(buf.Bytes(), []byte("secret-token")) || bytes.Contains(buf.Bytes(), []byte(`"abc"`)) {
+		t.Errorf("Expected secrets to be redacted, got %s", buf.String())
+	}
+
+	config := loggedEntry["config"].(map[string]interface{})
+	if config["port"] != float64(8080) {
+		t.Errorf("Expected port 8080, got '%v'", config["port"])
+	}
+	if config["dbPassword"] != redactedValue {
+		t.Errorf("Expected dbPassword to be redacted, got '%v'", config["dbPassword"])
+	}
+	if upstream := config["upstream"].(map[string]interface{}); upstream["url"] != "https://example.com" || upstream["token"] != redactedValue {
+		t.Errorf("Expected nested redaction of token only, got '%v'", upstream)
+	}
+
+	if goInfo := loggedEntry["go"].(map[string]interface{}); goInfo["version"] != runtime.Version() {
+		t.Errorf("Expected Go version '%s', got '%v'", runtime.Version(), goInfo["version"])
+	}
+	if _, ok := loggedEntry["resources"].(map[string]interface{})["num_cpu"]; !ok {
+		t.Errorf("Expected num_cpu in resources")
+	}
+	if cloudRun := loggedEntry["cloud_run"].(map[string]interface{}); cloudRun["revision"] != "orders-00042" {
+		t.Errorf("Expected the Cloud Run revision, got '%v'", cloudRun["revision"])
+	}
+}
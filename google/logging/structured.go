@@ -14,6 +14,9 @@ import (
     "regexp"
     "runtime"
     "strings"
+    "time"
+
+    "go.opentelemetry.io/otel/baggage"
 )
 
 type StructuredLogger struct {
@@ -22,53 +25,169 @@ type StructuredLogger struct {
     traceID      string
     spanID       string
     traceSampled bool
+    spanHex      bool
     writer       io.Writer
+    format       Format
+    additional   []slog.Handler
+    hooks        *hookRegistry
+
+    deadlineThreshold time.Duration
+    debugSecret       string
+    legacyUntil       time.Time
+    preferTraceparent bool
+    traceState        string
+    labels            map[string]string
+    operation         *operation
+    attrs             []slog.Attr
+    filters           []Filter
+    sampler           *Sampler
+    limiter           *LogLimiter
+    baggageKeys       []string
+    requestBaggage    baggage.Baggage
+    resource          *Resource
+    trustedProxies    *int
+    callerSkip        int
+    groups            []attrGroup
+    tailSampling      *TailSampling
+    tail              *tailBuffer
+    reporters         []*ErrorReporter
+    clock             Clock
+    projectID         string
+    request           *http.Request
+    level             slog.Leveler
 }
 
-// NewStructuredLogger creates a new StructuredLogger instance with optional trace information.
-func NewStructuredLogger(projectID, component string, r *http.Request, writer io.Writer) *StructuredLogger {
-    if writer == nil {
-        writer = os.Stderr
+// New creates a new StructuredLogger configured by opts. Without options it
+// writes to os.Stderr at the default level, and WithRequest adds the trace
+// context of a request.
+func New(opts ...Option) *StructuredLogger {
+    sl := &StructuredLogger{
+        format: defaultFormat(),
+        hooks:  &hookRegistry{},
+        clock:  defaultClock,
     }
 
-    handler := slog.NewJSONHandler(writer, &slog.HandlerOptions{
-        AddSource: false, // We'll add source manually for error levels
-    })
-
-    logger := slog.New(handler)
+    for _, opt := range opts {
+        opt(sl)
+    }
+    if sl.writer == nil {
+        sl.writer = os.Stderr
+    }
+    sl.applyResource()
+    sl.resolveFormat()
 
-    sl := &StructuredLogger{
-        logger:    logger,
-        component: component,
-        writer:    writer,
+    r := sl.request
+    sl.request = nil
+    var level slog.Leveler = defaultLevel()
+    if sl.level != nil {
+        level = sl.level
     }
+    if debugRequested(r, sl.debugSecret) {
+        level = slog.LevelDebug
+    } else if r != nil && sl.tailSampling != nil {
+        sl.tail = newTailBuffer(*sl.tailSampling)
+    }
+    sl.logger = slog.New(sl.newHandler(level))
 
     if r != nil {
-        traceID, spanID, traceSampled := extractTraceContext(projectID, r)
-        sl.traceID = traceID
-        sl.spanID = spanID
-        sl.traceSampled = traceSampled
+        tc := extractTraceContext(sl.projectID, r, sl.preferTraceparent)
+        sl.traceID = tc.traceID
+        sl.spanID = tc.spanID
+        sl.traceSampled = tc.sampled
+        sl.traceState = tc.state
+        sl.spanHex = tc.w3c
+        if len(sl.baggageKeys) > 0 {
+            sl.requestBaggage, _ = baggage.Parse(r.Header.Get("baggage"))
+        }
     }
 
     return sl
 }
 
+// NewStructuredLogger creates a new StructuredLogger instance with optional trace information.
+// An empty projectID is resolved with DetectProjectID when a trace is present.
+// It is New with WithProjectID, WithComponent, WithRequest, and WithWriter
+// applied before opts.
+func NewStructuredLogger(projectID, component string, r *http.Request, writer io.Writer, opts ...Option) *StructuredLogger {
+    return New(append([]Option{
+        WithProjectID(projectID),
+        WithComponent(component),
+        WithRequest(r),
+        WithWriter(writer),
+    }, opts...)...)
+}
+
+// traceContext is the trace context of a request. The span ID is decimal,
+// as in X-Cloud-Trace-Context, unless w3c is set.
+type traceContext struct {
+    traceID string
+    spanID  string
+    sampled bool
+    state   string
+    w3c     bool
+}
+
 // extractTraceContext extracts trace information from the request headers.
-func extractTraceContext(projectID string, r *http.Request) (string, string, bool) {
-    get := r.Header.Get("X-Cloud-Trace-Context")
-    traceID, spanID, traceSampled := deconstructXCloudTraceContext(get)
-    if traceID != "" {
-        traceID = fmt.Sprintf("projects/%s/traces/%s", projectID, traceID)
+// X-Cloud-Trace-Context takes precedence over traceparent unless
+// preferTraceparent is set; the tracestate is only kept with a traceparent.
+func extractTraceContext(projectID string, r *http.Request, preferTraceparent bool) traceContext {
+    cloudTraceID, cloudSpanID, cloudSampled := deconstructXCloudTraceContext(r.Header.Get("X-Cloud-Trace-Context"))
+    w3cTraceID, w3cSpanID, w3cSampled := deconstructTraceparent(r.Header.Get("traceparent"))
+
+    var tc traceContext
+    switch {
+    case w3cTraceID != "" && (preferTraceparent || cloudTraceID == ""):
+        tc = traceContext{traceID: w3cTraceID, spanID: w3cSpanID, sampled: w3cSampled, w3c: true}
+        tc.state = deconstructTracestate(r.Header.Values("tracestate"))
+    default:
+        tc = traceContext{traceID: cloudTraceID, spanID: cloudSpanID, sampled: cloudSampled}
     }
-    return traceID, spanID, traceSampled
+
+    if tc.traceID != "" {
+        if projectID == "" {
+            projectID = DetectProjectID(r.Context())
+        }
+        tc.traceID = fmt.Sprintf("projects/%s/traces/%s", projectID, tc.traceID)
+    }
+    return tc
 }
 
 // Log logs a message with the specified level and message.
 func (sl *StructuredLogger) Log(ctx context.Context, level slog.Level, msg string, args ...any) {
+    sl.log(ctx, 1, level, msg, args...)
+}
+
+// log writes an entry. depth is the number of frames between the caller of
+// log and the call site reported as the source location: 1 when log is
+// called from an exported logging method.
+func (sl *StructuredLogger) log(ctx context.Context, depth int, level slog.Level, msg string, args ...any) {
+    var pc uintptr
+    if level >= slog.LevelError && sl.logger.Enabled(ctx, level) {
+        var pcs [1]uintptr
+        runtime.Callers(2+depth+sl.callerSkip, pcs[:])
+        pc = pcs[0]
+    }
+    sl.logPC(ctx, pc, level, msg, args...)
+}
+
+// logPC writes an entry whose source location, logged for ERROR and above,
+// is the call site pc; zero omits it.
+func (sl *StructuredLogger) logPC(ctx context.Context, pc uintptr, level slog.Level, msg string, args ...any) {
+    // Skip building attributes for entries the handler would drop.
+    if !sl.logger.Enabled(ctx, level) {
+        return
+    }
+    if sl.sampler != nil && !sl.sampler.keep(level) {
+        return
+    }
+
+    t := sl.clock.Now()
     attrs := []slog.Attr{
         slog.String("component", sl.component),
     }
 
+    attrs = append(attrs, sl.timestampAttrs(t)...)
+
     if sl.traceID != "" {
         attrs = append(attrs, slog.String("logging.googleapis.com/trace", sl.traceID))
     }
@@ -81,21 +200,34 @@ func (sl *StructuredLogger) Log(ctx context.Context, level slog.Level, msg strin
         attrs = append(attrs, slog.Bool("logging.googleapis.com/trace_sampled", true))
     }
 
-    if level >= slog.LevelError {
+    var source slog.Source
+    if level >= slog.LevelError && pc != 0 {
         // Add source location
-        pc, file, line, ok := runtime.Caller(2) // Adjust skip level as needed
-        if ok {
-            fn := runtime.FuncForPC(pc).Name()
+        if frame, _ := runtime.CallersFrames([]uintptr{pc}).Next(); frame.File != "" {
+            source = slog.Source{Function: frame.Function, File: frame.File, Line: frame.Line}
             attrs = append(attrs, slog.Group("logging.googleapis.com/sourceLocation",
-                slog.String("file", file),
-                slog.Int("line", line),
-                slog.String("function", fn),
+                slog.String("file", source.File),
+                slog.Int("line", source.Line),
+                slog.String("function", source.Function),
             ))
         }
     }
 
-    // Process additional args as attributes
+    // Process additional args as attributes, collecting per-call labels
+    extra := len(attrs)
+    var labels []LabelArg
+    attrs = append(attrs, sl.attrs...)
     for i := 0; i < len(args); i += 2 {
+        if label, ok := args[i].(LabelArg); ok {
+            labels = append(labels, label)
+            i-- // Labels are single arguments
+            continue
+        }
+        if attr, ok := args[i].(slog.Attr); ok {
+            attrs = append(attrs, attr)
+            i-- // As in slog, an Attr is a single argument
+            continue
+        }
         if i+1 < len(args) {
             key, ok := args[i].(string)
             if !ok {
@@ -105,80 +237,139 @@ func (sl *StructuredLogger) Log(ctx context.Context, level slog.Level, msg strin
         }
     }
 
-    // Use LogAttrs to pass slog.Attr
-    sl.logger.LogAttrs(ctx, level, msg, attrs...)
+    if len(sl.groups) > 0 {
+        attrs = append(attrs[:extra:extra], sl.groupAttrs(attrs[extra+len(sl.attrs):])...)
+    }
+
+    if len(sl.baggageKeys) > 0 {
+        labels = sl.baggageLabels(ctx, labels)
+    }
+
+    callAttrs := attrs[extra:]
+    if len(sl.filters) > 0 || sl.hooks.hasEntryHooks() {
+        entry, keep := sl.applyFilters(sl.entry(t, level, msg, source, callAttrs))
+        if keep {
+            keep = sl.hooks.runEntryHooks(ctx, &entry)
+        }
+        if !keep {
+            return
+        }
+        level, msg = entry.Level, entry.Message
+        attrs = append(attrs[:extra:extra], entry.Attrs...)
+        callAttrs = attrs[extra:]
+    }
+
+    // The legacy fields mirror the level and message filters and hooks set
+    attrs = append(attrs, sl.legacyAttrs(level, msg)...)
+
+    // Only an entry that is written can be the first of its operation
+    if opAttr, ok := sl.operationAttr(); ok {
+        attrs = append(attrs, opAttr)
+    }
+
+    if labelsAttr, ok := sl.labelsAttr(labels); ok {
+        attrs = append(attrs, labelsAttr)
+    }
+
+    // Build the record directly so it carries the time of the clock
+    record := slog.NewRecord(t, level, msg, 0)
+    record.AddAttrs(attrs...)
+    sl.logger.Handler().Handle(ctx, record)
+
+    if sl.hooks.has(level) {
+        sl.hooks.fire(sl.entry(t, level, msg, source, callAttrs))
+    }
+}
+
+// entry returns the Entry passed to filters and hooks.
+func (sl *StructuredLogger) entry(t time.Time, level slog.Level, msg string, source slog.Source, attrs []slog.Attr) Entry {
+    return Entry{
+        Time:         t,
+        Level:        level,
+        Message:      msg,
+        Component:    sl.component,
+        TraceID:      sl.traceID,
+        SpanID:       sl.spanID,
+        TraceSampled: sl.traceSampled,
+        Source:       source,
+        Attrs:        attrs,
+    }
 }
 
 // LogDebug logs a debug message.
 func (sl *StructuredLogger) LogDebug(ctx context.Context, msg string, args ...any) {
-    sl.Log(ctx, slog.LevelDebug, msg, args...)
+    sl.log(ctx, 1, slog.LevelDebug, msg, args...)
 }
 
 // LogInfo logs an info message.
 func (sl *StructuredLogger) LogInfo(ctx context.Context, msg string, args ...any) {
-    sl.Log(ctx, slog.LevelInfo, msg, args...)
+    sl.log(ctx, 1, slog.LevelInfo, msg, args...)
 }
 
 // LogNotice logs a notice message (mapped to LevelNotice).
 func (sl *StructuredLogger) LogNotice(ctx context.Context, msg string, args ...any) {
-    sl.Log(ctx, LevelNotice, msg, args...)
+    sl.log(ctx, 1, LevelNotice, msg, args...)
 }
 
 // LogWarning logs a warning message.
 func (sl *StructuredLogger) LogWarning(ctx context.Context, msg string, args ...any) {
-    sl.Log(ctx, slog.LevelWarn, msg, args...)
+    sl.log(ctx, 1, slog.LevelWarn, msg, args...)
 }
 
 // LogError logs an error message.
 func (sl *StructuredLogger) LogError(ctx context.Context, msg string, args ...any) {
-    sl.Log(ctx, slog.LevelError, msg, args...)
+    sl.log(ctx, 1, slog.LevelError, msg, args...)
 }
 
 // LogCritical logs a critical message (custom level).
 func (sl *StructuredLogger) LogCritical(ctx context.Context, msg string, args ...any) {
-    sl.Log(ctx, LevelCritical, msg, args...)
+    sl.log(ctx, 1, LevelCritical, msg, args...)
 }
 
 // LogAlert logs an alert message (custom level).
 func (sl *StructuredLogger) LogAlert(ctx context.Context, msg string, args ...any) {
-    sl.Log(ctx, LevelAlert, msg, args...)
+    sl.log(ctx, 1, LevelAlert, msg, args...)
 }
 
 // LogEmergency logs an emergency message (custom level).
 func (sl *StructuredLogger) LogEmergency(ctx context.Context, msg string, args ...any) {
-    sl.Log(ctx, LevelEmergency, msg, args...)
+    sl.log(ctx, 1, LevelEmergency, msg, args...)
 }
 
 // SetLogLevel sets the minimum level of logs to output.
 func (sl *StructuredLogger) SetLogLevel(level string) {
-    var slogLevel slog.Level
+    slogLevel, ok := ParseLevel(level)
+    if !ok {
+        slogLevel = slog.LevelInfo
+    }
+
+    // Recreate the handler with the new minimum level
+    sl.logger = slog.New(sl.newHandler(slogLevel))
+}
+
+// ParseLevel returns the level for a severity name such as "DEBUG" or
+// "WARNING", ignoring case. It returns false for an unknown name.
+func ParseLevel(level string) (slog.Level, bool) {
     switch strings.ToUpper(level) {
     case "DEBUG":
-        slogLevel = slog.LevelDebug
+        return slog.LevelDebug, true
     case "INFO":
-        slogLevel = slog.LevelInfo
+        return slog.LevelInfo, true
     case "NOTICE":
-        slogLevel = LevelNotice
-    case "WARNING":
-        slogLevel = slog.LevelWarn
+        return LevelNotice, true
+    case "WARNING", "WARN":
+        return slog.LevelWarn, true
     case "ERROR":
-        slogLevel = slog.LevelError
+        return slog.LevelError, true
     case "CRITICAL":
-        slogLevel = LevelCritical
+        return LevelCritical, true
     case "ALERT":
-        slogLevel = LevelAlert
+        return LevelAlert, true
     case "EMERGENCY":
-        slogLevel = LevelEmergency
+        return LevelEmergency, true
     default:
-        slogLevel = slog.LevelInfo
+        return slog.LevelInfo, false
     }
-
-    // Update the handler options to set the log level
-    handler := slog.NewJSONHandler(sl.writer, &slog.HandlerOptions{
-        Level:     slogLevel,
-        AddSource: false,
-    })
-    sl.logger = slog.New(handler)
 }
 
 // Custom log levels beyond the standard slog levels
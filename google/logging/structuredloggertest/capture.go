@@ -0,0 +1,246 @@
+// capture.go
+
+// [License Header Omitted for Brevity]
+
+// Package structuredloggertest captures the entries of a StructuredLogger in
+// memory so tests can assert on them without parsing JSON output.
+package structuredloggertest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	structured "github.com/duizendstra/go/google/logging"
+)
+
+// Entry is a captured log entry.
+type Entry struct {
+	Level   slog.Level
+	Message string
+	Attrs   []slog.Attr
+}
+
+// Value returns the attribute at key, where a dotted key such as
+// "httpRequest.status" selects an attribute inside a group.
+func (e Entry) Value(key string) (slog.Value, bool) {
+	return lookup(e.Attrs, key)
+}
+
+// lookup finds key in attrs, descending into the group whose key is a
+// dotted prefix of key. Keys that contain dots themselves, such as
+// "logging.googleapis.com/sourceLocation", match as a whole.
+func lookup(attrs []slog.Attr, key string) (slog.Value, bool) {
+	for _, a := range attrs {
+		v := a.Value.Resolve()
+		if a.Key == key {
+			return v, true
+		}
+		if rest, ok := strings.CutPrefix(key, a.Key+"."); ok && v.Kind() == slog.KindGroup {
+			if found, ok := lookup(v.Group(), rest); ok {
+				return found, true
+			}
+		}
+	}
+	return slog.Value{}, false
+}
+
+// String formats the entry for failure messages.
+func (e Entry) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %q", e.Level, e.Message)
+	for _, a := range e.Attrs {
+		fmt.Fprintf(&b, " %s", a)
+	}
+	return b.String()
+}
+
+// Capture is a slog.Handler that records every entry it handles. It is safe
+// for concurrent use.
+type Capture struct {
+	store  *store
+	bound  []slog.Attr
+	groups []string
+}
+
+type store struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// NewCapture returns an empty Capture. Install it with
+// structured.WithAdditionalHandler, or use NewLogger.
+func NewCapture() *Capture {
+	return &Capture{store: &store{}}
+}
+
+// NewLogger returns a logger at DEBUG level whose entries are captured, and
+// the Capture holding them. The JSON output is discarded.
+func NewLogger(opts ...structured.Option) (*structured.StructuredLogger, *Capture) {
+	capture := NewCapture()
+	opts = append([]structured.Option{structured.WithAdditionalHandler(capture)}, opts...)
+	logger := structured.NewStructuredLogger("test-project", "test-component", nil, io.Discard, opts...)
+	logger.SetLogLevel("DEBUG")
+	return logger, capture
+}
+
+// Enabled implements slog.Handler; the logger's level decides what is captured.
+func (c *Capture) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+// Handle implements slog.Handler.
+func (c *Capture) Handle(_ context.Context, r slog.Record) error {
+	attrs := append([]slog.Attr(nil), c.bound...)
+	var recordAttrs []slog.Attr
+	r.Attrs(func(a slog.Attr) bool {
+		recordAttrs = append(recordAttrs, a)
+		return true
+	})
+	for i := len(c.groups) - 1; i >= 0; i-- {
+		recordAttrs = []slog.Attr{{Key: c.groups[i], Value: slog.GroupValue(recordAttrs...)}}
+	}
+	attrs = append(attrs, recordAttrs...)
+
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	c.store.entries = append(c.store.entries, Entry{Level: r.Level, Message: r.Message, Attrs: attrs})
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (c *Capture) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *c
+	for i := len(c.groups) - 1; i >= 0; i-- {
+		attrs = []slog.Attr{{Key: c.groups[i], Value: slog.GroupValue(attrs...)}}
+	}
+	clone.bound = append(append([]slog.Attr(nil), c.bound...), attrs...)
+	return &clone
+}
+
+// WithGroup implements slog.Handler.
+func (c *Capture) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return c
+	}
+	clone := *c
+	clone.groups = append(append([]string(nil), c.groups...), name)
+	return &clone
+}
+
+// Entries returns the captured entries in the order they were logged.
+func (c *Capture) Entries() []Entry {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	return append([]Entry(nil), c.store.entries...)
+}
+
+// Reset discards the captured entries.
+func (c *Capture) Reset() {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	c.store.entries = nil
+}
+
+// Find returns the first entry at level whose message contains msgContains
+// and that has every key-value pair of kv, for example "status", 200.
+func (c *Capture) Find(level slog.Level, msgContains string, kv ...any) (Entry, bool) {
+	for _, e := range c.Entries() {
+		if e.Level == level && strings.Contains(e.Message, msgContains) && matches(e, kv) {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// AssertLogged fails the test unless an entry at level whose message contains
+// msgContains and that has every key-value pair of kv was captured. Keys may
+// be dotted to select attributes inside groups.
+func (c *Capture) AssertLogged(t testing.TB, level slog.Level, msgContains string, kv ...any) {
+	t.Helper()
+	if _, ok := c.Find(level, msgContains, kv...); !ok {
+		t.Errorf("Expected an entry at %s containing %q with %v, got:\n%s", level, msgContains, kv, c.dump())
+	}
+}
+
+// AssertNotLogged fails the test if an entry matching the arguments of
+// AssertLogged was captured.
+func (c *Capture) AssertNotLogged(t testing.TB, level slog.Level, msgContains string, kv ...any) {
+	t.Helper()
+	if e, ok := c.Find(level, msgContains, kv...); ok {
+		t.Errorf("Expected no entry at %s containing %q with %v, got %s", level, msgContains, kv, e)
+	}
+}
+
+// dump lists the captured entries, one per line.
+func (c *Capture) dump() string {
+	entries := c.Entries()
+	if len(entries) == 0 {
+		return "  (no entries)"
+	}
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = "  " + e.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// matches reports whether e has every key-value pair of kv.
+func matches(e Entry, kv []any) bool {
+	for i := 0; i+1 < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			return false
+		}
+		got, ok := e.Value(key)
+		if !ok || !equal(got, kv[i+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// equal compares a captured value with an expected one. Numbers compare by
+// value regardless of their Go type, and an expected string matches an error
+// or fmt.Stringer with that text.
+func equal(got slog.Value, want any) bool {
+	wantValue := slog.AnyValue(want)
+	if x, ok := number(got); ok {
+		if y, ok := number(wantValue); ok {
+			return x.Cmp(y) == 0
+		}
+	}
+	if got.Kind() != slog.KindAny && wantValue.Kind() != slog.KindAny {
+		return got.Equal(wantValue)
+	}
+	if s, ok := want.(string); ok {
+		if err, ok := got.Any().(error); ok {
+			return err.Error() == s
+		}
+		if stringer, ok := got.Any().(fmt.Stringer); ok {
+			return stringer.String() == s
+		}
+	}
+	return reflect.DeepEqual(got.Any(), want)
+}
+
+// number returns the exact value of an Int64, Uint64, or finite Float64 value,
+// since slog.Value.Equal does not compare values of different kinds.
+func number(v slog.Value) (*big.Rat, bool) {
+	switch v.Kind() {
+	case slog.KindInt64:
+		return new(big.Rat).SetInt64(v.Int64()), true
+	case slog.KindUint64:
+		return new(big.Rat).SetUint64(v.Uint64()), true
+	case slog.KindFloat64:
+		r := new(big.Rat).SetFloat64(v.Float64())
+		return r, r != nil
+	}
+	return nil, false
+}
@@ -0,0 +1,72 @@
+// capture_test.go
+
+package structuredloggertest
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestCapture(t *testing.T) {
+	logger, capture := NewLogger()
+	ctx := context.Background()
+
+	logger.LogDebug(ctx, "Cache miss", "key", "user:42")
+	logger.With("tenant", "acme").LogError(ctx, "Order failed", "order_id", "o-1", "attempts", 3, "error", errors.New("quota exceeded"))
+
+	capture.AssertLogged(t, slog.LevelDebug, "Cache", "key", "user:42")
+	capture.AssertLogged(t, slog.LevelError, "Order failed", "tenant", "acme", "attempts", 3, "error", "quota exceeded")
+	capture.AssertLogged(t, slog.LevelError, "Order failed", "logging.googleapis.com/sourceLocation.function", "github.com/duizendstra/go/google/logging/structuredloggertest.TestCapture")
+	capture.AssertNotLogged(t, slog.LevelWarn, "Order failed")
+
+	if _, ok := capture.Find(slog.LevelError, "Order failed", "attempts", 4); ok {
+		t.Error("Expected no entry with attempts 4")
+	}
+	if len(capture.Entries()) != 2 {
+		t.Errorf("Expected 2 entries, got %d", len(capture.Entries()))
+	}
+	capture.Reset()
+	if len(capture.Entries()) != 0 {
+		t.Errorf("Expected no entries after Reset, got %d", len(capture.Entries()))
+	}
+}
+
+func TestAssertLoggedNumbers(t *testing.T) {
+	logger, capture := NewLogger()
+	logger.LogInfo(context.Background(), "Uploaded", "size", uint64(10), "ratio", 0.5, "offset", int8(-3))
+
+	capture.AssertLogged(t, slog.LevelInfo, "Uploaded", "size", 10, "ratio", 0.5, "offset", -3)
+	capture.AssertLogged(t, slog.LevelInfo, "Uploaded", "size", 10.0, "offset", -3.0)
+	if _, ok := capture.Find(slog.LevelInfo, "Uploaded", "size", 11); ok {
+		t.Error("Expected no entry with size 11")
+	}
+	if _, ok := capture.Find(slog.LevelInfo, "Uploaded", "ratio", 1); ok {
+		t.Error("Expected no entry with ratio 1")
+	}
+	if _, ok := capture.Find(slog.LevelInfo, "Uploaded", "offset", uint64(18446744073709551613)); ok {
+		t.Error("Expected a negative offset not to match a large unsigned value")
+	}
+}
+
+func TestAssertLoggedFails(t *testing.T) {
+	logger, capture := NewLogger()
+	logger.LogInfo(context.Background(), "Started")
+
+	ft := &fakeT{TB: t}
+	capture.AssertLogged(ft, slog.LevelInfo, "Stopped")
+	if !ft.failed {
+		t.Error("Expected AssertLogged to fail for a missing entry")
+	}
+}
+
+// fakeT records failures instead of failing the test.
+type fakeT struct {
+	testing.TB
+	failed bool
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...any) { t.failed = true }
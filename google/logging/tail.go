@@ -0,0 +1,139 @@
+// tail.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultTailSamplingMaxEntries is the default number of entries buffered per request.
+const DefaultTailSamplingMaxEntries = 256
+
+// TailSampling configures WithTailSampling.
+type TailSampling struct {
+	// Level is the highest level that is buffered. The zero value, INFO,
+	// buffers DEBUG and INFO entries.
+	Level slog.Level
+	// Latency keeps the buffered entries of requests that take longer.
+	// Zero keeps them only for failed requests.
+	Latency time.Duration
+	// MaxEntries bounds the buffer of a request; the oldest entries are
+	// dropped first. Defaults to DefaultTailSamplingMaxEntries.
+	MaxEntries int
+}
+
+// WithTailSampling buffers the DEBUG and INFO entries of each request handled
+// by Middleware instead of writing them. They are written, in order, when the
+// request logs an ERROR or higher entry, ends with a 5xx status, or takes
+// longer than cfg.Latency, and dropped otherwise, so failing requests carry
+// their debug context without the cost of always-on debug logging. Once the
+// buffer is written, the rest of the request is logged at DEBUG. Entries
+// above cfg.Level, and the "Request finished" entry, are written as usual.
+// Requests with the debug header of WithDebugHeader are not buffered.
+func WithTailSampling(cfg TailSampling) Option {
+	return func(sl *StructuredLogger) {
+		if cfg.MaxEntries <= 0 {
+			cfg.MaxEntries = DefaultTailSamplingMaxEntries
+		}
+		sl.tailSampling = &cfg
+	}
+}
+
+// States of a tailBuffer.
+const (
+	tailBuffering = iota
+	tailFlushed
+	tailFinished
+)
+
+// tailBuffer holds the buffered entries of one request.
+type tailBuffer struct {
+	mu      sync.Mutex
+	cfg     TailSampling
+	state   int
+	pending []bufferedRecord
+}
+
+type bufferedRecord struct {
+	ctx     context.Context
+	handler slog.Handler
+	record  slog.Record
+}
+
+func newTailBuffer(cfg TailSampling) *tailBuffer {
+	return &tailBuffer{cfg: cfg}
+}
+
+// flushLocked writes the pending entries. b.mu must be held.
+func (b *tailBuffer) flushLocked() {
+	for _, p := range b.pending {
+		p.handler.Handle(p.ctx, p.record)
+	}
+	b.pending = nil
+}
+
+// finish writes the pending entries of a request that failed or was slow and
+// drops them otherwise. Later entries are written at the logger's level.
+func (b *tailBuffer) finish(status int, latency time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == tailBuffering && (status >= 500 || (b.cfg.Latency > 0 && latency > b.cfg.Latency)) {
+		b.flushLocked()
+	}
+	b.pending = nil
+	b.state = tailFinished
+}
+
+// tailHandler buffers entries in a tailBuffer in front of a DEBUG handler.
+// level is the level of the logger, applied once the request has finished.
+type tailHandler struct {
+	next   slog.Handler
+	level  slog.Leveler
+	buffer *tailBuffer
+}
+
+func (h *tailHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if level >= h.level.Level() {
+		return true
+	}
+	h.buffer.mu.Lock()
+	defer h.buffer.mu.Unlock()
+	return h.buffer.state != tailFinished && h.next.Enabled(ctx, level)
+}
+
+func (h *tailHandler) Handle(ctx context.Context, r slog.Record) error {
+	b := h.buffer
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case b.state == tailFlushed:
+	case b.state == tailFinished || (r.Level > b.cfg.Level && r.Level < slog.LevelError):
+		if r.Level < h.level.Level() {
+			return nil
+		}
+	case r.Level >= slog.LevelError:
+		b.flushLocked()
+		b.state = tailFlushed
+	default:
+		if len(b.pending) == b.cfg.MaxEntries {
+			b.pending = b.pending[1:]
+		}
+		b.pending = append(b.pending, bufferedRecord{ctx: ctx, handler: h.next, record: r.Clone()})
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *tailHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &tailHandler{next: h.next.WithAttrs(attrs), level: h.level, buffer: h.buffer}
+}
+
+func (h *tailHandler) WithGroup(name string) slog.Handler {
+	return &tailHandler{next: h.next.WithGroup(name), level: h.level, buffer: h.buffer}
+}
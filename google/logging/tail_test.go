@@ -0,0 +1,109 @@
+// tail_test.go
+
+package structured
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tailRequest serves one request through Middleware with tail sampling and
+// returns the messages written to stderr.
+func tailRequest(t *testing.T, cfg TailSampling, handler http.HandlerFunc) []string {
+	t.Helper()
+	stderr := os.Stderr
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Error creating pipe: %v", err)
+	}
+	os.Stderr = w
+	Middleware("test-project", "test-component", WithTailSampling(cfg))(handler).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders", nil))
+	os.Stderr = stderr
+	w.Close()
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if i := strings.Index(line, `"msg":"`); i >= 0 {
+			rest := line[i+len(`"msg":"`):]
+			messages = append(messages, rest[:strings.Index(rest, `"`)])
+		}
+	}
+	return messages
+}
+
+func TestTailSampling(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      TailSampling
+		handler  http.HandlerFunc
+		expected []string
+	}{
+		{
+			name: "Successful request drops the buffer",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				logger := FromContext(r.Context())
+				logger.LogDebug(r.Context(), "Loaded cart")
+				logger.LogInfo(r.Context(), "Charged card")
+				logger.LogWarning(r.Context(), "Slow inventory")
+			},
+			expected: []string{"Slow inventory", "Request finished"},
+		},
+		{
+			name: "Error flushes the buffer",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				logger := FromContext(r.Context())
+				logger.LogDebug(r.Context(), "Loaded cart")
+				logger.LogInfo(r.Context(), "Charging card")
+				logger.LogError(r.Context(), "Card declined")
+				logger.LogDebug(r.Context(), "Rolling back")
+			},
+			expected: []string{"Request started", "Loaded cart", "Charging card", "Card declined", "Rolling back", "Request finished"},
+		},
+		{
+			name: "Server error status flushes the buffer",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				FromContext(r.Context()).LogDebug(r.Context(), "Loaded cart")
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			expected: []string{"Request started", "Loaded cart", "Request finished"},
+		},
+		{
+			name: "Slow request flushes the buffer",
+			cfg:  TailSampling{Latency: time.Millisecond},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				FromContext(r.Context()).LogDebug(r.Context(), "Loaded cart")
+				time.Sleep(5 * time.Millisecond)
+			},
+			expected: []string{"Request started", "Loaded cart", "Request finished"},
+		},
+		{
+			name: "Buffer keeps the newest entries",
+			cfg:  TailSampling{MaxEntries: 2},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				logger := FromContext(r.Context())
+				logger.LogDebug(r.Context(), "Step 1")
+				logger.LogDebug(r.Context(), "Step 2")
+				logger.LogDebug(r.Context(), "Step 3")
+				logger.LogError(r.Context(), "Failed")
+			},
+			expected: []string{"Step 2", "Step 3", "Failed", "Request finished"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := tailRequest(t, tt.cfg, tt.handler)
+			if strings.Join(messages, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("Expected messages %v, got %v", tt.expected, messages)
+			}
+		})
+	}
+}
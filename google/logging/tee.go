@@ -0,0 +1,60 @@
+// tee.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// WithAdditionalHandler also emits every entry through h, next to the primary
+// output. Use it to bridge into an OpenTelemetry log pipeline, for example
+// with the handler from go.opentelemetry.io/contrib/bridges/otelslog, while
+// keeping the stdout JSON that Cloud Run ingests. The logger's minimum level
+// applies to additional handlers as well.
+func WithAdditionalHandler(h slog.Handler) Option {
+	return func(sl *StructuredLogger) {
+		if h != nil {
+			sl.additional = append(sl.additional, h)
+		}
+	}
+}
+
+// teeHandler forwards records to a primary handler and to additional handlers.
+type teeHandler struct {
+	primary slog.Handler
+	others  []slog.Handler
+}
+
+func (h *teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.primary.Enabled(ctx, level)
+}
+
+func (h *teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	errs := []error{h.primary.Handle(ctx, r)}
+	for _, other := range h.others {
+		if other.Enabled(ctx, r.Level) {
+			errs = append(errs, other.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	others := make([]slog.Handler, len(h.others))
+	for i, other := range h.others {
+		others[i] = other.WithAttrs(attrs)
+	}
+	return &teeHandler{primary: h.primary.WithAttrs(attrs), others: others}
+}
+
+func (h *teeHandler) WithGroup(name string) slog.Handler {
+	others := make([]slog.Handler, len(h.others))
+	for i, other := range h.others {
+		others[i] = other.WithGroup(name)
+	}
+	return &teeHandler{primary: h.primary.WithGroup(name), others: others}
+}
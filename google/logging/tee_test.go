@@ -0,0 +1,60 @@
+// tee_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+// recordingHandler keeps the records it receives.
+type recordingHandler struct {
+	records *[]slog.Record
+}
+
+func (h recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	*h.records = append(*h.records, r)
+	return nil
+}
+func (h recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h recordingHandler) WithGroup(string) slog.Handler      { return h }
+
+func TestWithAdditionalHandler(t *testing.T) {
+	var buf bytes.Buffer
+	var records []slog.Record
+	sl := NewStructuredLogger("", "test-component", nil, &buf, WithAdditionalHandler(recordingHandler{records: &records}))
+
+	ctx := context.Background()
+	sl.LogDebug(ctx, "filtered debug message")
+	sl.LogInfo(ctx, "Test message", "userID", 12345)
+
+	if buf.Len() == 0 {
+		t.Errorf("Expected output on the primary writer")
+	}
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 record on the additional handler, got %d", len(records))
+	}
+	if records[0].Message != "Test message" {
+		t.Errorf("Expected message 'Test message', got '%s'", records[0].Message)
+	}
+
+	attrs := map[string]any{}
+	records[0].Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.Any()
+		return true
+	})
+	if attrs["component"] != "test-component" || attrs["userID"] != int64(12345) {
+		t.Errorf("Expected component and userID attributes, got %v", attrs)
+	}
+
+	// SetLogLevel keeps the additional handler in place
+	records = nil
+	sl.SetLogLevel("DEBUG")
+	sl.LogDebug(ctx, "debug message")
+	if len(records) != 1 {
+		t.Errorf("Expected the additional handler to receive DEBUG after SetLogLevel, got %d records", len(records))
+	}
+}
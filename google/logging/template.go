@@ -0,0 +1,117 @@
+// template.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// MessageTemplateKey is the field holding the unrendered template of entries
+// written by Logf and the Log*f variants.
+const MessageTemplateKey = "message_template"
+
+// Logf logs a message template such as "User {user} logged in from {ip}".
+// Each {name} hole is filled in order with the next value from args, and the
+// value is also kept as a structured field under its name, with the template
+// itself under MessageTemplateKey. The rendered message supports text search
+// while the fields and the stable template support structured filtering. Use
+// "{{" and "}}" for literal braces. Args left over after the holes are filled
+// are treated as key-value pairs, as in Log.
+func (sl *StructuredLogger) Logf(ctx context.Context, level slog.Level, template string, args ...any) {
+	msg, fields := renderTemplate(template, args)
+	sl.log(ctx, 1, level, msg, fields...)
+}
+
+// renderTemplate fills the holes of template from args and returns the
+// rendered message with the key-value pairs to log.
+func renderTemplate(template string, args []any) (string, []any) {
+	var b strings.Builder
+	fields := []any{MessageTemplateKey, template}
+	next := 0
+
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		switch {
+		case c == '{' && i+1 < len(template) && template[i+1] == '{':
+			b.WriteByte('{')
+			i++
+		case c == '}' && i+1 < len(template) && template[i+1] == '}':
+			b.WriteByte('}')
+			i++
+		case c == '{':
+			end := strings.IndexByte(template[i+1:], '}')
+			if end < 0 {
+				b.WriteString(template[i:])
+				i = len(template)
+				continue
+			}
+			name := template[i+1 : i+1+end]
+			i += end + 1
+			if next >= len(args) {
+				b.WriteString("{" + name + "}")
+				continue
+			}
+			value := args[next]
+			next++
+			b.WriteString(fmt.Sprint(value))
+			fields = append(fields, name, value)
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String(), append(fields, args[next:]...)
+}
+
+// LogDebugf logs a debug message template.
+func (sl *StructuredLogger) LogDebugf(ctx context.Context, template string, args ...any) {
+	msg, fields := renderTemplate(template, args)
+	sl.log(ctx, 1, slog.LevelDebug, msg, fields...)
+}
+
+// LogInfof logs an info message template.
+func (sl *StructuredLogger) LogInfof(ctx context.Context, template string, args ...any) {
+	msg, fields := renderTemplate(template, args)
+	sl.log(ctx, 1, slog.LevelInfo, msg, fields...)
+}
+
+// LogNoticef logs a notice message template.
+func (sl *StructuredLogger) LogNoticef(ctx context.Context, template string, args ...any) {
+	msg, fields := renderTemplate(template, args)
+	sl.log(ctx, 1, LevelNotice, msg, fields...)
+}
+
+// LogWarningf logs a warning message template.
+func (sl *StructuredLogger) LogWarningf(ctx context.Context, template string, args ...any) {
+	msg, fields := renderTemplate(template, args)
+	sl.log(ctx, 1, slog.LevelWarn, msg, fields...)
+}
+
+// LogErrorf logs an error message template.
+func (sl *StructuredLogger) LogErrorf(ctx context.Context, template string, args ...any) {
+	msg, fields := renderTemplate(template, args)
+	sl.log(ctx, 1, slog.LevelError, msg, fields...)
+}
+
+// LogCriticalf logs a critical message template.
+func (sl *StructuredLogger) LogCriticalf(ctx context.Context, template string, args ...any) {
+	msg, fields := renderTemplate(template, args)
+	sl.log(ctx, 1, LevelCritical, msg, fields...)
+}
+
+// LogAlertf logs an alert message template.
+func (sl *StructuredLogger) LogAlertf(ctx context.Context, template string, args ...any) {
+	msg, fields := renderTemplate(template, args)
+	sl.log(ctx, 1, LevelAlert, msg, fields...)
+}
+
+// LogEmergencyf logs an emergency message template.
+func (sl *StructuredLogger) LogEmergencyf(ctx context.Context, template string, args ...any) {
+	msg, fields := renderTemplate(template, args)
+	sl.log(ctx, 1, LevelEmergency, msg, fields...)
+}
@@ -0,0 +1,95 @@
+// template_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	tests := []struct {
+		name           string
+		template       string
+		args           []any
+		expectedMsg    string
+		expectedFields []any
+	}{
+		{
+			name:           "Named holes",
+			template:       "User {user} logged in from {ip}",
+			args:           []any{"alice", "10.0.0.1"},
+			expectedMsg:    "User alice logged in from 10.0.0.1",
+			expectedFields: []any{MessageTemplateKey, "User {user} logged in from {ip}", "user", "alice", "ip", "10.0.0.1"},
+		},
+		{
+			name:           "Escaped braces",
+			template:       "Set {{key}} to {value}",
+			args:           []any{3},
+			expectedMsg:    "Set {key} to 3",
+			expectedFields: []any{MessageTemplateKey, "Set {{key}} to {value}", "value", 3},
+		},
+		{
+			name:           "Missing argument",
+			template:       "Hello {name}",
+			args:           nil,
+			expectedMsg:    "Hello {name}",
+			expectedFields: []any{MessageTemplateKey, "Hello {name}"},
+		},
+		{
+			name:           "Extra key-value pairs",
+			template:       "Processed {count} items",
+			args:           []any{5, "batch", "b-1"},
+			expectedMsg:    "Processed 5 items",
+			expectedFields: []any{MessageTemplateKey, "Processed {count} items", "count", 5, "batch", "b-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, fields := renderTemplate(tt.template, tt.args)
+			if msg != tt.expectedMsg {
+				t.Errorf("Expected message '%s', got '%s'", tt.expectedMsg, msg)
+			}
+			if len(fields) != len(tt.expectedFields) {
+				t.Fatalf("Expected fields %v, got %v", tt.expectedFields, fields)
+			}
+			for i := range fields {
+				if fields[i] != tt.expectedFields[i] {
+					t.Errorf("Expected field %d to be '%v', got '%v'", i, tt.expectedFields[i], fields[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLogErrorf(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+	sl.LogErrorf(context.Background(), "Order {order_id} failed after {attempts} attempts", "o-42", 3)
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if loggedEntry["msg"] != "Order o-42 failed after 3 attempts" {
+		t.Errorf("Expected rendered message, got '%v'", loggedEntry["msg"])
+	}
+	if loggedEntry[MessageTemplateKey] != "Order {order_id} failed after {attempts} attempts" {
+		t.Errorf("Expected the template field, got '%v'", loggedEntry[MessageTemplateKey])
+	}
+	if loggedEntry["order_id"] != "o-42" || loggedEntry["attempts"] != float64(3) {
+		t.Errorf("Expected the arguments as fields, got order_id='%v' attempts='%v'", loggedEntry["order_id"], loggedEntry["attempts"])
+	}
+
+	loc, ok := loggedEntry["logging.googleapis.com/sourceLocation"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected a source location")
+	}
+	if file, _ := loc["file"].(string); !strings.HasSuffix(file, "template_test.go") {
+		t.Errorf("Expected the source location of the caller, got '%v'", loc["file"])
+	}
+}
@@ -0,0 +1,43 @@
+// timing.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Outcomes of an operation timed with TimeOperation.
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+// TimeOperation logs "<name> started" at DEBUG and returns a function that
+// logs "<name> finished" with the elapsed time under "duration_ms" and the
+// outcome under "outcome", so performance logs have the same shape across
+// services. The finish entry is INFO, or ERROR with the error when err points
+// to a non-nil error; err may be nil. args are added to both entries.
+// Defer the returned function with a named error result:
+//
+//	func export(ctx context.Context) (err error) {
+//		defer logger.TimeOperation(ctx, "Export", "tenant", tenant)(&err)
+//		...
+//	}
+func (sl *StructuredLogger) TimeOperation(ctx context.Context, name string, args ...any) func(err *error) {
+	sl.log(ctx, 1, slog.LevelDebug, name+" started", args...)
+	start := time.Now()
+	return func(err *error) {
+		timing := []any{"duration_ms", time.Since(start).Milliseconds()}
+		if err != nil && *err != nil {
+			timing = append(timing, "outcome", OutcomeError, "error", *err)
+			sl.log(ctx, 1, slog.LevelError, name+" finished", append(timing, args...)...)
+			return
+		}
+		timing = append(timing, "outcome", OutcomeSuccess)
+		sl.log(ctx, 1, slog.LevelInfo, name+" finished", append(timing, args...)...)
+	}
+}
@@ -0,0 +1,71 @@
+// timing_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTimeOperation(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		expectedLevel   string
+		expectedOutcome string
+	}{
+		{"Success", nil, "INFO", OutcomeSuccess},
+		{"Error", errors.New("quota exceeded"), "ERROR", OutcomeError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			sl := NewStructuredLogger("", "test-component", nil, &buf)
+			sl.SetLogLevel("DEBUG")
+
+			func() (err error) {
+				defer sl.TimeOperation(context.Background(), "Export", "tenant", "acme")(&err)
+				return tt.err
+			}()
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("Expected 2 entries, got %d: %s", len(lines), buf.String())
+			}
+			var started, finished map[string]interface{}
+			if err := json.Unmarshal([]byte(lines[0]), &started); err != nil {
+				t.Fatalf("Error unmarshaling log output: %v", err)
+			}
+			if err := json.Unmarshal([]byte(lines[1]), &finished); err != nil {
+				t.Fatalf("Error unmarshaling log output: %v", err)
+			}
+
+			if started["msg"] != "Export started" || started["level"] != "DEBUG" || started["tenant"] != "acme" {
+				t.Errorf("Expected a DEBUG start entry with the arguments, got %v", started)
+			}
+			if finished["msg"] != "Export finished" || finished["level"] != tt.expectedLevel {
+				t.Errorf("Expected a %s finish entry, got %v", tt.expectedLevel, finished)
+			}
+			if finished["outcome"] != tt.expectedOutcome || finished["tenant"] != "acme" {
+				t.Errorf("Expected outcome %q with the arguments, got %v", tt.expectedOutcome, finished)
+			}
+			if _, ok := finished["duration_ms"].(float64); !ok {
+				t.Errorf("Expected duration_ms, got %v", finished["duration_ms"])
+			}
+		})
+	}
+}
+
+func TestTimeOperationNilError(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+	sl.TimeOperation(context.Background(), "Warmup")(nil)
+	if !strings.Contains(buf.String(), `"outcome":"success"`) {
+		t.Errorf("Expected a successful outcome, got %s", buf.String())
+	}
+}
@@ -0,0 +1,70 @@
+// traceparent.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxTracestateLength is the tracestate length the W3C recommendation
+// requires propagators to support; longer values are dropped.
+const maxTracestateLength = 512
+
+// reTraceparent matches a W3C traceparent header. Versions after 00 may
+// append fields, which are ignored.
+var reTraceparent = regexp.MustCompile(`^([a-f\d]{2})-([a-f\d]{32})-([a-f\d]{16})-([a-f\d]{2})(-.*)?$`)
+
+// WithPreferTraceparent gives the W3C traceparent header precedence over
+// X-Cloud-Trace-Context when a request carries both, for services whose
+// callers are OpenTelemetry-instrumented.
+func WithPreferTraceparent() Option {
+	return func(sl *StructuredLogger) {
+		sl.preferTraceparent = true
+	}
+}
+
+// TraceState returns the W3C tracestate of the request when its trace
+// context was taken from the traceparent header, for propagation to
+// downstream calls.
+func (sl *StructuredLogger) TraceState() string {
+	return sl.traceState
+}
+
+// deconstructTraceparent parses the W3C traceparent header.
+func deconstructTraceparent(s string) (traceID, spanID string, traceSampled bool) {
+	matches := reTraceparent.FindStringSubmatch(strings.TrimSpace(s))
+	if len(matches) != 6 {
+		return "", "", false
+	}
+	version, traceID, spanID := matches[1], matches[2], matches[3]
+	if version == "ff" || (version == "00" && matches[5] != "") {
+		return "", "", false
+	}
+	if traceID == strings.Repeat("0", 32) || spanID == strings.Repeat("0", 16) {
+		return "", "", false
+	}
+	flags, _ := strconv.ParseUint(matches[4], 16, 8)
+	return traceID, spanID, flags&1 == 1
+}
+
+// deconstructTracestate joins the tracestate header lines into one list,
+// dropping it when it exceeds maxTracestateLength.
+func deconstructTracestate(values []string) string {
+	var members []string
+	for _, v := range values {
+		for _, member := range strings.Split(v, ",") {
+			if member = strings.TrimSpace(member); member != "" {
+				members = append(members, member)
+			}
+		}
+	}
+	state := strings.Join(members, ",")
+	if len(state) > maxTracestateLength {
+		return ""
+	}
+	return state
+}
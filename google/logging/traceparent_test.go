@@ -0,0 +1,85 @@
+// traceparent_test.go
+
+package structured
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDeconstructTraceparent(t *testing.T) {
+	tests := []struct {
+		name            string
+		header          string
+		expectedTraceID string
+		expectedSpanID  string
+		expectedSampled bool
+	}{
+		{"Sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true},
+		{"Not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", false},
+		{"Invalid trace ID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "", "", false},
+		{"Malformed", "garbage", "", "", false},
+		{"Invalid version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "", "", false},
+		{"Future version with extra fields", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true},
+		{"Version 00 with extra fields", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traceID, spanID, sampled := deconstructTraceparent(tt.header)
+			if traceID != tt.expectedTraceID || spanID != tt.expectedSpanID || sampled != tt.expectedSampled {
+				t.Errorf("Expected (%s, %s, %v), got (%s, %s, %v)", tt.expectedTraceID, tt.expectedSpanID, tt.expectedSampled, traceID, spanID, sampled)
+			}
+		})
+	}
+}
+
+func TestTraceparentFallback(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	sl := NewStructuredLogger("test-project", "test-component", r, nil)
+	if sl.traceID != "projects/test-project/traces/4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected trace from traceparent, got '%s'", sl.traceID)
+	}
+	if sl.spanID != "00f067aa0ba902b7" || !sl.traceSampled {
+		t.Errorf("Expected span and sampled flag from traceparent, got '%s' %v", sl.spanID, sl.traceSampled)
+	}
+}
+
+func TestTracePrecedence(t *testing.T) {
+	newRequest := func() *http.Request {
+		r, _ := http.NewRequest("GET", "/", nil)
+		r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b120001000/1;o=0")
+		r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		r.Header.Add("tracestate", "congo=t61rcWkgMzE")
+		r.Header.Add("tracestate", "rojo=00f067aa0ba902b7")
+		return r
+	}
+
+	sl := NewStructuredLogger("test-project", "test-component", newRequest(), nil)
+	if sl.traceID != "projects/test-project/traces/105445aa7843bc8bf206b120001000" || sl.traceSampled {
+		t.Errorf("Expected X-Cloud-Trace-Context to take precedence, got '%s' sampled=%v", sl.traceID, sl.traceSampled)
+	}
+	if sl.TraceState() != "" {
+		t.Errorf("Expected no tracestate without the traceparent, got '%s'", sl.TraceState())
+	}
+
+	sl = NewStructuredLogger("test-project", "test-component", newRequest(), nil, WithPreferTraceparent())
+	if sl.traceID != "projects/test-project/traces/4bf92f3577b34da6a3ce929d0e0e4736" || !sl.traceSampled {
+		t.Errorf("Expected traceparent to take precedence, got '%s' sampled=%v", sl.traceID, sl.traceSampled)
+	}
+	if sl.TraceState() != "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7" {
+		t.Errorf("Expected the combined tracestate, got '%s'", sl.TraceState())
+	}
+}
+
+func TestDeconstructTracestate(t *testing.T) {
+	if got := deconstructTracestate([]string{" a=1 , b=2", "", "c=3"}); got != "a=1,b=2,c=3" {
+		t.Errorf("Expected 'a=1,b=2,c=3', got '%s'", got)
+	}
+	if got := deconstructTracestate([]string{"a=" + strings.Repeat("x", maxTracestateLength)}); got != "" {
+		t.Errorf("Expected an oversized tracestate to be dropped, got %d bytes", len(got))
+	}
+}
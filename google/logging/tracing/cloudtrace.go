@@ -0,0 +1,194 @@
+// cloudtrace.go
+
+// [License Header Omitted for Brevity]
+
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	structured "github.com/duizendstra/go/google/logging"
+)
+
+// DefaultCloudTraceEndpoint is the default endpoint of CloudTraceConfig.
+const DefaultCloudTraceEndpoint = "https://cloudtrace.googleapis.com/v2"
+
+// Cloud Trace limits on the size of span names and attributes, in bytes.
+const (
+	maxDisplayNameBytes    = 128
+	maxAttributeKeyBytes   = 128
+	maxAttributeValueBytes = 256
+)
+
+// CloudTraceConfig configures a CloudTraceExporter.
+type CloudTraceConfig struct {
+	// ProjectID is the project the spans are written to. Defaults to
+	// DetectProjectID.
+	ProjectID string
+	// HTTPClient must be authorized for the Cloud Trace API, for example one
+	// returned by google.DefaultClient. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// Endpoint defaults to DefaultCloudTraceEndpoint.
+	Endpoint string
+}
+
+// CloudTraceExporter writes spans with the batchWrite method of the Cloud
+// Trace API.
+type CloudTraceExporter struct {
+	cfg CloudTraceConfig
+}
+
+// NewCloudTraceExporter returns a CloudTraceExporter.
+func NewCloudTraceExporter(cfg CloudTraceConfig) *CloudTraceExporter {
+	if cfg.ProjectID == "" {
+		cfg.ProjectID = structured.DetectProjectID(context.Background())
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = DefaultCloudTraceEndpoint
+	}
+	return &CloudTraceExporter{cfg: cfg}
+}
+
+type cloudTraceSpan struct {
+	Name         string               `json:"name"`
+	SpanID       string               `json:"spanId"`
+	ParentSpanID string               `json:"parentSpanId,omitempty"`
+	DisplayName  truncatableString    `json:"displayName"`
+	StartTime    string               `json:"startTime"`
+	EndTime      string               `json:"endTime"`
+	Attributes   *cloudTraceAttrs     `json:"attributes,omitempty"`
+	Status       *cloudTraceSpanState `json:"status,omitempty"`
+}
+
+type truncatableString struct {
+	Value              string `json:"value"`
+	TruncatedByteCount int    `json:"truncatedByteCount,omitempty"`
+}
+
+type cloudTraceAttrs struct {
+	AttributeMap map[string]cloudTraceValue `json:"attributeMap"`
+}
+
+type cloudTraceValue struct {
+	StringValue *truncatableString `json:"stringValue,omitempty"`
+	IntValue    string             `json:"intValue,omitempty"`
+	BoolValue   *bool              `json:"boolValue,omitempty"`
+}
+
+type cloudTraceSpanState struct {
+	// Code is a google.rpc.Code; 2 is UNKNOWN.
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// ExportSpans writes spans with projects.traces.batchWrite.
+func (e *CloudTraceExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	req := struct {
+		Spans []cloudTraceSpan `json:"spans"`
+	}{Spans: make([]cloudTraceSpan, len(spans))}
+	for i, span := range spans {
+		req.Spans[i] = e.span(span)
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/projects/%s/traces:batchWrite", e.cfg.Endpoint, e.cfg.ProjectID)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := e.cfg.HTTPClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("trace export failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// span converts a span into a Cloud Trace Span resource.
+func (e *CloudTraceExporter) span(span SpanData) cloudTraceSpan {
+	out := cloudTraceSpan{
+		Name:         fmt.Sprintf("projects/%s/traces/%s/spans/%s", e.cfg.ProjectID, span.TraceID, span.SpanID),
+		SpanID:       span.SpanID,
+		ParentSpanID: span.ParentSpanID,
+		DisplayName:  truncate(span.Name, maxDisplayNameBytes),
+		StartTime:    span.Start.UTC().Format(time.RFC3339Nano),
+		EndTime:      span.End.UTC().Format(time.RFC3339Nano),
+	}
+	if len(span.Attributes) > 0 {
+		out.Attributes = &cloudTraceAttrs{AttributeMap: make(map[string]cloudTraceValue, len(span.Attributes))}
+		for key, value := range span.Attributes {
+			out.Attributes.AttributeMap[truncate(key, maxAttributeKeyBytes).Value] = cloudTraceAttr(value)
+		}
+	}
+	if span.Error != "" {
+		out.Status = &cloudTraceSpanState{Code: 2, Message: span.Error}
+	}
+	return out
+}
+
+// cloudTraceAttr converts an attribute value into an AttributeValue.
+func cloudTraceAttr(value any) cloudTraceValue {
+	if b, ok := value.(bool); ok {
+		return cloudTraceValue{BoolValue: &b}
+	}
+	if n, ok := intValue(value); ok {
+		return cloudTraceValue{IntValue: strconv.FormatInt(n, 10)}
+	}
+	s := truncate(fmt.Sprint(value), maxAttributeValueBytes)
+	return cloudTraceValue{StringValue: &s}
+}
+
+// truncate cuts s to at most n bytes on a rune boundary.
+func truncate(s string, n int) truncatableString {
+	if len(s) <= n {
+		return truncatableString{Value: s}
+	}
+	cut := n
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return truncatableString{Value: s[:cut], TruncatedByteCount: len(s) - cut}
+}
+
+// intValue returns the value of a signed or unsigned integer that fits in
+// an int64.
+func intValue(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	}
+	return 0, false
+}
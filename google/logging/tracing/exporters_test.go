@@ -0,0 +1,114 @@
+// exporters_test.go
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSpan() SpanData {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	return SpanData{
+		TraceID:      "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:       "00f067aa0ba902b7",
+		ParentSpanID: "00000000000004d2",
+		Name:         "load-user",
+		Start:        start,
+		End:          start.Add(25 * time.Millisecond),
+		Attributes:   map[string]any{"user_id": "u-1", "attempts": 2, "cached": true},
+		Error:        "not found",
+	}
+}
+
+func TestCloudTraceExporter(t *testing.T) {
+	var path string
+	var body map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer ts.Close()
+
+	exporter := NewCloudTraceExporter(CloudTraceConfig{ProjectID: "test-project", Endpoint: ts.URL})
+	if err := exporter.ExportSpans(context.Background(), []SpanData{testSpan()}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	if path != "/projects/test-project/traces:batchWrite" {
+		t.Errorf("Expected path /projects/test-project/traces:batchWrite, got %s", path)
+	}
+	span := body["spans"].([]any)[0].(map[string]any)
+	if span["name"] != "projects/test-project/traces/4bf92f3577b34da6a3ce929d0e0e4736/spans/00f067aa0ba902b7" {
+		t.Errorf("Expected the span resource name, got %v", span["name"])
+	}
+	if span["startTime"] != "2024-01-02T03:04:05Z" || span["endTime"] != "2024-01-02T03:04:05.025Z" {
+		t.Errorf("Expected RFC 3339 times, got %v and %v", span["startTime"], span["endTime"])
+	}
+	attrs := span["attributes"].(map[string]any)["attributeMap"].(map[string]any)
+	if attrs["attempts"].(map[string]any)["intValue"] != "2" || attrs["cached"].(map[string]any)["boolValue"] != true {
+		t.Errorf("Expected typed attributes, got %v", attrs)
+	}
+	if span["status"].(map[string]any)["message"] != "not found" {
+		t.Errorf("Expected status message not found, got %v", span["status"])
+	}
+}
+
+func TestCloudTraceExporterError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	exporter := NewCloudTraceExporter(CloudTraceConfig{ProjectID: "test-project", Endpoint: ts.URL})
+	err := exporter.ExportSpans(context.Background(), []SpanData{testSpan()})
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Expected an error with status 403, got %v", err)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	got := truncate(strings.Repeat("é", 100), 129)
+	if len(got.Value) != 128 || got.TruncatedByteCount != 72 {
+		t.Errorf("Expected 128 bytes and 72 truncated, got %d and %d", len(got.Value), got.TruncatedByteCount)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var apiKey string
+	var body map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey = r.Header.Get("x-api-key")
+		json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer ts.Close()
+
+	exporter := NewOTLPExporter(OTLPConfig{Endpoint: ts.URL, ServiceName: "orders-api", Headers: map[string]string{"x-api-key": "secret"}})
+	if err := exporter.ExportSpans(context.Background(), []SpanData{testSpan()}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	if apiKey != "secret" {
+		t.Errorf("Expected header x-api-key secret, got %s", apiKey)
+	}
+	resourceSpans := body["resourceSpans"].([]any)[0].(map[string]any)
+	service := resourceSpans["resource"].(map[string]any)["attributes"].([]any)[0].(map[string]any)
+	if service["key"] != "service.name" || service["value"].(map[string]any)["stringValue"] != "orders-api" {
+		t.Errorf("Expected service.name orders-api, got %v", service)
+	}
+	span := resourceSpans["scopeSpans"].([]any)[0].(map[string]any)["spans"].([]any)[0].(map[string]any)
+	if span["traceId"] != "4bf92f3577b34da6a3ce929d0e0e4736" || span["parentSpanId"] != "00000000000004d2" {
+		t.Errorf("Expected hexadecimal IDs, got %v and %v", span["traceId"], span["parentSpanId"])
+	}
+	if span["startTimeUnixNano"] != "1704164645000000000" || span["endTimeUnixNano"] != "1704164645025000000" {
+		t.Errorf("Expected times in Unix nanoseconds, got %v and %v", span["startTimeUnixNano"], span["endTimeUnixNano"])
+	}
+	if span["status"].(map[string]any)["code"] != float64(2) {
+		t.Errorf("Expected status code 2, got %v", span["status"])
+	}
+}
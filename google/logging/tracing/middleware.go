@@ -0,0 +1,84 @@
+// middleware.go
+
+// [License Header Omitted for Brevity]
+
+package tracing
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Middleware starts a span for every request, named after the method and the
+// route pattern the http.ServeMux matched, such as "GET /orders/{id}", with
+// the http.method, http.route, and http.status_code attributes. Requests not
+// routed by a ServeMux are named after the method alone.
+// Install it inside the Middleware of the logging package, so the span joins
+// the trace of the request headers:
+//
+//	handler := structured.Middleware(projectID, "api")(tracer.Middleware(mux))
+func (t *Tracer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := t.Start(r.Context(), r.Method, "http.method", r.Method)
+		defer span.End()
+
+		rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		req := r.WithContext(ctx)
+		next.ServeHTTP(rw, req)
+
+		// The ServeMux sets the pattern on the request it routes.
+		if i := strings.Index(req.Pattern, "/"); i >= 0 {
+			route := req.Pattern[i:]
+			span.setName(r.Method + " " + route)
+			span.SetAttributes("http.route", route)
+		}
+
+		span.SetAttributes("http.status_code", rw.status)
+		if rw.status >= 500 {
+			span.RecordError(fmt.Errorf("%d %s", rw.status, http.StatusText(rw.status)))
+		}
+	})
+}
+
+// statusRecorder records the status code of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher for streaming handlers.
+func (w *statusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker for websocket handlers.
+func (w *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, fmt.Errorf("response writer does not support hijacking")
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
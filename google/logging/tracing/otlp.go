@@ -0,0 +1,179 @@
+// otlp.go
+
+// [License Header Omitted for Brevity]
+
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	structured "github.com/duizendstra/go/google/logging"
+)
+
+// DefaultOTLPEndpoint is the default endpoint of OTLPConfig, the traces path
+// of a collector on the same host.
+const DefaultOTLPEndpoint = "http://localhost:4318/v1/traces"
+
+// instrumentationScope is the scope name of the exported spans.
+const instrumentationScope = "github.com/duizendstra/go/google/logging/tracing"
+
+// OTLPConfig configures an OTLPExporter.
+type OTLPConfig struct {
+	// Endpoint is the OTLP/HTTP traces URL. Defaults to
+	// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT, then DefaultOTLPEndpoint.
+	Endpoint string
+	// Headers are added to every export request, for example an API key.
+	Headers map[string]string
+	// ServiceName is the service.name resource attribute. Defaults to the
+	// service of DetectResource.
+	ServiceName string
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// OTLPExporter sends spans to an OpenTelemetry collector with OTLP/HTTP in
+// the JSON encoding.
+type OTLPExporter struct {
+	cfg OTLPConfig
+}
+
+// NewOTLPExporter returns an OTLPExporter.
+func NewOTLPExporter(cfg OTLPConfig) *OTLPExporter {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = DefaultOTLPEndpoint
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = structured.DetectResource().Service
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	return &OTLPExporter{cfg: cfg}
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            *otlpStatus    `json:"status,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    string  `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+type otlpStatus struct {
+	// Code 2 is STATUS_CODE_ERROR.
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// ExportSpans posts spans to the collector.
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	scope := otlpScopeSpans{Spans: make([]otlpSpan, len(spans))}
+	scope.Scope.Name = instrumentationScope
+	for i, span := range spans {
+		scope.Spans[i] = otlpSpanOf(span)
+	}
+	var resource otlpResource
+	if e.cfg.ServiceName != "" {
+		resource.Attributes = []otlpKeyValue{{Key: "service.name", Value: otlpAttr(e.cfg.ServiceName)}}
+	}
+	body, err := json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{{Resource: resource, ScopeSpans: []otlpScopeSpans{scope}}}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", e.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := e.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("trace export failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// otlpSpanOf converts a span into an OTLP span of kind INTERNAL.
+func otlpSpanOf(span SpanData) otlpSpan {
+	out := otlpSpan{
+		TraceID:           span.TraceID,
+		SpanID:            span.SpanID,
+		ParentSpanID:      span.ParentSpanID,
+		Name:              span.Name,
+		Kind:              1,
+		StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+	}
+	for key, value := range span.Attributes {
+		out.Attributes = append(out.Attributes, otlpKeyValue{Key: key, Value: otlpAttr(value)})
+	}
+	if span.Error != "" {
+		out.Status = &otlpStatus{Code: 2, Message: span.Error}
+	}
+	return out
+}
+
+// otlpAttr converts an attribute value into an AnyValue.
+func otlpAttr(value any) otlpValue {
+	if b, ok := value.(bool); ok {
+		return otlpValue{BoolValue: &b}
+	}
+	if n, ok := intValue(value); ok {
+		return otlpValue{IntValue: strconv.FormatInt(n, 10)}
+	}
+	s := fmt.Sprint(value)
+	return otlpValue{StringValue: &s}
+}
@@ -0,0 +1,105 @@
+// span.go
+
+// [License Header Omitted for Brevity]
+
+package tracing
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+)
+
+type spanKey struct{}
+
+// SpanData is an ended span as passed to an Exporter. IDs are hexadecimal,
+// as in traceparent.
+type SpanData struct {
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Name         string
+	Start        time.Time
+	End          time.Time
+	// Attributes holds string, bool, and integer values; other values are
+	// exported as their fmt.Sprint text.
+	Attributes map[string]any
+	// Error is the message of the error recorded with RecordError.
+	Error string
+}
+
+// Span is a span started by a Tracer. It is safe for concurrent use.
+type Span struct {
+	tracer  *Tracer
+	sampled bool
+
+	mu   sync.Mutex
+	data SpanData
+	once sync.Once
+}
+
+// FromContext returns the span stored by Tracer.Start, or nil.
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// TraceID returns the hexadecimal trace ID of the span.
+func (s *Span) TraceID() string {
+	return s.data.TraceID
+}
+
+// SpanID returns the hexadecimal ID of the span.
+func (s *Span) SpanID() string {
+	return s.data.SpanID
+}
+
+// SetAttributes adds key-value pairs to the span. Keys must be strings.
+func (s *Span) SetAttributes(args ...any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i+1 < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			continue // Key must be a string
+		}
+		if s.data.Attributes == nil {
+			s.data.Attributes = make(map[string]any)
+		}
+		s.data.Attributes[key] = args[i+1]
+	}
+}
+
+// setName renames the span, for names known only after it started.
+func (s *Span) setName(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Name = name
+}
+
+// RecordError marks the span as failed with err. A nil err is ignored.
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Error = err.Error()
+}
+
+// End ends the span and queues it for export when its trace is sampled.
+// Calls after the first are ignored.
+func (s *Span) End() {
+	s.once.Do(func() {
+		if !s.sampled {
+			return
+		}
+		s.mu.Lock()
+		s.data.End = time.Now()
+		data := s.data
+		data.Attributes = maps.Clone(s.data.Attributes)
+		s.mu.Unlock()
+		s.tracer.enqueue(data)
+	})
+}
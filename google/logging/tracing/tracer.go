@@ -0,0 +1,144 @@
+// tracer.go
+
+// [License Header Omitted for Brevity]
+
+// Package tracing starts spans in the trace of the request-scoped
+// StructuredLogger and exports them to Cloud Trace or an OTLP collector, so
+// a service can record where the time of a request goes without the
+// OpenTelemetry SDK. Entries logged within a span are attributed to it.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	structured "github.com/duizendstra/go/google/logging"
+	"github.com/duizendstra/go/google/logging/internal/batch"
+)
+
+// Defaults of Config.
+const (
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = 5 * time.Second
+	DefaultQueueSize     = 2048
+)
+
+// Exporter sends ended spans to a tracing backend.
+type Exporter interface {
+	ExportSpans(ctx context.Context, spans []SpanData) error
+}
+
+// Config configures a Tracer.
+type Config struct {
+	// Exporter receives the sampled spans, for example a CloudTraceExporter
+	// or an OTLPExporter. When nil, spans are discarded.
+	Exporter Exporter
+	// BatchSize is the number of queued spans that triggers an export.
+	BatchSize int
+	// FlushInterval is the longest a span waits in the queue.
+	FlushInterval time.Duration
+	// QueueSize bounds the queue; spans beyond it are dropped.
+	QueueSize int
+}
+
+// Tracer starts spans. Ended spans of sampled traces wait in a bounded queue
+// and reach the Exporter in batches, off the request path; Dropped counts
+// those that did not fit or failed to export. It is safe for concurrent use.
+type Tracer struct {
+	cfg     Config
+	batcher *batch.Batcher[SpanData]
+}
+
+// NewTracer starts a Tracer. Call Close on shutdown to export the queued
+// spans.
+func NewTracer(cfg Config) *Tracer {
+	if cfg.Exporter == nil {
+		cfg.Exporter = discardExporter{}
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+
+	t := &Tracer{cfg: cfg}
+	t.batcher = batch.New(cfg.BatchSize, cfg.FlushInterval, cfg.QueueSize, t.export)
+	return t
+}
+
+// Start starts a span named name with the given key-value attributes. Its
+// parent is the span in ctx, or else the trace context of the logger in ctx,
+// such as the one Middleware of the logging package parsed from the request
+// headers; without either, the span starts a new, sampled trace. The returned
+// context carries the span and a logger whose entries belong to it. End the
+// span when the work is done:
+//
+//	ctx, span := tracer.Start(ctx, "load-user", "user_id", id)
+//	defer span.End()
+func (t *Tracer) Start(ctx context.Context, name string, args ...any) (context.Context, *Span) {
+	span := &Span{tracer: t, data: SpanData{Name: name, SpanID: newID(8), Start: time.Now()}}
+	if parent := FromContext(ctx); parent != nil {
+		span.data.TraceID, span.data.ParentSpanID, span.sampled = parent.data.TraceID, parent.data.SpanID, parent.sampled
+	} else if traceID, spanID, sampled := structured.FromContext(ctx).SpanContext(); traceID != "" {
+		span.data.TraceID, span.data.ParentSpanID, span.sampled = traceID, spanID, sampled
+	} else {
+		span.data.TraceID, span.sampled = newID(16), true
+	}
+	span.SetAttributes(args...)
+
+	logger := structured.FromContext(ctx).WithSpan(span.data.TraceID, span.data.SpanID, span.sampled)
+	ctx = context.WithValue(ctx, spanKey{}, span)
+	return structured.NewContext(ctx, logger), span
+}
+
+// Dropped returns the number of spans that were dropped or failed to export.
+func (t *Tracer) Dropped() int64 {
+	return t.batcher.Dropped()
+}
+
+// Flush exports the queued spans and waits until they are exported or ctx is
+// done.
+func (t *Tracer) Flush(ctx context.Context) error {
+	return t.batcher.Flush(ctx)
+}
+
+// Close exports the queued spans and stops the tracer.
+func (t *Tracer) Close(ctx context.Context) error {
+	return t.batcher.Close(ctx)
+}
+
+// enqueue queues an ended span without blocking.
+func (t *Tracer) enqueue(data SpanData) {
+	t.batcher.Add(data)
+}
+
+// export sends a batch of spans to the exporter, counting the whole batch as
+// failed when the export fails.
+func (t *Tracer) export(spans []SpanData) (failed int) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := t.cfg.Exporter.ExportSpans(ctx, spans); err != nil {
+		return len(spans)
+	}
+	return 0
+}
+
+// discardExporter drops spans, for tracers without an exporter.
+type discardExporter struct{}
+
+func (discardExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	return nil
+}
+
+// newID returns n random bytes in hexadecimal.
+func newID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
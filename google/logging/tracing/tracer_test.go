@@ -0,0 +1,197 @@
+// tracer_test.go
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	structured "github.com/duizendstra/go/google/logging"
+)
+
+// memoryExporter keeps the exported spans.
+type memoryExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+func (e *memoryExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *memoryExporter) exported() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]SpanData(nil), e.spans...)
+}
+
+func requestContext(traceHeader string) context.Context {
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("X-Cloud-Trace-Context", traceHeader)
+	return structured.NewContext(context.Background(), structured.NewStructuredLogger("test-project", "test-component", req, nil))
+}
+
+func TestStartFromLoggerTrace(t *testing.T) {
+	exporter := &memoryExporter{}
+	tracer := NewTracer(Config{Exporter: exporter})
+
+	ctx, span := tracer.Start(requestContext("4bf92f3577b34da6a3ce929d0e0e4736/1234;o=1"), "load-user", "user_id", "u-1")
+	_, child := tracer.Start(ctx, "query")
+	child.RecordError(errors.New("not found"))
+	child.End()
+	span.End()
+	if err := tracer.Close(context.Background()); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	spans := exporter.exported()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	query, load := spans[0], spans[1]
+	if load.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || load.ParentSpanID != "00000000000004d2" {
+		t.Errorf("Expected the span to continue the request trace, got trace %s parent %s", load.TraceID, load.ParentSpanID)
+	}
+	if load.Attributes["user_id"] != "u-1" {
+		t.Errorf("Expected attribute user_id u-1, got %v", load.Attributes["user_id"])
+	}
+	if query.TraceID != load.TraceID || query.ParentSpanID != load.SpanID {
+		t.Errorf("Expected query to be a child of load-user, got parent %s", query.ParentSpanID)
+	}
+	if query.Error != "not found" {
+		t.Errorf("Expected error not found, got %q", query.Error)
+	}
+	if load.End.Before(load.Start) {
+		t.Errorf("Expected the end after the start")
+	}
+}
+
+func TestStartSetsLoggerSpan(t *testing.T) {
+	tracer := NewTracer(Config{Exporter: &memoryExporter{}})
+	defer tracer.Close(context.Background())
+
+	ctx, span := tracer.Start(requestContext("4bf92f3577b34da6a3ce929d0e0e4736/1234;o=1"), "work")
+	defer span.End()
+
+	traceID, spanID, _ := structured.FromContext(ctx).SpanContext()
+	if traceID != span.TraceID() || spanID != span.SpanID() {
+		t.Errorf("Expected the logger in the context to carry span %s, got %s", span.SpanID(), spanID)
+	}
+}
+
+func TestStartRootSpan(t *testing.T) {
+	exporter := &memoryExporter{}
+	tracer := NewTracer(Config{Exporter: exporter})
+
+	_, span := tracer.Start(context.Background(), "job")
+	span.End()
+	span.End()
+	tracer.Close(context.Background())
+
+	spans := exporter.exported()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	if len(spans[0].TraceID) != 32 || len(spans[0].SpanID) != 16 || spans[0].ParentSpanID != "" {
+		t.Errorf("Expected a new trace without parent, got %+v", spans[0])
+	}
+}
+
+func TestUnsampledSpansAreNotExported(t *testing.T) {
+	exporter := &memoryExporter{}
+	tracer := NewTracer(Config{Exporter: exporter})
+
+	_, span := tracer.Start(requestContext("4bf92f3577b34da6a3ce929d0e0e4736/1234;o=0"), "work")
+	span.End()
+	tracer.Close(context.Background())
+
+	if spans := exporter.exported(); len(spans) != 0 {
+		t.Errorf("Expected no spans for an unsampled trace, got %d", len(spans))
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	exporter := &memoryExporter{}
+	tracer := NewTracer(Config{Exporter: exporter})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if FromContext(r.Context()) == nil {
+			t.Error("Expected a span in the request context")
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	handler := structured.Middleware("test-project", "test-component")(tracer.Middleware(mux))
+	req := httptest.NewRequest("GET", "/orders/o-1", nil)
+	req.Header.Set("X-Cloud-Trace-Context", "4bf92f3577b34da6a3ce929d0e0e4736/1234;o=1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	tracer.Close(context.Background())
+
+	spans := exporter.exported()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Name != "GET /orders/{id}" || spans[0].TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected span GET /orders/{id} in the request trace, got %s in %s", spans[0].Name, spans[0].TraceID)
+	}
+	if spans[0].Attributes["http.route"] != "/orders/{id}" {
+		t.Errorf("Expected the route template, got %v", spans[0].Attributes["http.route"])
+	}
+	if spans[0].Attributes["http.status_code"] != http.StatusServiceUnavailable || spans[0].Error == "" {
+		t.Errorf("Expected a failed span with status 503, got %v %q", spans[0].Attributes["http.status_code"], spans[0].Error)
+	}
+}
+
+func TestMiddlewareWithoutRoute(t *testing.T) {
+	exporter := &memoryExporter{}
+	tracer := NewTracer(Config{Exporter: exporter})
+
+	handler := tracer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/users/u-42", nil))
+	tracer.Close(context.Background())
+
+	spans := exporter.exported()
+	if len(spans) != 1 || spans[0].Name != "DELETE" {
+		t.Fatalf("Expected one span named after the method, got %v", spans)
+	}
+	if _, ok := spans[0].Attributes["http.route"]; ok {
+		t.Errorf("Expected no http.route without a matched pattern, got %v", spans[0].Attributes["http.route"])
+	}
+}
+
+func TestTracerWithoutExporter(t *testing.T) {
+	tracer := NewTracer(Config{})
+
+	_, span := tracer.Start(context.Background(), "job")
+	span.End()
+	if err := tracer.Close(context.Background()); err != nil {
+		t.Errorf("Expected Close to succeed without an exporter, got %v", err)
+	}
+}
+
+func TestMiddlewareFlusher(t *testing.T) {
+	tracer := NewTracer(Config{})
+	defer tracer.Close(context.Background())
+
+	handler := tracer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("Expected the response writer to implement http.Flusher")
+		}
+		w.Write([]byte("data: 1\n\n"))
+		f.Flush()
+	}))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/events", nil))
+
+	if !recorder.Flushed {
+		t.Error("Expected the response to be flushed")
+	}
+}
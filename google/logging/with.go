@@ -0,0 +1,82 @@
+// with.go
+
+// [License Header Omitted for Brevity]
+
+package structured
+
+import (
+	"log/slog"
+	"maps"
+)
+
+// With returns a derived logger that adds the given key-value pairs to every
+// entry, so attributes such as a tenant or job ID are not repeated on every
+// call. Arguments are key-value pairs or slog.Attr values, as in LogInfo;
+// labels created with Label become logger labels. The parent logger is
+// not changed; the derived logger shares its output, level, and hooks.
+func (sl *StructuredLogger) With(args ...any) *StructuredLogger {
+	clone := *sl
+	clone.attrs = append([]slog.Attr(nil), sl.attrs...)
+	labelsCopied := false
+	for i := 0; i < len(args); i += 2 {
+		if label, ok := args[i].(LabelArg); ok {
+			if !labelsCopied {
+				clone.labels = make(map[string]string, len(sl.labels)+1)
+				maps.Copy(clone.labels, sl.labels)
+				labelsCopied = true
+			}
+			clone.labels[label.Key] = label.Value
+			i-- // Labels are single arguments
+			continue
+		}
+		if attr, ok := args[i].(slog.Attr); ok {
+			clone.attrs = append(clone.attrs, attr)
+			i-- // As in slog, an Attr is a single argument
+			continue
+		}
+		if i+1 < len(args) {
+			key, ok := args[i].(string)
+			if !ok {
+				continue // Key must be a string
+			}
+			clone.attrs = append(clone.attrs, slog.Any(key, args[i+1]))
+		}
+	}
+	return &clone
+}
+
+// WithGroup returns a derived logger that nests the attributes of every entry,
+// and those added by later With calls, under name, as slog.Logger.WithGroup
+// does. In Cloud Logging they appear as jsonPayload.<name>.<key>. The
+// component, trace, source location, labels, and attributes added before the
+// group stay at the top level. An empty name returns sl.
+func (sl *StructuredLogger) WithGroup(name string) *StructuredLogger {
+	if name == "" {
+		return sl
+	}
+	clone := *sl
+	clone.groups = append(append([]attrGroup(nil), sl.groups...), attrGroup{name: name, start: len(sl.attrs)})
+	return &clone
+}
+
+// attrGroup is a group opened by WithGroup; start is the index of the first
+// attribute of sl.attrs that belongs to it.
+type attrGroup struct {
+	name  string
+	start int
+}
+
+// groupAttrs returns the attributes of sl.attrs and the per-call attrs nested
+// under the open groups.
+func (sl *StructuredLogger) groupAttrs(attrs []slog.Attr) []slog.Attr {
+	for i := len(sl.groups) - 1; i >= 0; i-- {
+		end := len(sl.attrs)
+		if i+1 < len(sl.groups) {
+			end = sl.groups[i+1].start
+		}
+		members := append(append([]slog.Attr(nil), sl.attrs[sl.groups[i].start:end]...), attrs...)
+		attrs = []slog.Attr{{Key: sl.groups[i].name, Value: slog.GroupValue(members...)}}
+	}
+	top := sl.groups[0].start
+	return append(sl.attrs[:top:top], attrs...)
+}
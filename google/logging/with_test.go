@@ -0,0 +1,131 @@
+// with_test.go
+
+package structured
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestWith(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf, WithLabels(map[string]string{"env": "prod"}))
+	child := sl.With("tenant", "acme", "jobID", 42, Label("team", "billing"))
+
+	child.LogInfo(context.Background(), "Child entry", "step", "load")
+	sl.LogInfo(context.Background(), "Parent entry")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(lines))
+	}
+
+	var childEntry, parentEntry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &childEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &parentEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+
+	if childEntry["tenant"] != "acme" || childEntry["jobID"] != float64(42) || childEntry["step"] != "load" {
+		t.Errorf("Expected tenant, jobID, and step on the child entry, got %v", childEntry)
+	}
+	childLabels, _ := childEntry[keyLabels].(map[string]interface{})
+	if childLabels["env"] != "prod" || childLabels["team"] != "billing" {
+		t.Errorf("Expected env and team labels on the child entry, got %v", childLabels)
+	}
+
+	if _, ok := parentEntry["tenant"]; ok {
+		t.Errorf("Expected the parent logger to be unchanged, got %v", parentEntry)
+	}
+	parentLabels, _ := parentEntry[keyLabels].(map[string]interface{})
+	if _, ok := parentLabels["team"]; ok {
+		t.Errorf("Expected the parent labels to be unchanged, got %v", parentLabels)
+	}
+}
+
+func TestWithSlogAttr(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+	sl.With(slog.String("tenant", "acme"), "jobID", 42, slog.Group("batch", slog.Int("size", 10))).LogInfo(context.Background(), "Child entry")
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	batch, _ := loggedEntry["batch"].(map[string]interface{})
+	if loggedEntry["tenant"] != "acme" || loggedEntry["jobID"] != float64(42) || batch["size"] != float64(10) {
+		t.Errorf("Expected the slog.Attr arguments and the key-value pair, got %v", loggedEntry)
+	}
+}
+
+func TestWithHookAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+	var entry Entry
+	sl.RegisterHook(LevelNotice, func(e Entry) { entry = e })
+
+	sl.With("tenant", "acme").LogNotice(context.Background(), "Hooked")
+
+	if len(entry.Attrs) != 1 || entry.Attrs[0].Key != "tenant" {
+		t.Errorf("Expected the hook to receive the tenant attribute, got %v", entry.Attrs)
+	}
+}
+
+func TestWithGroup(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+	child := sl.With("tenant", "acme").WithGroup("request").With("method", "GET").WithGroup("headers")
+
+	child.LogInfo(context.Background(), "Request received", "accept", "application/json", Label("team", "billing"))
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if loggedEntry["tenant"] != "acme" || loggedEntry["component"] != "test-component" {
+		t.Errorf("Expected tenant and component at the top level, got %v", loggedEntry)
+	}
+	request, _ := loggedEntry["request"].(map[string]interface{})
+	if request["method"] != "GET" {
+		t.Errorf("Expected request.method to be GET, got %v", loggedEntry["request"])
+	}
+	headers, _ := request["headers"].(map[string]interface{})
+	if headers["accept"] != "application/json" {
+		t.Errorf("Expected request.headers.accept, got %v", loggedEntry["request"])
+	}
+	labels, _ := loggedEntry["logging.googleapis.com/labels"].(map[string]interface{})
+	if labels["team"] != "billing" {
+		t.Errorf("Expected labels at the top level, got %v", loggedEntry)
+	}
+
+	buf.Reset()
+	sl.LogInfo(context.Background(), "Parent entry", "method", "POST")
+	var parentEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &parentEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	if _, exists := parentEntry["request"]; exists || parentEntry["method"] != "POST" {
+		t.Errorf("Expected the parent logger to be unchanged, got %v", parentEntry)
+	}
+}
+
+func TestGroupAttr(t *testing.T) {
+	var buf bytes.Buffer
+	sl := NewStructuredLogger("", "test-component", nil, &buf)
+	sl.LogInfo(context.Background(), "API call", slog.Group("request", "method", "GET", "path", "/users"))
+
+	var loggedEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &loggedEntry); err != nil {
+		t.Fatalf("Error unmarshaling log output: %v", err)
+	}
+	request, _ := loggedEntry["request"].(map[string]interface{})
+	if request["method"] != "GET" || request["path"] != "/users" {
+		t.Errorf("Expected a nested request group, got %v", loggedEntry)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	budget := NewBudget(4, 0.5)
+	p := Policy{MaxAttempts: 10, InitialBackoff: time.Millisecond, Budget: budget}
+
+	// 4 tokens: retries stop once 2 or fewer remain.
+	calls := 0
+	err := Do(context.Background(), p, func(ctx context.Context) error {
+		calls++
+		return errTransient
+	})
+	if !errors.Is(err, ErrBudgetExhausted) || !errors.Is(err, errTransient) {
+		t.Fatalf("Expected budget exhausted wrapping the last error, got: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 calls before the budget ran out, got %d", calls)
+	}
+
+	// Successes earn tokens back.
+	for i := 0; i < 4; i++ {
+		Do(context.Background(), p, func(ctx context.Context) error { return nil })
+	}
+	if got := budget.Tokens(); got != 4 {
+		t.Errorf("Expected the budget to refill to 4 tokens, got %v", got)
+	}
+}
+
+func TestBudgetPermanentErrorsAreFree(t *testing.T) {
+	budget := NewBudget(4, 0.5)
+	p := Policy{MaxAttempts: 10, InitialBackoff: time.Millisecond, Budget: budget}
+
+	for i := 0; i < 3; i++ {
+		Do(context.Background(), p, func(ctx context.Context) error { return MarkPermanent(errTransient) })
+	}
+	if got := budget.Tokens(); got != 4 {
+		t.Errorf("Expected permanent errors not to cost tokens, got %v tokens", got)
+	}
+}
@@ -0,0 +1,5 @@
+module github.com/duizendstra/go/google/retry
+
+go 1.23.2
+
+require github.com/duizendstra/go/google/errors v0.0.1
@@ -0,0 +1,222 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	apierrors "github.com/duizendstra/go/google/errors"
+)
+
+// Default values used for zero fields of a Policy.
+const (
+	DefaultMaxAttempts    = 3
+	DefaultInitialBackoff = 100 * time.Millisecond
+	DefaultMaxBackoff     = 10 * time.Second
+	DefaultMultiplier     = 2.0
+)
+
+// ErrBudgetExhausted is returned, wrapping the last error, when the retry
+// budget does not allow another attempt.
+var ErrBudgetExhausted = errors.New("retry budget exhausted")
+
+// Decision is the outcome of classifying an error.
+type Decision int
+
+const (
+	// Retryable errors are retried while attempts and budget remain.
+	Retryable Decision = iota
+	// Permanent errors are returned immediately.
+	Permanent
+)
+
+// Policy describes how an operation is retried. The zero value retries three
+// times with exponential backoff starting at 100ms.
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts.
+	MaxBackoff time.Duration
+	// Multiplier grows the delay after each retry.
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction (0 to 1) in either direction.
+	Jitter float64
+	// Classify decides whether an error is retried. Defaults to DefaultClassifier.
+	Classify func(error) Decision
+	// Budget, when set, limits retries across all operations sharing it.
+	Budget *Budget
+	// OnRetry is called before sleeping ahead of each retry.
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// PermanentError marks an error as not retryable.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// MarkPermanent wraps err so that DefaultClassifier does not retry it.
+func MarkPermanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// RetryAfterError carries a server-provided minimum delay before the next attempt.
+type RetryAfterError struct {
+	Err   error
+	Delay time.Duration
+}
+
+func (e *RetryAfterError) Error() string { return e.Err.Error() }
+func (e *RetryAfterError) Unwrap() error { return e.Err }
+
+// WithRetryAfter wraps err with a minimum delay before the next attempt,
+// e.g. from a Retry-After header.
+func WithRetryAfter(err error, delay time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &RetryAfterError{Err: err, Delay: delay}
+}
+
+// DefaultClassifier treats context errors and PermanentErrors as permanent,
+// and Google API errors as retryable only when RetryableStatus reports their
+// status, so requests failing with 400, 403, or 404 are not repeated.
+// Everything else is retryable.
+func DefaultClassifier(err error) Decision {
+	var permanent *PermanentError
+	if errors.As(err, &permanent) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return Permanent
+	}
+	var apiErr *apierrors.GoogleAPIError
+	if errors.As(err, &apiErr) && !RetryableStatus(apiErr.StatusCode) {
+		return Permanent
+	}
+	return Retryable
+}
+
+// RetryableStatus reports whether an HTTP status code is worth retrying.
+func RetryableStatus(code int) bool {
+	switch code {
+	case 408, 429, 500, 502, 503, 504:
+		return true
+	}
+	return false
+}
+
+// Do calls fn until it succeeds, returns a permanent error, or the policy's
+// attempts, budget, or ctx run out.
+func Do(ctx context.Context, p Policy, fn func(ctx context.Context) error) error {
+	_, err := DoValue(ctx, p, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+// DoValue is like Do for functions that return a value.
+func DoValue[T any](ctx context.Context, p Policy, fn func(ctx context.Context) (T, error)) (T, error) {
+	p = p.withDefaults()
+
+	for attempt := 1; ; attempt++ {
+		value, err := fn(ctx)
+		if err == nil {
+			p.Budget.success()
+			return value, nil
+		}
+
+		// Only retryable failures cost budget tokens, as in gRPC throttling
+		if p.Classify(err) == Permanent {
+			return value, err
+		}
+		p.Budget.failure()
+		if attempt >= p.MaxAttempts {
+			return value, fmt.Errorf("after %d attempts: %w", attempt, err)
+		}
+		if !p.Budget.allow() {
+			return value, fmt.Errorf("%w: %w", ErrBudgetExhausted, err)
+		}
+
+		delay := p.Backoff(attempt)
+		var retryAfter *RetryAfterError
+		if errors.As(err, &retryAfter) && retryAfter.Delay > delay {
+			delay = retryAfter.Delay
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return value, fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// Backoff returns the jittered delay before the retry following the given attempt.
+func (p Policy) Backoff(attempt int) time.Duration {
+	p = p.withDefaults()
+
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		delay *= p.Multiplier
+		if delay >= float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+	if delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	return time.Duration(delay)
+}
+
+func (p Policy) withDefaults() Policy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultMaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultInitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultMaxBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = DefaultMultiplier
+	}
+	if p.Classify == nil {
+		p.Classify = DefaultClassifier
+	}
+	return p
+}
@@ -0,0 +1,184 @@
+// Copyright 2024 Jasper Duizendstra
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	apierrors "github.com/duizendstra/go/google/errors"
+)
+
+var errTransient = errors.New("transient error")
+
+func TestDo(t *testing.T) {
+	tests := []struct {
+		name          string
+		failures      int
+		err           error
+		maxAttempts   int
+		expectedCalls int
+		expectErr     bool
+	}{
+		{name: "Succeeds first time", failures: 0, err: errTransient, maxAttempts: 3, expectedCalls: 1},
+		{name: "Succeeds after retries", failures: 2, err: errTransient, maxAttempts: 3, expectedCalls: 3},
+		{name: "Attempts exhausted", failures: 5, err: errTransient, maxAttempts: 3, expectedCalls: 3, expectErr: true},
+		{name: "Permanent error", failures: 5, err: MarkPermanent(errTransient), maxAttempts: 3, expectedCalls: 1, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			var retries []int
+			p := Policy{
+				MaxAttempts:    tt.maxAttempts,
+				InitialBackoff: time.Millisecond,
+				OnRetry:        func(attempt int, err error, delay time.Duration) { retries = append(retries, attempt) },
+			}
+
+			err := Do(context.Background(), p, func(ctx context.Context) error {
+				calls++
+				if calls <= tt.failures {
+					return tt.err
+				}
+				return nil
+			})
+
+			if tt.expectErr != (err != nil) {
+				t.Fatalf("Expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if err != nil && !errors.Is(err, errTransient) {
+				t.Errorf("Expected the last error to be wrapped, got: %v", err)
+			}
+			if calls != tt.expectedCalls {
+				t.Errorf("Expected %d calls, got %d", tt.expectedCalls, calls)
+			}
+			if len(retries) != tt.expectedCalls-1 {
+				t.Errorf("Expected %d OnRetry calls, got %d", tt.expectedCalls-1, len(retries))
+			}
+		})
+	}
+}
+
+func TestDoValue(t *testing.T) {
+	calls := 0
+	value, err := DoValue(context.Background(), Policy{InitialBackoff: time.Millisecond}, func(ctx context.Context) (string, error) {
+		calls++
+		if calls == 1 {
+			return "", errTransient
+		}
+		return "ok", nil
+	})
+	if err != nil || value != "ok" {
+		t.Fatalf("Expected value 'ok' without error, got '%s', %v", value, err)
+	}
+}
+
+func TestDoContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := Do(ctx, Policy{MaxAttempts: 10, InitialBackoff: time.Second}, func(ctx context.Context) error {
+		return errTransient
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, got: %v", err)
+	}
+}
+
+func TestDoRetryAfter(t *testing.T) {
+	var delays []time.Duration
+	calls := 0
+	p := Policy{
+		InitialBackoff: time.Millisecond,
+		OnRetry:        func(attempt int, err error, delay time.Duration) { delays = append(delays, delay) },
+	}
+
+	Do(context.Background(), p, func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			return WithRetryAfter(errTransient, 30*time.Millisecond)
+		}
+		return nil
+	})
+
+	if len(delays) != 1 || delays[0] != 30*time.Millisecond {
+		t.Errorf("Expected the Retry-After delay of 30ms, got %v", delays)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := Policy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, want := range expected {
+		if got := p.Backoff(i + 1); got != want {
+			t.Errorf("Attempt %d: expected backoff %v, got %v", i+1, want, got)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := p.Backoff(1); got < 50*time.Millisecond || got > 150*time.Millisecond {
+			t.Fatalf("Expected jittered backoff within 50ms-150ms, got %v", got)
+		}
+	}
+}
+
+func TestRetryableStatus(t *testing.T) {
+	for _, code := range []int{408, 429, 500, 502, 503, 504} {
+		if !RetryableStatus(code) {
+			t.Errorf("Expected status %d to be retryable", code)
+		}
+	}
+	for _, code := range []int{200, 400, 401, 403, 404} {
+		if RetryableStatus(code) {
+			t.Errorf("Expected status %d not to be retryable", code)
+		}
+	}
+}
+
+func TestDefaultClassifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected Decision
+	}{
+		{name: "Transient error", err: errTransient, expected: Retryable},
+		{name: "Permanent error", err: MarkPermanent(errTransient), expected: Permanent},
+		{name: "Context canceled", err: context.Canceled, expected: Permanent},
+		{name: "API error 503", err: &apierrors.GoogleAPIError{StatusCode: 503}, expected: Retryable},
+		{name: "API error 429", err: fmt.Errorf("list users: %w", &apierrors.GoogleAPIError{StatusCode: 429}), expected: Retryable},
+		{name: "API error 400", err: &apierrors.GoogleAPIError{StatusCode: 400}, expected: Permanent},
+		{name: "API error 403", err: &apierrors.GoogleAPIError{StatusCode: 403}, expected: Permanent},
+		{name: "API error 404", err: fmt.Errorf("get user: %w", &apierrors.GoogleAPIError{StatusCode: 404}), expected: Permanent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultClassifier(tt.err); got != tt.expected {
+				t.Errorf("Expected decision %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package googleclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+)
+
+// AuditRecord describes one mutating API request.
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+	// Method is the HTTP method: POST, PUT, PATCH, or DELETE.
+	Method string `json:"method"`
+	// Endpoint is the request URL without its query string.
+	Endpoint string `json:"endpoint"`
+	// Actor is who initiated the change: the actor set with WithAuditActor,
+	// or the delegated subject of the client.
+	Actor string `json:"actor"`
+	// Subject is the user the client impersonates.
+	Subject string `json:"subject"`
+	// PayloadHash is the hex SHA-256 of the request body, empty without a body.
+	PayloadHash string `json:"payload_hash,omitempty"`
+	// Status is the response status code, 0 when no response was received.
+	Status   int           `json:"status"`
+	Duration time.Duration `json:"duration"`
+	// Error is the transport error, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// AuditSink persists audit records, e.g. by streaming them into a BigQuery
+// table. Record is called synchronously after each mutating request, so slow
+// sinks should buffer.
+type AuditSink interface {
+	Record(ctx context.Context, record AuditRecord) error
+}
+
+// AuditSinkFunc adapts a function to AuditSink.
+type AuditSinkFunc func(ctx context.Context, record AuditRecord) error
+
+// Record calls f.
+func (f AuditSinkFunc) Record(ctx context.Context, record AuditRecord) error {
+	return f(ctx, record)
+}
+
+type auditActorKey struct{}
+
+// WithAuditActor returns a context whose mutating requests are attributed to
+// actor, e.g. the administrator on whose behalf an automation runs.
+func WithAuditActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// WithAuditTrail records every POST, PUT, PATCH, and DELETE request made by
+// the client to sink. A failing sink is logged as a warning and does not fail
+// the request.
+func WithAuditTrail(sink AuditSink) ClientOption {
+	return func(c *GoogleBaseServiceClient) {
+		c.Use(c.auditInterceptor(sink))
+	}
+}
+
+func (c *GoogleBaseServiceClient) auditInterceptor(sink AuditSink) Interceptor {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			switch req.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			default:
+				return next.RoundTrip(req)
+			}
+
+			ctx := req.Context()
+			record := AuditRecord{
+				Time:     time.Now(),
+				Method:   req.Method,
+				Endpoint: endpointOf(req),
+				Actor:    c.subject,
+				Subject:  c.subject,
+			}
+			if actor, ok := ctx.Value(auditActorKey{}).(string); ok && actor != "" {
+				record.Actor = actor
+			}
+
+			if req.Body != nil && req.Body != http.NoBody {
+				payload, err := io.ReadAll(req.Body)
+				req.Body.Close()
+				if err != nil {
+					return nil, err
+				}
+				sum := sha256.Sum256(payload)
+				record.PayloadHash = hex.EncodeToString(sum[:])
+				req = req.Clone(ctx)
+				req.Body = io.NopCloser(bytes.NewReader(payload))
+			}
+
+			resp, err := next.RoundTrip(req)
+			record.Duration = time.Since(record.Time)
+			if err != nil {
+				record.Error = err.Error()
+			} else {
+				record.Status = resp.StatusCode
+			}
+
+			if sinkErr := sink.Record(ctx, record); sinkErr != nil {
+				c.logger.LogWarning(ctx, "Error recording audit record", "method", record.Method, "endpoint", record.Endpoint, "error", sinkErr)
+			}
+			return resp, err
+		})
+	}
+}
+
+// endpointOf returns the request URL without query string or fragment.
+func endpointOf(req *http.Request) string {
+	u := *req.URL
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
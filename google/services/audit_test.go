@@ -0,0 +1,73 @@
+package googleclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditTrail(t *testing.T) {
+	var receivedBody string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		receivedBody = string(body)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	var records []AuditRecord
+	client := newTestClient(ts.URL)
+	client.subject = "admin@example.com"
+	WithAuditTrail(AuditSinkFunc(func(ctx context.Context, record AuditRecord) error {
+		records = append(records, record)
+		return nil
+	}))(client)
+
+	payload := []byte(`{"name": "group"}`)
+	_, err := client.makePostRequest(context.Background(), "groups?alt=json", map[string]string{"Content-Type": "application/json"}, payload)
+	assert.NoError(t, err)
+	assert.Equal(t, string(payload), receivedBody, "the body must still reach the server")
+
+	_, err = client.makeRequest(context.Background(), "groups", url.Values{})
+	assert.NoError(t, err)
+
+	_, err = client.makePostRequest(WithAuditActor(context.Background(), "operator@example.com"), "groups", nil, nil)
+	assert.NoError(t, err)
+
+	if assert.Len(t, records, 2, "GET requests are not audited") {
+		sum := sha256.Sum256(payload)
+		assert.Equal(t, "POST", records[0].Method)
+		assert.Equal(t, ts.URL+"/groups", records[0].Endpoint)
+		assert.Equal(t, "admin@example.com", records[0].Actor)
+		assert.Equal(t, "admin@example.com", records[0].Subject)
+		assert.Equal(t, hex.EncodeToString(sum[:]), records[0].PayloadHash)
+		assert.Equal(t, http.StatusOK, records[0].Status)
+
+		assert.Equal(t, "operator@example.com", records[1].Actor)
+		assert.Empty(t, records[1].PayloadHash)
+	}
+}
+
+func TestAuditTrailSinkError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	client := newTestClient(ts.URL)
+	WithAuditTrail(AuditSinkFunc(func(ctx context.Context, record AuditRecord) error {
+		return errors.New("sink unavailable")
+	}))(client)
+
+	_, err := client.makePostRequest(context.Background(), "groups", nil, []byte(`{}`))
+	assert.NoError(t, err, "a failing sink must not fail the request")
+}
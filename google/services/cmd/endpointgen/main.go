@@ -0,0 +1,45 @@
+// Command endpointgen generates typed client methods on top of
+// GoogleBaseServiceClient from a JSON service spec.
+//
+// Usage from a go:generate directive in package googleclient:
+//
+//	//go:generate go run github.com/duizendstra/go/google/services/cmd/endpointgen -spec directory.json -out directory_gen.go
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	googleclient "github.com/duizendstra/go/google/services"
+)
+
+func main() {
+	specPath := flag.String("spec", "", "path to the JSON service spec")
+	outPath := flag.String("out", "", "path of the generated Go file")
+	flag.Parse()
+
+	if *specPath == "" || *outPath == "" {
+		log.Fatal("both -spec and -out must be provided")
+	}
+
+	data, err := os.ReadFile(*specPath)
+	if err != nil {
+		log.Fatalf("error reading spec: %v", err)
+	}
+
+	var spec googleclient.ServiceSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		log.Fatalf("error decoding spec %s: %v", *specPath, err)
+	}
+
+	src, err := googleclient.GenerateEndpoints(spec)
+	if err != nil {
+		log.Fatalf("error generating %s: %v", *outPath, err)
+	}
+
+	if err := os.WriteFile(*outPath, src, 0o644); err != nil {
+		log.Fatalf("error writing %s: %v", *outPath, err)
+	}
+}
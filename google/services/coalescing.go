@@ -0,0 +1,50 @@
+package googleclient
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// WithRequestCoalescing makes concurrent identical GET requests (same URL and
+// parameters) share a single upstream call, preventing thundering herds when
+// many requests need the same lookup at once.
+func WithRequestCoalescing() ClientOption {
+	return func(c *GoogleBaseServiceClient) {
+		c.getGroup = &singleflight.Group{}
+	}
+}
+
+// coalescedCallTimeout bounds a shared GET, which does not stop when the
+// caller that started it gives up.
+const coalescedCallTimeout = 30 * time.Second
+
+// coalescedGet joins an in-flight GET for reqURL or starts one. The shared
+// call keeps the values of the context of the caller that started it, but
+// not its cancellation or deadline, so one caller giving up does not fail the
+// others; it is bounded by coalescedCallTimeout instead. Every caller still
+// stops waiting when its own context is done.
+func (c *GoogleBaseServiceClient) coalescedGet(ctx context.Context, reqURL string) ([]byte, error) {
+	ch := c.getGroup.DoChan(c.cacheKey(ctx, reqURL), func() (interface{}, error) {
+		callCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), coalescedCallTimeout)
+		defer cancel()
+		return c.get(callCtx, reqURL)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		body := res.Val.([]byte)
+		if res.Shared {
+			// Each caller gets its own copy so callers cannot affect each other.
+			body = bytes.Clone(body)
+		}
+		return body, nil
+	}
+}
@@ -0,0 +1,116 @@
+package googleclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestCoalescing(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "success"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(ts.URL)
+	WithRequestCoalescing()(client)
+
+	params := url.Values{}
+	params.Add("key", "value")
+
+	var wg sync.WaitGroup
+	results := make([][]byte, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body, err := client.makeRequest(context.Background(), "test-endpoint", params)
+			assert.NoError(t, err)
+			results[i] = body
+		}(i)
+	}
+
+	// Give the goroutines time to join the in-flight request
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for _, body := range results {
+		assert.Equal(t, `{"message": "success"}`, string(body))
+	}
+
+	// Requests with different parameters are not coalesced
+	params.Set("key", "other")
+	_, err := client.makeRequest(context.Background(), "test-endpoint", params)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestRequestCoalescingContextCancelled(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	client := newTestClient(ts.URL)
+	WithRequestCoalescing()(client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := client.makeRequest(ctx, "test-endpoint", url.Values{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRequestCoalescingFirstCallerCancelled(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "success"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(ts.URL)
+	WithRequestCoalescing()(client)
+
+	firstCtx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := client.makeRequest(firstCtx, "test-endpoint", url.Values{})
+		firstErr <- err
+	}()
+	<-started
+
+	second := make(chan []byte, 1)
+	go func() {
+		body, err := client.makeRequest(context.Background(), "test-endpoint", url.Values{})
+		assert.NoError(t, err)
+		second <- body
+	}()
+	// Give the second caller time to join the in-flight request
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+	close(release)
+	assert.Equal(t, `{"message": "success"}`, string(<-second))
+}
@@ -0,0 +1,265 @@
+package googleclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/duizendstra/go/google/retry"
+)
+
+// ErrChecksumMismatch is returned when a downloaded file does not match the
+// size or checksum announced by the server.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// DownloadOption configures DownloadToFile.
+type DownloadOption func(*download)
+
+// WithDownloadRetry sets the policy for retrying failed and interrupted
+// downloads. Defaults to the zero retry.Policy.
+func WithDownloadRetry(policy retry.Policy) DownloadOption {
+	return func(d *download) {
+		d.policy = policy
+	}
+}
+
+// WithProgress calls fn after every chunk written to disk with the number of
+// bytes written so far and the total size, or -1 when it is unknown.
+func WithProgress(fn func(written, total int64)) DownloadOption {
+	return func(d *download) {
+		d.progress = fn
+	}
+}
+
+// download is the state of a download that survives retries.
+type download struct {
+	policy   retry.Policy
+	progress func(written, total int64)
+
+	file    *os.File
+	written int64
+	total   int64
+	md5     hash.Hash
+	crc32c  hash.Hash32
+
+	wantMD5    []byte
+	wantCRC32C []byte
+}
+
+// DownloadToFile streams the response of a GET to endpoint into the file at
+// path and returns the number of bytes written. A download interrupted by a
+// transport error or a retryable status resumes with a ranged request from
+// the last byte written. The size is verified against Content-Length, and the
+// content against the MD5 and CRC32C checksums of an x-goog-hash header, when
+// the server provides them; a mismatch returns ErrChecksumMismatch.
+//
+// The data is written to path + ".part" and renamed to path once verified,
+// so path never holds a partial download.
+func (c *GoogleBaseServiceClient) DownloadToFile(ctx context.Context, endpoint string, params url.Values, path string, opts ...DownloadOption) (int64, error) {
+	reqURL := fmt.Sprintf("%s/%s", c.baseEndpoint, endpoint)
+	if len(params) > 0 {
+		reqURL += "?" + params.Encode()
+	}
+
+	d := &download{total: -1, md5: md5.New(), crc32c: crc32.New(crc32cTable)}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	partPath := path + ".part"
+	file, err := os.Create(partPath)
+	if err != nil {
+		return 0, fmt.Errorf("error creating %s: %w", filepath.Base(partPath), err)
+	}
+	d.file = file
+	defer func() {
+		if d.file != nil {
+			d.file.Close()
+			os.Remove(partPath)
+		}
+	}()
+
+	err = retry.Do(ctx, d.policy, func(ctx context.Context) error {
+		return c.downloadAttempt(ctx, reqURL, d)
+	})
+	if err != nil {
+		return d.written, err
+	}
+	if err := d.verify(); err != nil {
+		return d.written, err
+	}
+
+	if err := d.file.Close(); err != nil {
+		return d.written, fmt.Errorf("error closing %s: %w", filepath.Base(partPath), err)
+	}
+	d.file = nil
+	if err := os.Rename(partPath, path); err != nil {
+		os.Remove(partPath)
+		return d.written, fmt.Errorf("error renaming download: %w", err)
+	}
+	return d.written, nil
+}
+
+// downloadAttempt requests the remainder of the download and appends it to
+// the file. Errors reading the response are retryable; errors writing the
+// file are permanent.
+func (c *GoogleBaseServiceClient) downloadAttempt(ctx context.Context, reqURL string, d *download) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return retry.MarkPermanent(fmt.Errorf("error creating download request: %w", err))
+	}
+	if d.written > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", d.written))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making API call: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		// The server ignored the range or this is the first attempt.
+		if d.written > 0 {
+			if err := d.reset(); err != nil {
+				return retry.MarkPermanent(err)
+			}
+		}
+		d.total = resp.ContentLength
+	case resp.StatusCode == http.StatusPartialContent && d.written > 0:
+		start, total, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != d.written {
+			return retry.MarkPermanent(fmt.Errorf("unexpected Content-Range %q resuming at byte %d", resp.Header.Get("Content-Range"), d.written))
+		}
+		d.total = total
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		apiErr := newAPIError(resp.StatusCode, body)
+		if !retry.RetryableStatus(resp.StatusCode) {
+			return retry.MarkPermanent(apiErr)
+		}
+		return apiErr
+	}
+	d.expectHashes(resp.Header.Values("X-Goog-Hash"))
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, err := d.file.Write(buf[:n]); err != nil {
+				return retry.MarkPermanent(fmt.Errorf("error writing download: %w", err))
+			}
+			d.md5.Write(buf[:n])
+			d.crc32c.Write(buf[:n])
+			d.written += int64(n)
+			if d.progress != nil {
+				d.progress(d.written, d.total)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("error reading download at byte %d: %w", d.written, readErr)
+		}
+	}
+	if d.total >= 0 && d.written < d.total {
+		return fmt.Errorf("download interrupted at byte %d of %d", d.written, d.total)
+	}
+	return nil
+}
+
+// reset discards what was written before a full response restarts the download.
+func (d *download) reset() error {
+	if err := d.file.Truncate(0); err != nil {
+		return fmt.Errorf("error truncating download: %w", err)
+	}
+	if _, err := d.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("error truncating download: %w", err)
+	}
+	d.written = 0
+	d.md5.Reset()
+	d.crc32c.Reset()
+	return nil
+}
+
+// expectHashes records the checksums of an x-goog-hash header, e.g.
+// "crc32c=n03x6A==,md5=Ojk9c3dhfxgoKVVHYwFbHQ==". They describe the whole
+// object, also in ranged responses.
+func (d *download) expectHashes(headers []string) {
+	for _, header := range headers {
+		for _, part := range strings.Split(header, ",") {
+			name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+			if !ok {
+				continue
+			}
+			sum, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				continue
+			}
+			switch name {
+			case "md5":
+				d.wantMD5 = sum
+			case "crc32c":
+				d.wantCRC32C = sum
+			}
+		}
+	}
+}
+
+// verify checks the written file against the announced size and checksums.
+func (d *download) verify() error {
+	if d.total >= 0 && d.written != d.total {
+		return fmt.Errorf("%w: got %d bytes, want %d", ErrChecksumMismatch, d.written, d.total)
+	}
+	if d.wantMD5 != nil && !bytes.Equal(d.md5.Sum(nil), d.wantMD5) {
+		return fmt.Errorf("%w: MD5 does not match", ErrChecksumMismatch)
+	}
+	if d.wantCRC32C != nil && !bytes.Equal(d.crc32c.Sum(nil), d.wantCRC32C) {
+		return fmt.Errorf("%w: CRC32C does not match", ErrChecksumMismatch)
+	}
+	return nil
+}
+
+// parseContentRange parses "bytes start-end/total"; total is -1 when it is "*".
+func parseContentRange(header string) (start, total int64, ok bool) {
+	rangeSpec, found := strings.CutPrefix(header, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	byteRange, size, found := strings.Cut(rangeSpec, "/")
+	if !found {
+		return 0, 0, false
+	}
+	first, _, found := strings.Cut(byteRange, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if size == "*" {
+		return start, -1, true
+	}
+	total, err = strconv.ParseInt(size, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, total, true
+}
@@ -0,0 +1,97 @@
+package googleclient
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	apierrors "github.com/duizendstra/go/google/errors"
+	"github.com/duizendstra/go/google/retry"
+	"github.com/stretchr/testify/assert"
+)
+
+func googHash(content []byte) string {
+	md5Sum := md5.Sum(content)
+	crc := make([]byte, 4)
+	binary.BigEndian.PutUint32(crc, crc32.Checksum(content, crc32.MakeTable(crc32.Castagnoli)))
+	return "crc32c=" + base64.StdEncoding.EncodeToString(crc) + ",md5=" + base64.StdEncoding.EncodeToString(md5Sum[:])
+}
+
+func TestDownloadToFileResumes(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 1000))
+	var ranges []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/files/report.csv", r.URL.Path)
+		assert.Equal(t, "media", r.URL.Query().Get("alt"))
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("X-Goog-Hash", googHash(content))
+		if len(ranges) == 1 {
+			// Announce the full size but drop the connection halfway.
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.Write(content[:4000])
+			return
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes 4000-%d/%d", len(content)-1, len(content)))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(content[4000:])
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "report.csv")
+	var lastWritten, lastTotal int64
+	n, err := newTestClient(ts.URL).DownloadToFile(context.Background(), "files/report.csv", map[string][]string{"alt": {"media"}}, path,
+		WithDownloadRetry(retry.Policy{InitialBackoff: time.Millisecond}),
+		WithProgress(func(written, total int64) { lastWritten, lastTotal = written, total }))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), n)
+	assert.Equal(t, []string{"", "bytes=4000-"}, ranges)
+	assert.Equal(t, int64(len(content)), lastWritten)
+	assert.Equal(t, int64(len(content)), lastTotal)
+
+	got, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, content, got)
+}
+
+func TestDownloadToFileChecksumMismatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Goog-Hash", googHash([]byte("expected content")))
+		w.Write([]byte("corrupted content"))
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "report.csv")
+	_, err := newTestClient(ts.URL).DownloadToFile(context.Background(), "files/report.csv", nil, path)
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+	_, statErr := os.Stat(path)
+	assert.True(t, os.IsNotExist(statErr))
+	_, statErr = os.Stat(path + ".part")
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+func TestDownloadToFileNotFound(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":{"code":404,"message":"File not found","status":"NOT_FOUND"}}`))
+	}))
+	defer ts.Close()
+
+	_, err := newTestClient(ts.URL).DownloadToFile(context.Background(), "files/missing", nil, filepath.Join(t.TempDir(), "missing"))
+	var apiErr *apierrors.GoogleAPIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "NOT_FOUND", apiErr.ErrorCode)
+	assert.Equal(t, 1, calls)
+}
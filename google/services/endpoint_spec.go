@@ -0,0 +1,319 @@
+package googleclient
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// ServiceSpec declares a typed client built on top of GoogleBaseServiceClient.
+type ServiceSpec struct {
+	// Package is the package of the generated file. The generated methods use
+	// unexported helpers of GoogleBaseServiceClient, so it must be empty or
+	// "googleclient".
+	Package string `json:"package"`
+	// Client is the name of the generated client type, e.g. "DirectoryClient".
+	Client    string         `json:"client"`
+	Endpoints []EndpointSpec `json:"endpoints"`
+}
+
+// EndpointSpec declares a single API method.
+type EndpointSpec struct {
+	// Name is the Go method name, e.g. "ListUsers".
+	Name string `json:"name"`
+	// Method is the HTTP method, GET or POST.
+	Method string `json:"method"`
+	// Path is the path template relative to the base endpoint, e.g. "users/{userKey}".
+	Path string `json:"path"`
+	// Query lists the query parameters, generated as a <Name>Params struct.
+	Query []ParamSpec `json:"query,omitempty"`
+	// Body is the Go type of the JSON request body for POST endpoints.
+	Body string `json:"body,omitempty"`
+	// Response is the Go type the JSON response is decoded into. Empty means no response body.
+	Response string `json:"response,omitempty"`
+	// Pagination generates a <Name>All method that follows page tokens.
+	Pagination *PaginationSpec `json:"pagination,omitempty"`
+}
+
+// ParamSpec declares a query parameter. Type is one of string, int, or bool.
+type ParamSpec struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// PaginationSpec describes how a list endpoint pages through its results.
+type PaginationSpec struct {
+	// PageTokenParam is the query parameter carrying the page token, e.g. "pageToken".
+	PageTokenParam string `json:"pageTokenParam"`
+	// NextPageTokenField is the field of the response holding the next token, e.g. "NextPageToken".
+	NextPageTokenField string `json:"nextPageTokenField"`
+	// ItemsField is the field of the response holding the page items, e.g. "Users".
+	ItemsField string `json:"itemsField"`
+	// ItemType is the Go type of a single item, e.g. "User".
+	ItemType string `json:"itemType"`
+}
+
+var rePathParam = regexp.MustCompile(`\{(\w+)\}`)
+
+// ExpandPath substitutes the {name} segments of a path template with the
+// path-escaped values from params.
+func ExpandPath(template string, params map[string]string) (string, error) {
+	var missing []string
+	path := rePathParam.ReplaceAllStringFunc(template, func(m string) string {
+		name := m[1 : len(m)-1]
+		value, ok := params[name]
+		if !ok || value == "" {
+			missing = append(missing, name)
+			return m
+		}
+		return url.PathEscape(value)
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("missing path parameters for %s: %s", template, strings.Join(missing, ", "))
+	}
+	return path, nil
+}
+
+// reservedParamNames would collide with the receiver, arguments, locals, and
+// imported packages of the generated methods.
+var reservedParamNames = map[string]bool{
+	"c": true, "ctx": true, "params": true, "body": true,
+	"path": true, "err": true, "data": true, "payload": true, "query": true, "resp": true, "items": true,
+	"context": true, "json": true, "fmt": true, "url": true, "strconv": true,
+}
+
+// GenerateEndpoints renders the Go source of the typed client described by spec.
+func GenerateEndpoints(spec ServiceSpec) ([]byte, error) {
+	if spec.Package == "" {
+		spec.Package = "googleclient"
+	}
+	if spec.Package != "googleclient" {
+		return nil, fmt.Errorf("service spec package %q is not supported: generated clients must be in package googleclient", spec.Package)
+	}
+	if spec.Client == "" {
+		return nil, fmt.Errorf("service spec must declare a client name")
+	}
+
+	data := genService{Package: spec.Package, Client: spec.Client}
+	for _, e := range spec.Endpoints {
+		ge, err := newGenEndpoint(e)
+		if err != nil {
+			return nil, err
+		}
+		for _, q := range ge.Query {
+			if q.Type != "string" {
+				data.NeedsStrconv = true
+			}
+		}
+		if ge.Method == "GET" || len(ge.Query) > 0 {
+			data.NeedsURL = true
+		}
+		if ge.Body != "" {
+			data.NeedsJSON = true
+		}
+		if ge.Response != "" || ge.Body != "" {
+			data.NeedsFmt = true
+		}
+		data.Endpoints = append(data.Endpoints, ge)
+	}
+
+	var buf bytes.Buffer
+	if err := serviceTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("error rendering %s: %w", spec.Client, err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("error formatting %s: %w", spec.Client, err)
+	}
+	return src, nil
+}
+
+type genService struct {
+	Package      string
+	Client       string
+	NeedsJSON    bool
+	NeedsFmt     bool
+	NeedsURL     bool
+	NeedsStrconv bool
+	Endpoints    []genEndpoint
+}
+
+type genEndpoint struct {
+	EndpointSpec
+	PathParams []string
+	Query      []genParam
+}
+
+type genParam struct {
+	Name  string
+	Field string
+	Type  string
+}
+
+func newGenEndpoint(e EndpointSpec) (genEndpoint, error) {
+	ge := genEndpoint{EndpointSpec: e}
+	ge.Method = strings.ToUpper(e.Method)
+
+	if e.Name == "" || e.Path == "" {
+		return ge, fmt.Errorf("endpoint %q must declare a name and a path", e.Name)
+	}
+	if ge.Method != "GET" && ge.Method != "POST" {
+		return ge, fmt.Errorf("endpoint %s: unsupported method %q", e.Name, e.Method)
+	}
+	if ge.Method == "GET" && e.Body != "" {
+		return ge, fmt.Errorf("endpoint %s: GET endpoints cannot declare a body", e.Name)
+	}
+
+	for _, m := range rePathParam.FindAllStringSubmatch(e.Path, -1) {
+		if reservedParamNames[m[1]] || token.IsKeyword(m[1]) {
+			return ge, fmt.Errorf("endpoint %s: path parameter name %q is reserved", e.Name, m[1])
+		}
+		ge.PathParams = append(ge.PathParams, m[1])
+	}
+
+	for _, q := range e.Query {
+		switch q.Type {
+		case "string", "int", "bool":
+		default:
+			return ge, fmt.Errorf("endpoint %s: query parameter %s has unsupported type %q", e.Name, q.Name, q.Type)
+		}
+		ge.Query = append(ge.Query, genParam{Name: q.Name, Field: exportName(q.Name), Type: q.Type})
+	}
+
+	if p := e.Pagination; p != nil {
+		if ge.Method != "GET" || e.Response == "" {
+			return ge, fmt.Errorf("endpoint %s: pagination requires a GET endpoint with a response", e.Name)
+		}
+		if p.PageTokenParam == "" || p.NextPageTokenField == "" || p.ItemsField == "" || p.ItemType == "" {
+			return ge, fmt.Errorf("endpoint %s: incomplete pagination config", e.Name)
+		}
+		ge.Query = append(ge.Query, genParam{Name: p.PageTokenParam, Field: exportName(p.PageTokenParam), Type: "string"})
+	}
+	return ge, nil
+}
+
+// exportName upper-cases the first letter of a parameter name.
+func exportName(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+var serviceTemplate = template.Must(template.New("service").Funcs(template.FuncMap{"exportName": exportName}).Parse(`// Code generated by endpointgen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	{{- if .NeedsJSON}}
+	"encoding/json"
+	{{- end}}
+	{{- if .NeedsFmt}}
+	"fmt"
+	{{- end}}
+	{{- if .NeedsURL}}
+	"net/url"
+	{{- end}}
+	{{- if .NeedsStrconv}}
+	"strconv"
+	{{- end}}
+)
+
+// {{.Client}} is a typed client generated from a service spec.
+type {{.Client}} struct {
+	*GoogleBaseServiceClient
+}
+
+// New{{.Client}} wraps a base client.
+func New{{.Client}}(base *GoogleBaseServiceClient) *{{.Client}} {
+	return &{{.Client}}{GoogleBaseServiceClient: base}
+}
+{{range $e := .Endpoints}}
+{{- if $e.Query}}
+// {{$e.Name}}Params holds the query parameters of {{$e.Name}}.
+type {{$e.Name}}Params struct {
+{{- range $e.Query}}
+	{{.Field}} {{.Type}}
+{{- end}}
+}
+
+func (p {{$e.Name}}Params) values() url.Values {
+	v := url.Values{}
+{{- range $e.Query}}
+{{- if eq .Type "string"}}
+	if p.{{.Field}} != "" {
+		v.Set("{{.Name}}", p.{{.Field}})
+	}
+{{- else if eq .Type "int"}}
+	if p.{{.Field}} != 0 {
+		v.Set("{{.Name}}", strconv.Itoa(p.{{.Field}}))
+	}
+{{- else}}
+	if p.{{.Field}} {
+		v.Set("{{.Name}}", strconv.FormatBool(p.{{.Field}}))
+	}
+{{- end}}
+{{- end}}
+	return v
+}
+{{end}}
+// {{$e.Name}} calls {{$e.Method}} {{$e.Path}}.
+func (c *{{$.Client}}) {{$e.Name}}(ctx context.Context{{range $e.PathParams}}, {{.}} string{{end}}{{if $e.Query}}, params {{$e.Name}}Params{{end}}{{if $e.Body}}, body *{{$e.Body}}{{end}}) ({{if $e.Response}}*{{$e.Response}}, {{end}}error) {
+	path, err := ExpandPath("{{$e.Path}}", map[string]string{ {{- range $e.PathParams}}"{{.}}": {{.}}, {{end -}} })
+	if err != nil {
+		return {{if $e.Response}}nil, {{end}}err
+	}
+{{- if eq $e.Method "GET"}}
+	{{if $e.Response}}data, err :={{else}}_, err ={{end}} c.makeRequest(ctx, path, {{if $e.Query}}params.values(){{else}}url.Values{}{{end}})
+{{- else}}
+{{- if $e.Query}}
+	if query := params.values(); len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+{{- end}}
+	var payload []byte
+{{- if $e.Body}}
+	payload, err = json.Marshal(body)
+	if err != nil {
+		return {{if $e.Response}}nil, {{end}}fmt.Errorf("error encoding {{$e.Name}} request: %w", err)
+	}
+{{- end}}
+	{{if $e.Response}}data, err :={{else}}_, err ={{end}} c.makePostRequest(ctx, path, map[string]string{"Content-Type": "application/json"}, payload)
+{{- end}}
+	if err != nil {
+		return {{if $e.Response}}nil, {{end}}err
+	}
+{{- if $e.Response}}
+	var resp {{$e.Response}}
+	if err := c.DecodeResponse(data, &resp); err != nil {
+		return nil, fmt.Errorf("error decoding {{$e.Name}} response: %w", err)
+	}
+	return &resp, nil
+{{- else}}
+	return nil
+{{- end}}
+}
+{{if $e.Pagination}}
+// {{$e.Name}}All calls {{$e.Name}} until all pages are read and returns the collected items.
+func (c *{{$.Client}}) {{$e.Name}}All(ctx context.Context{{range $e.PathParams}}, {{.}} string{{end}}, params {{$e.Name}}Params) ([]{{$e.Pagination.ItemType}}, error) {
+	var items []{{$e.Pagination.ItemType}}
+	for {
+		resp, err := c.{{$e.Name}}(ctx{{range $e.PathParams}}, {{.}}{{end}}, params)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, resp.{{$e.Pagination.ItemsField}}...)
+		if resp.{{$e.Pagination.NextPageTokenField}} == "" {
+			return items, nil
+		}
+		params.{{exportName $e.Pagination.PageTokenParam}} = resp.{{$e.Pagination.NextPageTokenField}}
+	}
+}
+{{end}}
+{{- end}}`))
@@ -0,0 +1,105 @@
+package googleclient
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandPath(t *testing.T) {
+	path, err := ExpandPath("users/{userKey}/aliases", map[string]string{"userKey": "jane doe@example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, "users/jane%20doe@example.com/aliases", path)
+
+	_, err = ExpandPath("users/{userKey}", map[string]string{})
+	assert.EqualError(t, err, "missing path parameters for users/{userKey}: userKey")
+}
+
+func TestGenerateEndpoints(t *testing.T) {
+	spec := ServiceSpec{
+		Client: "DirectoryClient",
+		Endpoints: []EndpointSpec{
+			{
+				Name:     "ListUsers",
+				Method:   "GET",
+				Path:     "customer/{customer}/users",
+				Query:    []ParamSpec{{Name: "maxResults", Type: "int"}, {Name: "showDeleted", Type: "bool"}},
+				Response: "UserList",
+				Pagination: &PaginationSpec{
+					PageTokenParam:     "pageToken",
+					NextPageTokenField: "NextPageToken",
+					ItemsField:         "Users",
+					ItemType:           "User",
+				},
+			},
+			{
+				Name:     "InsertUser",
+				Method:   "POST",
+				Path:     "users",
+				Body:     "User",
+				Response: "User",
+			},
+		},
+	}
+
+	src, err := GenerateEndpoints(spec)
+	assert.NoError(t, err)
+
+	code := string(src)
+	assert.True(t, strings.HasPrefix(code, "// Code generated by endpointgen. DO NOT EDIT."))
+	assert.Contains(t, code, "func (c *DirectoryClient) ListUsers(ctx context.Context, customer string, params ListUsersParams) (*UserList, error)")
+	assert.Contains(t, code, "func (c *DirectoryClient) ListUsersAll(ctx context.Context, customer string, params ListUsersParams) ([]User, error)")
+	assert.Contains(t, code, "func (c *DirectoryClient) InsertUser(ctx context.Context, body *User) (*User, error)")
+	assert.Contains(t, code, `v.Set("maxResults", strconv.Itoa(p.MaxResults))`)
+	assert.Contains(t, code, `v.Set("pageToken", p.PageToken)`)
+}
+
+func TestGenerateEndpointsValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		endpoint    EndpointSpec
+		expectedErr string
+	}{
+		{
+			name:        "Unsupported method",
+			endpoint:    EndpointSpec{Name: "DeleteUser", Method: "DELETE", Path: "users/{userKey}"},
+			expectedErr: `endpoint DeleteUser: unsupported method "DELETE"`,
+		},
+		{
+			name:        "Pagination on POST",
+			endpoint:    EndpointSpec{Name: "Insert", Method: "POST", Path: "users", Response: "User", Pagination: &PaginationSpec{}},
+			expectedErr: "endpoint Insert: pagination requires a GET endpoint with a response",
+		},
+		{
+			name:        "Reserved path parameter",
+			endpoint:    EndpointSpec{Name: "Get", Method: "GET", Path: "users/{ctx}"},
+			expectedErr: `endpoint Get: path parameter name "ctx" is reserved`,
+		},
+		{
+			name:        "Path parameter shadowing a generated local",
+			endpoint:    EndpointSpec{Name: "Get", Method: "GET", Path: "users/{data}/aliases/{err}"},
+			expectedErr: `endpoint Get: path parameter name "data" is reserved`,
+		},
+		{
+			name:        "Path parameter named like a keyword",
+			endpoint:    EndpointSpec{Name: "Get", Method: "GET", Path: "types/{type}"},
+			expectedErr: `endpoint Get: path parameter name "type" is reserved`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := GenerateEndpoints(ServiceSpec{Client: "TestClient", Endpoints: []EndpointSpec{tt.endpoint}})
+			assert.EqualError(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestGenerateEndpointsPackage(t *testing.T) {
+	_, err := GenerateEndpoints(ServiceSpec{Package: "directory", Client: "DirectoryClient"})
+	assert.EqualError(t, err, `service spec package "directory" is not supported: generated clients must be in package googleclient`)
+
+	_, err = GenerateEndpoints(ServiceSpec{Package: "googleclient", Client: "DirectoryClient"})
+	assert.NoError(t, err)
+}
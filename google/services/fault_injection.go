@@ -0,0 +1,147 @@
+package googleclient
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// FaultConfig configures the FaultInjection interceptor. Rates are fractions
+// between 0 and 1 of the requests that are affected.
+type FaultConfig struct {
+	// Seed makes the injected faults reproducible.
+	Seed int64
+
+	// Latency is added to the fraction LatencyRate of requests.
+	Latency     time.Duration
+	LatencyRate float64
+
+	// ErrorRate is the fraction of requests answered with a synthetic error
+	// response instead of reaching the API.
+	ErrorRate float64
+	// ErrorStatuses are the status codes picked from for synthetic errors.
+	// Defaults to 429 and 500.
+	ErrorStatuses []int
+
+	// ResetRate is the fraction of requests failing with a connection reset.
+	ResetRate float64
+}
+
+// FaultInjection returns an interceptor that injects latency, error responses,
+// and connection resets for resilience testing without touching production APIs.
+func FaultInjection(cfg FaultConfig) Interceptor {
+	statuses := cfg.ErrorStatuses
+	if len(statuses) == 0 {
+		statuses = []int{http.StatusTooManyRequests, http.StatusInternalServerError}
+	}
+
+	var mu sync.Mutex
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	roll := func(rate float64) bool {
+		if rate <= 0 {
+			return false
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return rng.Float64() < rate
+	}
+	pick := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return statuses[rng.Intn(len(statuses))]
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if cfg.Latency > 0 && roll(cfg.LatencyRate) {
+				timer := time.NewTimer(cfg.Latency)
+				select {
+				case <-timer.C:
+				case <-req.Context().Done():
+					timer.Stop()
+					closeRequestBody(req)
+					return nil, req.Context().Err()
+				}
+			}
+
+			if roll(cfg.ResetRate) {
+				closeRequestBody(req)
+				return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+			}
+
+			if roll(cfg.ErrorRate) {
+				closeRequestBody(req)
+				return faultResponse(req, pick()), nil
+			}
+
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// closeRequestBody closes the body of a request that is not sent, as an
+// http.RoundTripper must even when it returns an error.
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+// faultResponse builds a synthetic Google-style error response.
+func faultResponse(req *http.Request, status int) *http.Response {
+	body := fmt.Sprintf(`{"error":{"code":%d,"message":"injected fault","status":%q}}`, status, googleStatus(status))
+	header := http.Header{"Content-Type": {"application/json"}}
+	if status == http.StatusTooManyRequests {
+		header.Set("Retry-After", "1")
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// googleStatus returns the canonical status name the Google APIs report with
+// an HTTP status, the reverse of the mapping in the errors module.
+func googleStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "INVALID_ARGUMENT"
+	case http.StatusUnauthorized:
+		return "UNAUTHENTICATED"
+	case http.StatusForbidden:
+		return "PERMISSION_DENIED"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusConflict:
+		return "ABORTED"
+	case http.StatusTooManyRequests:
+		return "RESOURCE_EXHAUSTED"
+	case 499:
+		return "CANCELLED"
+	case http.StatusInternalServerError:
+		return "INTERNAL"
+	case http.StatusNotImplemented:
+		return "UNIMPLEMENTED"
+	case http.StatusServiceUnavailable:
+		return "UNAVAILABLE"
+	case http.StatusGatewayTimeout:
+		return "DEADLINE_EXCEEDED"
+	default:
+		return "UNKNOWN"
+	}
+}
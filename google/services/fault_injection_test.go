@@ -0,0 +1,161 @@
+package googleclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	logger "github.com/duizendstra/go/google/logging"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+// newTestClient returns a client with a mocked token source pointed at baseEndpoint.
+func newTestClient(baseEndpoint string) *GoogleBaseServiceClient {
+	return &GoogleBaseServiceClient{
+		httpClient: &http.Client{
+			Transport: &oauth2.Transport{
+				Source: &MockTokenSource{},
+			},
+		},
+		baseEndpoint: baseEndpoint,
+		logger:       logger.NewStructuredLogger("test-project", "test-component", nil, nil),
+	}
+}
+
+func TestFaultInjection(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "success"}`))
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name          string
+		cfg           FaultConfig
+		expectedErr   string
+		expectedCalls int
+	}{
+		{
+			name:          "No faults",
+			cfg:           FaultConfig{},
+			expectedCalls: 1,
+		},
+		{
+			name:          "Injected 429",
+			cfg:           FaultConfig{ErrorRate: 1, ErrorStatuses: []int{http.StatusTooManyRequests}},
+			expectedErr:   `API request failed with status 429: {"error":{"code":429,"message":"injected fault","status":"RESOURCE_EXHAUSTED"}}`,
+			expectedCalls: 0,
+		},
+		{
+			name:          "Injected 503",
+			cfg:           FaultConfig{ErrorRate: 1, ErrorStatuses: []int{http.StatusServiceUnavailable}},
+			expectedErr:   `"status":"UNAVAILABLE"`,
+			expectedCalls: 0,
+		},
+		{
+			name:          "Connection reset",
+			cfg:           FaultConfig{ResetRate: 1},
+			expectedErr:   "error making API call",
+			expectedCalls: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = 0
+			client := newTestClient(ts.URL)
+			client.Use(FaultInjection(tt.cfg))
+
+			_, err := client.makeRequest(context.Background(), "test-endpoint", url.Values{})
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.expectedErr)
+			}
+			assert.Equal(t, tt.expectedCalls, calls)
+		})
+	}
+}
+
+func TestFaultInjectionConnectionReset(t *testing.T) {
+	rt := FaultInjection(FaultConfig{ResetRate: 1})(http.DefaultTransport)
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+
+	_, err := rt.RoundTrip(req)
+	assert.True(t, errors.Is(err, syscall.ECONNRESET))
+}
+
+// closeTracker records whether a request body was closed.
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (b *closeTracker) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestFaultInjectionClosesRequestBody(t *testing.T) {
+	for name, cfg := range map[string]FaultConfig{
+		"Error": {ErrorRate: 1},
+		"Reset": {ResetRate: 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			body := &closeTracker{Reader: strings.NewReader(`{"name": "a"}`)}
+			rt := FaultInjection(cfg)(http.DefaultTransport)
+			resp, _ := rt.RoundTrip(httptest.NewRequest("POST", "http://example.com", body))
+			if resp != nil {
+				resp.Body.Close()
+			}
+			assert.True(t, body.closed, "the request body is closed when the request is not sent")
+		})
+	}
+}
+
+func TestFaultInjectionLatency(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	client := newTestClient(ts.URL)
+	client.Use(FaultInjection(FaultConfig{Latency: 50 * time.Millisecond, LatencyRate: 1}))
+
+	start := time.Now()
+	_, err := client.makeRequest(context.Background(), "test-endpoint", url.Values{})
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// The injected delay honours context cancellation
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = client.makeRequest(ctx, "test-endpoint", url.Values{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestFaultInjectionSeed(t *testing.T) {
+	outcomes := func() []int {
+		rt := FaultInjection(FaultConfig{Seed: 42, ErrorRate: 0.5})(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		}))
+		var statuses []int
+		for i := 0; i < 20; i++ {
+			resp, _ := rt.RoundTrip(httptest.NewRequest("GET", "http://example.com", nil))
+			statuses = append(statuses, resp.StatusCode)
+		}
+		return statuses
+	}
+
+	assert.Equal(t, outcomes(), outcomes())
+}
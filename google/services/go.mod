@@ -6,8 +6,11 @@ require (
 	github.com/duizendstra/go/google/auth v0.0.1
 	github.com/duizendstra/go/google/errors v0.0.1
 	github.com/duizendstra/go/google/logging v0.0.3
+	github.com/duizendstra/go/google/retry v0.0.0-00010101000000-000000000000
 	github.com/stretchr/testify v1.9.0
 	golang.org/x/oauth2 v0.23.0
+	golang.org/x/sync v0.8.0
+	golang.org/x/time v0.6.0
 )
 
 require (
@@ -39,3 +42,9 @@ require (
 	google.golang.org/protobuf v1.34.2 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace github.com/duizendstra/go/google/retry => ../retry
+
+replace github.com/duizendstra/go/google/logging => ../logging
+
+replace github.com/duizendstra/go/google/auth => ../auth
@@ -3,6 +3,7 @@ package googleclient
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,25 +14,49 @@ import (
 	"github.com/duizendstra/go/google/errors"
 	"github.com/duizendstra/go/google/logging"
 	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
 )
 
-type APIError struct {
-	StatusCode int
-	Body       string
-}
-
-func (e *APIError) Error() string {
-	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Body)
+// APIError is the error returned for non-2xx API responses.
+//
+// Deprecated: APIError is an alias kept for source compatibility; use
+// errors.GoogleAPIError.
+type APIError = errors.GoogleAPIError
+
+// newAPIError returns the *errors.GoogleAPIError for a non-2xx response,
+// with ErrorCode and ErrorMessage taken from a Google JSON error body.
+func newAPIError(statusCode int, body []byte) *errors.GoogleAPIError {
+	apiErr := &errors.GoogleAPIError{StatusCode: statusCode, Body: string(body)}
+	var googleErr struct {
+		Error struct {
+			Message string `json:"message"`
+			Status  string `json:"status"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &googleErr) == nil {
+		apiErr.ErrorCode = googleErr.Error.Status
+		apiErr.ErrorMessage = googleErr.Error.Message
+	}
+	return apiErr
 }
 
 type GoogleBaseServiceClient struct {
 	httpClient   *http.Client
 	baseEndpoint string
-	logger       *structured.StructuredLogger
+	subject      string
+	logger       structured.Logger
+	getGroup     *singleflight.Group
+	fields       string
+	etags        *etagCache
+
+	decodeOptions DecodeOptions
+	locale        string
+	localized     bool
+	maxRedirects  int
 }
 
 // NewGoogleBaseServiceClient creates a new instance of GoogleBaseServiceClient
-func NewGoogleBaseServiceClient(ctx context.Context, logger *structured.StructuredLogger, targetServiceAccount, userEmail, scopes, baseEndpoint string) (*GoogleBaseServiceClient, error) {
+func NewGoogleBaseServiceClient(ctx context.Context, logger structured.Logger, targetServiceAccount, userEmail, scopes, baseEndpoint string, opts ...ClientOption) (*GoogleBaseServiceClient, error) {
 	httpClient, err := serviceaccount.GenerateGoogleHTTPClient(ctx, logger, &serviceaccount.GoogleIAMServiceClient{}, targetServiceAccount, userEmail, scopes)
 	if err != nil {
         if strings.Contains(err.Error(), "Gaia id not found for email") {
@@ -53,17 +78,32 @@ func NewGoogleBaseServiceClient(ctx context.Context, logger *structured.Structur
         
 		return nil, apiErr
 	}
-	return &GoogleBaseServiceClient{
+	client := &GoogleBaseServiceClient{
 		httpClient:   httpClient,
 		baseEndpoint: baseEndpoint,
+		subject:      userEmail,
 		logger:       logger,
-	}, nil
+	}
+	client.setMaxRedirects(DefaultMaxRedirects)
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client, nil
 }
 
 // makeRequest executes an HTTP GET request to the specified endpoint with given parameters
 func (c *GoogleBaseServiceClient) makeRequest(ctx context.Context, endpoint string, params url.Values) ([]byte, error) {
+	params = c.withFields(params)
 	reqURL := fmt.Sprintf("%s/%s?%s", c.baseEndpoint, endpoint, params.Encode())
 
+	if c.getGroup != nil {
+		return c.coalescedGet(ctx, reqURL)
+	}
+	return c.get(ctx, reqURL)
+}
+
+// get executes an HTTP GET request to reqURL.
+func (c *GoogleBaseServiceClient) get(ctx context.Context, reqURL string) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
@@ -80,18 +120,35 @@ func (c *GoogleBaseServiceClient) makeRequest(ctx context.Context, endpoint stri
 	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
 	req.Header.Set("Content-Type", "application/json")
 
+	key := c.cacheKey(ctx, reqURL)
+	cached, hasCached := c.etags.get(key)
+	if hasCached {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error making API call: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && hasCached {
+		return bytes.Clone(cached.body), nil
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, newAPIError(resp.StatusCode, body)
 	}
 
-	return io.ReadAll(resp.Body)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		c.etags.put(key, etag, body)
+	}
+	return body, nil
 }
 
 // makePostRequest executes an HTTP POST request to the specified endpoint with given headers and body.
@@ -117,7 +174,7 @@ func (c *GoogleBaseServiceClient) makePostRequest(ctx context.Context, endpoint
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, newAPIError(resp.StatusCode, bodyBytes)
 	}
 
 	return io.ReadAll(resp.Body)
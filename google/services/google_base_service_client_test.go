@@ -4,12 +4,14 @@ import (
 
 	"context"
 	"encoding/json"
+	"errors"
 
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"testing"
 
+	apierrors "github.com/duizendstra/go/google/errors"
 	logger "github.com/duizendstra/go/google/logging"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/oauth2"
@@ -110,3 +112,27 @@ func TestMakePostRequest(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "post success", jsonResponse["message"])
 }
+
+func TestAPIErrorType(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":{"code":404,"message":"Resource Not Found: userKey","status":"NOT_FOUND"}}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(ts.URL)
+	_, getErr := client.makeRequest(context.Background(), "users/missing", url.Values{})
+	_, postErr := client.makePostRequest(context.Background(), "users", nil, []byte(`{}`))
+
+	for _, err := range []error{getErr, postErr} {
+		var apiErr *apierrors.GoogleAPIError
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+		assert.Equal(t, "NOT_FOUND", apiErr.ErrorCode)
+		assert.Equal(t, "Resource Not Found: userKey", apiErr.ErrorMessage)
+
+		// The deprecated alias still matches.
+		var legacy *APIError
+		assert.True(t, errors.As(err, &legacy))
+	}
+}
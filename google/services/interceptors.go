@@ -0,0 +1,51 @@
+package googleclient
+
+import (
+	"net/http"
+
+	"golang.org/x/oauth2"
+)
+
+// Interceptor wraps the transport used for API calls, e.g. to add headers,
+// record metrics, or inject faults.
+type Interceptor func(next http.RoundTripper) http.RoundTripper
+
+// ClientOption configures a GoogleBaseServiceClient.
+type ClientOption func(*GoogleBaseServiceClient)
+
+// WithInterceptors installs interceptors on the client. The first interceptor
+// is the outermost one.
+func WithInterceptors(interceptors ...Interceptor) ClientOption {
+	return func(c *GoogleBaseServiceClient) {
+		c.Use(interceptors...)
+	}
+}
+
+// Use installs interceptors on the client. The first interceptor is the
+// outermost one. Interceptors run below the oauth2 transport, so requests
+// they see already carry the Authorization header.
+func (c *GoogleBaseServiceClient) Use(interceptors ...Interceptor) {
+	if oauthTransport, ok := c.httpClient.Transport.(*oauth2.Transport); ok {
+		oauthTransport.Base = chain(oauthTransport.Base, interceptors)
+		return
+	}
+	c.httpClient.Transport = chain(c.httpClient.Transport, interceptors)
+}
+
+// chain wraps base with interceptors so that interceptors[0] runs first.
+func chain(base http.RoundTripper, interceptors []Interceptor) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		base = interceptors[i](base)
+	}
+	return base
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
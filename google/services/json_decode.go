@@ -0,0 +1,124 @@
+package googleclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DecodeOptions controls how DecodeJSON maps API responses onto Go values.
+type DecodeOptions struct {
+	// SnakeCase maps snake_case keys such as "primary_email" onto the Go field
+	// PrimaryEmail (or a field tagged json:"primaryEmail"), for the APIs that
+	// respond in snake_case.
+	SnakeCase bool
+	// DisallowUnknownFields fails with an *UnknownFieldError when the response
+	// contains a field the target does not declare, so upstream schema drift
+	// surfaces as an error instead of silently dropped data.
+	DisallowUnknownFields bool
+}
+
+// UnknownFieldError reports a response field that the target type does not declare.
+type UnknownFieldError struct {
+	Field string
+}
+
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("response contains unknown field %q", e.Field)
+}
+
+// WithDecodeOptions sets the options DecodeResponse uses, including in generated clients.
+func WithDecodeOptions(opts DecodeOptions) ClientOption {
+	return func(c *GoogleBaseServiceClient) {
+		c.decodeOptions = opts
+	}
+}
+
+// DecodeResponse decodes an API response into v with the client's DecodeOptions.
+func (c *GoogleBaseServiceClient) DecodeResponse(data []byte, v any) error {
+	return DecodeJSON(data, v, c.decodeOptions)
+}
+
+// DecodeJSON decodes data into v according to opts.
+func DecodeJSON(data []byte, v any, opts DecodeOptions) error {
+	renamed := map[string]string{}
+	if opts.SnakeCase {
+		// UseNumber keeps numbers as json.Number so int64 IDs beyond 2^53
+		// survive the round trip unchanged.
+		gdec := json.NewDecoder(bytes.NewReader(data))
+		gdec.UseNumber()
+		var generic any
+		if err := gdec.Decode(&generic); err != nil {
+			return err
+		}
+		converted, err := json.Marshal(camelKeys(generic, renamed))
+		if err != nil {
+			return err
+		}
+		data = converted
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if opts.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			if name, uerr := strconv.Unquote(field); uerr == nil {
+				if original, ok := renamed[name]; ok {
+					name = original
+				}
+				return &UnknownFieldError{Field: name}
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// camelKeys rewrites the snake_case object keys of v to lowerCamelCase,
+// recording the original name of each renamed key.
+func camelKeys(v any, renamed map[string]string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, value := range v {
+			camel := SnakeToCamel(key)
+			if camel != key {
+				renamed[camel] = key
+			}
+			out[camel] = camelKeys(value, renamed)
+		}
+		return out
+	case []any:
+		for i, value := range v {
+			v[i] = camelKeys(value, renamed)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// SnakeToCamel converts a snake_case name to lowerCamelCase, e.g.
+// "primary_email" to "primaryEmail". Names without underscores are unchanged.
+func SnakeToCamel(name string) string {
+	if !strings.Contains(name, "_") {
+		return name
+	}
+	parts := strings.Split(name, "_")
+	var b strings.Builder
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		if b.Len() == 0 {
+			b.WriteString(part)
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package googleclient
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type decodeTestUser struct {
+	PrimaryEmail string `json:"primaryEmail"`
+	FullName     string
+	Aliases      []struct {
+		AliasEmail string
+	}
+}
+
+func TestDecodeJSONSnakeCase(t *testing.T) {
+	data := []byte(`{"primary_email": "a@example.com", "full_name": "Alice", "aliases": [{"alias_email": "al@example.com"}]}`)
+
+	var user decodeTestUser
+	err := DecodeJSON(data, &user, DecodeOptions{SnakeCase: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "a@example.com", user.PrimaryEmail)
+	assert.Equal(t, "Alice", user.FullName)
+	if assert.Len(t, user.Aliases, 1) {
+		assert.Equal(t, "al@example.com", user.Aliases[0].AliasEmail)
+	}
+
+	var plain decodeTestUser
+	assert.NoError(t, DecodeJSON(data, &plain, DecodeOptions{}))
+	assert.Empty(t, plain.PrimaryEmail, "snake_case keys do not match without SnakeCase")
+}
+
+func TestDecodeJSONSnakeCaseLargeNumbers(t *testing.T) {
+	var got struct {
+		CustomerID int64   `json:"customerId"`
+		Ratio      float64 `json:"ratio"`
+	}
+	err := DecodeJSON([]byte(`{"customer_id": 9007199254740993, "ratio": 0.25}`), &got, DecodeOptions{SnakeCase: true})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9007199254740993), got.CustomerID, "integers beyond 2^53 keep their precision")
+	assert.Equal(t, 0.25, got.Ratio)
+}
+
+func TestDecodeJSONDisallowUnknownFields(t *testing.T) {
+	var user decodeTestUser
+	err := DecodeJSON([]byte(`{"primaryEmail": "a@example.com", "suspended": true}`), &user, DecodeOptions{DisallowUnknownFields: true})
+
+	var unknown *UnknownFieldError
+	if assert.True(t, errors.As(err, &unknown)) {
+		assert.Equal(t, "suspended", unknown.Field)
+	}
+
+	err = DecodeJSON([]byte(`{"primary_email": "a@example.com", "is_admin": true}`), &user, DecodeOptions{SnakeCase: true, DisallowUnknownFields: true})
+	if assert.True(t, errors.As(err, &unknown)) {
+		assert.Equal(t, "is_admin", unknown.Field, "the original key is reported")
+	}
+
+	assert.NoError(t, DecodeJSON([]byte(`{"primaryEmail": "a@example.com"}`), &user, DecodeOptions{DisallowUnknownFields: true}))
+}
+
+func TestSnakeToCamel(t *testing.T) {
+	assert.Equal(t, "primaryEmail", SnakeToCamel("primary_email"))
+	assert.Equal(t, "nextPageToken", SnakeToCamel("next_page_token"))
+	assert.Equal(t, "kind", SnakeToCamel("kind"))
+	assert.Equal(t, "etag", SnakeToCamel("_etag"))
+}
+
+func TestDecodeResponseUsesClientOptions(t *testing.T) {
+	client := newTestClient("http://example.com")
+	WithDecodeOptions(DecodeOptions{DisallowUnknownFields: true})(client)
+
+	var user decodeTestUser
+	var unknown *UnknownFieldError
+	assert.True(t, errors.As(client.DecodeResponse([]byte(`{"extra": 1}`), &user), &unknown))
+}
@@ -0,0 +1,58 @@
+package googleclient
+
+import (
+	"context"
+	"net/http"
+)
+
+type localeKey struct{}
+
+// WithCallLocale returns a context whose requests ask for responses in
+// locale, a BCP 47 tag such as "nl-NL", overriding the locale of WithLocale.
+// It takes effect on clients created with WithLocale.
+func WithCallLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// WithLocale sends an Accept-Language header on every request, so APIs that
+// localize names, such as Directory org units and Calendar, answer in the
+// user's language. The locale of WithCallLocale takes precedence; an empty
+// defaultLocale only propagates the locale of the context.
+func WithLocale(defaultLocale string) ClientOption {
+	return func(c *GoogleBaseServiceClient) {
+		c.locale = defaultLocale
+		c.localized = true
+		c.Use(c.localeInterceptor())
+	}
+}
+
+func (c *GoogleBaseServiceClient) localeInterceptor() Interceptor {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if locale := c.localeFor(req.Context()); locale != "" && req.Header.Get("Accept-Language") == "" {
+				req = req.Clone(req.Context())
+				req.Header.Set("Accept-Language", locale)
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// localeFor returns the locale requested for ctx, empty without WithLocale.
+func (c *GoogleBaseServiceClient) localeFor(ctx context.Context) string {
+	if !c.localized {
+		return ""
+	}
+	if locale, ok := ctx.Value(localeKey{}).(string); ok && locale != "" {
+		return locale
+	}
+	return c.locale
+}
+
+// cacheKey keys coalesced and cached GET responses, which differ per locale.
+func (c *GoogleBaseServiceClient) cacheKey(ctx context.Context, reqURL string) string {
+	if locale := c.localeFor(ctx); locale != "" {
+		return reqURL + "\x00" + locale
+	}
+	return reqURL
+}
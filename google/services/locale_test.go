@@ -0,0 +1,45 @@
+package googleclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLocale(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"name":"` + r.Header.Get("Accept-Language") + `"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(ts.URL)
+	WithLocale("en-US")(client)
+	WithETagCache(10)(client)
+
+	body, err := client.makeRequest(context.Background(), "orgunits", url.Values{})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name":"en-US"}`, string(body))
+
+	body, err = client.makeRequest(WithCallLocale(context.Background(), "nl-NL"), "orgunits", url.Values{})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name":"nl-NL"}`, string(body), "the call locale overrides the default and is cached separately")
+}
+
+func TestWithoutLocale(t *testing.T) {
+	var header string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("Accept-Language")
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(ts.URL)
+	_, err := client.makeRequest(WithCallLocale(context.Background(), "nl-NL"), "orgunits", url.Values{})
+	assert.NoError(t, err)
+	assert.Empty(t, header, "the context locale is only propagated with WithLocale")
+}
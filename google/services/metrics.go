@@ -0,0 +1,154 @@
+package googleclient
+
+import (
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RequestMetrics describes one API call for per-endpoint dashboards.
+type RequestMetrics struct {
+	// Endpoint is the templated path relative to the base endpoint, e.g.
+	// "users/{userKey}", never the raw URL with IDs.
+	Endpoint string
+	Method   string
+	// Status is the response status code, 0 when no response was received.
+	Status int
+	// RequestBytes and ResponseBytes are the body sizes sent and received.
+	RequestBytes  int64
+	ResponseBytes int64
+	// Latency runs from sending the request until the response body is
+	// read to the end or closed.
+	Latency time.Duration
+}
+
+// Metrics records the requests of a client, typically into latency and size
+// histograms labelled by endpoint and method. Implementations must be safe
+// for concurrent use.
+type Metrics interface {
+	ObserveRequest(m RequestMetrics)
+}
+
+// MetricsFunc adapts a function to Metrics.
+type MetricsFunc func(m RequestMetrics)
+
+// ObserveRequest calls f.
+func (f MetricsFunc) ObserveRequest(m RequestMetrics) {
+	f(m)
+}
+
+// WithMetrics reports every request of the client to m. The endpoint label is
+// the first of the path templates, such as "users/{userKey}" from an
+// EndpointSpec, that matches the request path. Paths matching no template are
+// normalized by replacing segments that look like IDs (containing digits or
+// "@") with "{id}".
+func WithMetrics(m Metrics, templates ...string) ClientOption {
+	return func(c *GoogleBaseServiceClient) {
+		c.Use(c.metricsInterceptor(m, newEndpointLabeler(templates)))
+	}
+}
+
+func (c *GoogleBaseServiceClient) metricsInterceptor(m Metrics, labeler *endpointLabeler) Interceptor {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			observation := RequestMetrics{
+				Endpoint: labeler.label(c.relativePath(req)),
+				Method:   req.Method,
+			}
+			if req.ContentLength > 0 {
+				observation.RequestBytes = req.ContentLength
+			}
+
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				observation.Latency = time.Since(start)
+				m.ObserveRequest(observation)
+				return resp, err
+			}
+			observation.Status = resp.StatusCode
+			resp.Body = &countingBody{ReadCloser: resp.Body, done: func(n int64) {
+				observation.ResponseBytes = n
+				observation.Latency = time.Since(start)
+				m.ObserveRequest(observation)
+			}}
+			return resp, nil
+		})
+	}
+}
+
+// relativePath returns the request path relative to the client's base endpoint.
+func (c *GoogleBaseServiceClient) relativePath(req *http.Request) string {
+	path := req.URL.Path
+	if base, err := req.URL.Parse(c.baseEndpoint); err == nil {
+		path = strings.TrimPrefix(path, strings.TrimSuffix(base.Path, "/"))
+	}
+	return strings.Trim(path, "/")
+}
+
+// countingBody counts the bytes read from a response body and calls done
+// once, at EOF or on Close.
+type countingBody struct {
+	io.ReadCloser
+	n    int64
+	once sync.Once
+	done func(n int64)
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if err == io.EOF {
+		b.once.Do(func() { b.done(b.n) })
+	}
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.done(b.n) })
+	return err
+}
+
+// endpointLabeler maps request paths to templated endpoint labels.
+type endpointLabeler struct {
+	templates []string
+	patterns  []*regexp.Regexp
+}
+
+func newEndpointLabeler(templates []string) *endpointLabeler {
+	l := &endpointLabeler{}
+	for _, t := range templates {
+		t = strings.Trim(t, "/")
+		pattern := reQuotedPathParam.ReplaceAllString(regexp.QuoteMeta(t), `[^/]+`)
+		l.templates = append(l.templates, t)
+		l.patterns = append(l.patterns, regexp.MustCompile("^"+pattern+"$"))
+	}
+	return l
+}
+
+var (
+	// reQuotedPathParam matches a {name} segment escaped by regexp.QuoteMeta.
+	reQuotedPathParam = regexp.MustCompile(`\\\{\w+\\\}`)
+	reAPIVersion      = regexp.MustCompile(`^v\d+((alpha|beta)\d*)?$`)
+)
+
+// label returns the first matching template, or path with ID-like segments
+// replaced by "{id}".
+func (l *endpointLabeler) label(path string) string {
+	for i, p := range l.patterns {
+		if p.MatchString(path) {
+			return l.templates[i]
+		}
+	}
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if !reAPIVersion.MatchString(s) && strings.ContainsAny(s, "0123456789@") {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
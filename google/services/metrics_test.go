@@ -0,0 +1,53 @@
+package googleclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMetrics(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"u-1"}`))
+	}))
+	defer ts.Close()
+
+	var mu sync.Mutex
+	var observed []RequestMetrics
+	client := newTestClient(ts.URL + "/admin/directory/v1")
+	WithMetrics(MetricsFunc(func(m RequestMetrics) {
+		mu.Lock()
+		defer mu.Unlock()
+		observed = append(observed, m)
+	}), "users/{userKey}")(client)
+
+	_, err := client.makeRequest(context.Background(), "users/alice@example.com", url.Values{})
+	assert.NoError(t, err)
+	_, err = client.makePostRequest(context.Background(), "groups/03x8tuzt1ab2cd3/members", nil, []byte(`{"email":"bob@example.com"}`))
+	assert.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if assert.Len(t, observed, 2) {
+		assert.Equal(t, "users/{userKey}", observed[0].Endpoint)
+		assert.Equal(t, "GET", observed[0].Method)
+		assert.Equal(t, http.StatusOK, observed[0].Status)
+		assert.Equal(t, int64(12), observed[0].ResponseBytes)
+		assert.Positive(t, observed[0].Latency)
+
+		assert.Equal(t, "groups/{id}/members", observed[1].Endpoint)
+		assert.Equal(t, int64(27), observed[1].RequestBytes)
+	}
+}
+
+func TestEndpointLabel(t *testing.T) {
+	labeler := newEndpointLabeler([]string{"/users/{userId}/messages/{id}"})
+	assert.Equal(t, "users/{userId}/messages/{id}", labeler.label("users/me/messages/18c2f3a"))
+	assert.Equal(t, "v1/users/{id}/drafts", labeler.label("v1/users/bob@example.com/drafts"))
+	assert.Equal(t, "customers/my_customer/domains", labeler.label("customers/my_customer/domains"))
+}
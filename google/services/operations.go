@@ -0,0 +1,121 @@
+package googleclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/duizendstra/go/google/retry"
+)
+
+// Default polling intervals of Operations.
+const (
+	DefaultPollInterval    = time.Second
+	DefaultMaxPollInterval = 30 * time.Second
+)
+
+// Operation is a Google long-running operation.
+type Operation struct {
+	Name     string          `json:"name"`
+	Done     bool            `json:"done"`
+	Error    *OperationError `json:"error,omitempty"`
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// OperationError is the google.rpc.Status of a failed operation.
+type OperationError struct {
+	// Code is the google.rpc.Code, e.g. 5 for NOT_FOUND.
+	Code    int               `json:"code"`
+	Message string            `json:"message"`
+	Details []json.RawMessage `json:"details,omitempty"`
+}
+
+func (e *OperationError) Error() string {
+	return fmt.Sprintf("operation failed with code %d: %s", e.Code, e.Message)
+}
+
+// PollOptions controls how Operations polls.
+type PollOptions struct {
+	// Interval is the delay before the second poll; Wait polls once right
+	// away. Defaults to DefaultPollInterval.
+	Interval time.Duration
+	// MaxInterval caps the delay between polls, which doubles after each poll.
+	// Defaults to DefaultMaxPollInterval.
+	MaxInterval time.Duration
+	// Timeout bounds the total wait, in addition to the context deadline.
+	Timeout time.Duration
+}
+
+// Operations polls the long-running operations returned by an API, such as
+// the Admin SDK data transfers.
+type Operations struct {
+	client *GoogleBaseServiceClient
+	opts   PollOptions
+}
+
+// NewOperations returns an Operations helper that reads operations through client.
+func NewOperations(client *GoogleBaseServiceClient, opts PollOptions) *Operations {
+	return &Operations{client: client, opts: opts}
+}
+
+// Get reads the operation at path, relative to the client's base endpoint,
+// e.g. "operations/abc123".
+func (o *Operations) Get(ctx context.Context, path string) (*Operation, error) {
+	data, err := o.client.makeRequest(ctx, path, url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	var op Operation
+	if err := json.Unmarshal(data, &op); err != nil {
+		return nil, fmt.Errorf("error decoding operation %s: %w", path, err)
+	}
+	return &op, nil
+}
+
+// Wait polls the operation at path with exponential backoff until it is done,
+// then decodes its response into result, which may be nil. A failed
+// operation returns its *OperationError.
+func (o *Operations) Wait(ctx context.Context, path string, result any) (*Operation, error) {
+	if o.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.opts.Timeout)
+		defer cancel()
+	}
+	interval, maxInterval := o.opts.Interval, o.opts.MaxInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	if maxInterval <= 0 {
+		maxInterval = DefaultMaxPollInterval
+	}
+	backoff := retry.Policy{InitialBackoff: interval, MaxBackoff: maxInterval}
+
+	for attempt := 1; ; attempt++ {
+		op, err := o.Get(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		if op.Done {
+			if op.Error != nil {
+				return op, op.Error
+			}
+			if result != nil && len(op.Response) > 0 {
+				if err := o.client.DecodeResponse(op.Response, result); err != nil {
+					return op, fmt.Errorf("error decoding response of operation %s: %w", op.Name, err)
+				}
+			}
+			return op, nil
+		}
+
+		timer := time.NewTimer(backoff.Backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return op, fmt.Errorf("waiting for operation %s: %w", op.Name, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
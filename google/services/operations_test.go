@@ -0,0 +1,62 @@
+package googleclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperationsWait(t *testing.T) {
+	polls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/operations/op1", r.URL.Path)
+		polls++
+		if polls < 3 {
+			w.Write([]byte(`{"name":"operations/op1","done":false}`))
+			return
+		}
+		w.Write([]byte(`{"name":"operations/op1","done":true,"response":{"id":"t-1","status":"completed"}}`))
+	}))
+	defer ts.Close()
+
+	ops := NewOperations(newTestClient(ts.URL), PollOptions{Interval: time.Millisecond})
+	var result struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+	op, err := ops.Wait(context.Background(), "operations/op1", &result)
+	assert.NoError(t, err)
+	assert.True(t, op.Done)
+	assert.Equal(t, 3, polls)
+	assert.Equal(t, "t-1", result.ID)
+	assert.Equal(t, "completed", result.Status)
+}
+
+func TestOperationsWaitFailed(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"operations/op1","done":true,"error":{"code":7,"message":"caller lacks permission"}}`))
+	}))
+	defer ts.Close()
+
+	ops := NewOperations(newTestClient(ts.URL), PollOptions{})
+	_, err := ops.Wait(context.Background(), "operations/op1", nil)
+	var opErr *OperationError
+	assert.ErrorAs(t, err, &opErr)
+	assert.Equal(t, 7, opErr.Code)
+	assert.Equal(t, "caller lacks permission", opErr.Message)
+}
+
+func TestOperationsWaitTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"operations/op1","done":false}`))
+	}))
+	defer ts.Close()
+
+	ops := NewOperations(newTestClient(ts.URL), PollOptions{Interval: time.Millisecond, Timeout: 20 * time.Millisecond})
+	_, err := ops.Wait(context.Background(), "operations/op1", nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,97 @@
+package googleclient
+
+import (
+	"bytes"
+	"container/list"
+	"net/url"
+	"sync"
+)
+
+// WithFields requests a partial response on every GET by setting the fields
+// parameter, e.g. "users(primaryEmail,name/fullName),nextPageToken", unless
+// the call sets fields itself. This cuts payload sizes of large list calls
+// without changes to callers.
+func WithFields(fields string) ClientOption {
+	return func(c *GoogleBaseServiceClient) {
+		c.fields = fields
+	}
+}
+
+// WithETagCache keeps the ETag and body of up to maxEntries GET responses and
+// revalidates them with If-None-Match, so unchanged resources are answered
+// with 304 Not Modified instead of a full payload.
+func WithETagCache(maxEntries int) ClientOption {
+	return func(c *GoogleBaseServiceClient) {
+		c.etags = newETagCache(maxEntries)
+	}
+}
+
+// withFields adds the client's default fields parameter to params.
+func (c *GoogleBaseServiceClient) withFields(params url.Values) url.Values {
+	if c.fields == "" || params.Get("fields") != "" {
+		return params
+	}
+	withFields := url.Values{}
+	for key, values := range params {
+		withFields[key] = values
+	}
+	withFields.Set("fields", c.fields)
+	return withFields
+}
+
+// etagEntry is a cached response body with its ETag.
+type etagEntry struct {
+	url  string
+	etag string
+	body []byte
+}
+
+// etagCache is a size-bounded LRU cache of GET responses keyed by URL.
+// A nil *etagCache caches nothing.
+type etagCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	entries    map[string]*list.Element
+}
+
+func newETagCache(maxEntries int) *etagCache {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &etagCache{maxEntries: maxEntries, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (c *etagCache) get(url string) (etagEntry, bool) {
+	if c == nil {
+		return etagEntry{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[url]
+	if !ok {
+		return etagEntry{}, false
+	}
+	c.order.MoveToFront(elem)
+	return *elem.Value.(*etagEntry), true
+}
+
+func (c *etagCache) put(url, etag string, body []byte) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &etagEntry{url: url, etag: etag, body: bytes.Clone(body)}
+	if elem, ok := c.entries[url]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[url] = c.order.PushFront(entry)
+	if c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*etagEntry).url)
+	}
+}
@@ -0,0 +1,74 @@
+package googleclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithFields(t *testing.T) {
+	var gotFields []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotFields = append(gotFields, r.URL.Query().Get("fields"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	client := newTestClient(ts.URL)
+	WithFields("users(primaryEmail),nextPageToken")(client)
+
+	_, err := client.makeRequest(context.Background(), "users", nil)
+	assert.NoError(t, err)
+
+	// An explicit fields parameter wins over the client default
+	params := url.Values{"fields": {"kind"}}
+	_, err = client.makeRequest(context.Background(), "users", params)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"users(primaryEmail),nextPageToken", "kind"}, gotFields)
+}
+
+func TestWithETagCache(t *testing.T) {
+	var ifNoneMatch []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ifNoneMatch = append(ifNoneMatch, r.Header.Get("If-None-Match"))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "success"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(ts.URL)
+	WithETagCache(10)(client)
+
+	for i := 0; i < 2; i++ {
+		body, err := client.makeRequest(context.Background(), "users/123", url.Values{})
+		assert.NoError(t, err)
+		assert.Equal(t, `{"message": "success"}`, string(body))
+	}
+
+	assert.Equal(t, []string{"", `"v1"`}, ifNoneMatch)
+}
+
+func TestETagCacheEviction(t *testing.T) {
+	cache := newETagCache(2)
+	cache.put("a", "1", []byte("a"))
+	cache.put("b", "1", []byte("b"))
+	cache.get("a")
+	cache.put("c", "1", []byte("c"))
+
+	_, okA := cache.get("a")
+	_, okB := cache.get("b")
+	_, okC := cache.get("c")
+	assert.True(t, okA)
+	assert.False(t, okB, "least recently used entry should be evicted")
+	assert.True(t, okC)
+}
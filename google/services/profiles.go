@@ -0,0 +1,200 @@
+package googleclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/duizendstra/go/google/logging"
+	"github.com/duizendstra/go/google/retry"
+	"golang.org/x/time/rate"
+)
+
+// Profile holds the org-wide defaults for one API, e.g. "admin-sdk" or "gmail".
+type Profile struct {
+	// BaseURL is the base endpoint of the API.
+	BaseURL string
+	// Scopes is the space-separated list of scopes the API requires.
+	Scopes string
+	// Timeout bounds each attempt of a request. Zero means no timeout.
+	Timeout time.Duration
+	// Retry retries transport errors and retryable statuses (408, 429, 5xx
+	// gateway errors), honouring Retry-After. Nil disables retries.
+	Retry *retry.Policy
+	// RateLimit is the number of requests per second the client may send.
+	// Zero means no limit.
+	RateLimit float64
+	// RateBurst is the number of requests allowed at once. Defaults to 1.
+	RateBurst int
+}
+
+var profiles = struct {
+	sync.RWMutex
+	m map[string]Profile
+}{m: make(map[string]Profile)}
+
+// RegisterProfile registers or replaces the profile with the given name.
+func RegisterProfile(name string, profile Profile) {
+	profiles.Lock()
+	defer profiles.Unlock()
+	profiles.m[name] = profile
+}
+
+// LookupProfile returns the profile registered under name.
+func LookupProfile(name string) (Profile, bool) {
+	profiles.RLock()
+	defer profiles.RUnlock()
+	profile, ok := profiles.m[name]
+	return profile, ok
+}
+
+// ProfileNames returns the names of the registered profiles, sorted.
+func ProfileNames() []string {
+	profiles.RLock()
+	defer profiles.RUnlock()
+	names := make([]string, 0, len(profiles.m))
+	for name := range profiles.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewClientFromProfile creates a client with the base URL, scopes, timeout,
+// retry policy, and rate limit of the named profile. opts are applied after
+// the profile's own options.
+func NewClientFromProfile(ctx context.Context, logger structured.Logger, name, targetServiceAccount, userEmail string, opts ...ClientOption) (*GoogleBaseServiceClient, error) {
+	profile, ok := LookupProfile(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown endpoint profile %q", name)
+	}
+	return NewGoogleBaseServiceClient(ctx, logger, targetServiceAccount, userEmail, profile.Scopes, profile.BaseURL,
+		append([]ClientOption{WithInterceptors(profile.Interceptors()...)}, opts...)...)
+}
+
+// Interceptors returns the interceptors that apply the profile: retries
+// outermost, then rate limiting and the timeout, per attempt.
+func (p Profile) Interceptors() []Interceptor {
+	var interceptors []Interceptor
+	if p.Retry != nil {
+		interceptors = append(interceptors, retryInterceptor(*p.Retry))
+	}
+	if p.RateLimit > 0 {
+		burst := p.RateBurst
+		if burst <= 0 {
+			burst = 1
+		}
+		interceptors = append(interceptors, rateLimitInterceptor(rate.NewLimiter(rate.Limit(p.RateLimit), burst)))
+	}
+	if p.Timeout > 0 {
+		interceptors = append(interceptors, timeoutInterceptor(p.Timeout))
+	}
+	return interceptors
+}
+
+// retryableStatusError signals a response with a retryable status to retry.Do.
+type retryableStatusError struct {
+	status int
+}
+
+func (e *retryableStatusError) Error() string {
+	return fmt.Sprintf("retryable status %d", e.status)
+}
+
+// retryInterceptor retries requests under policy. When the attempts run out
+// on a retryable status, or the request body cannot be replayed, the last
+// response is returned as is.
+func retryInterceptor(policy retry.Policy) Interceptor {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			var last *http.Response
+			attempt := 0
+			err := retry.Do(req.Context(), policy, func(ctx context.Context) error {
+				attempt++
+				attemptReq := req
+				if attempt > 1 {
+					// Check the body can be replayed before discarding
+					// last, which is returned unchanged when it cannot.
+					if req.Body != nil && req.Body != http.NoBody {
+						if req.GetBody == nil {
+							return retry.MarkPermanent(fmt.Errorf("request body cannot be replayed"))
+						}
+						body, err := req.GetBody()
+						if err != nil {
+							return retry.MarkPermanent(err)
+						}
+						attemptReq = req.Clone(ctx)
+						attemptReq.Body = body
+					}
+					if last != nil {
+						io.Copy(io.Discard, last.Body)
+						last.Body.Close()
+						last = nil
+					}
+				}
+
+				resp, err := next.RoundTrip(attemptReq)
+				if err != nil {
+					return err
+				}
+				last = resp
+				if !retry.RetryableStatus(resp.StatusCode) {
+					return nil
+				}
+				statusErr := error(&retryableStatusError{status: resp.StatusCode})
+				if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+					statusErr = retry.WithRetryAfter(statusErr, time.Duration(seconds)*time.Second)
+				}
+				return statusErr
+			})
+			if last != nil {
+				return last, nil
+			}
+			return nil, err
+		})
+	}
+}
+
+// rateLimitInterceptor waits for limiter before each request.
+func rateLimitInterceptor(limiter *rate.Limiter) Interceptor {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if err := limiter.Wait(req.Context()); err != nil {
+				return nil, fmt.Errorf("waiting for rate limit: %w", err)
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// timeoutInterceptor bounds each request, including reading the response body, by timeout.
+func timeoutInterceptor(timeout time.Duration) Interceptor {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			ctx, cancel := context.WithTimeout(req.Context(), timeout)
+			resp, err := next.RoundTrip(req.WithContext(ctx))
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		})
+	}
+}
+
+// cancelOnClose releases a request context once the response body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
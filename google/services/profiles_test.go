@@ -0,0 +1,111 @@
+package googleclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/duizendstra/go/google/retry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterProfile(t *testing.T) {
+	RegisterProfile("test-gmail", Profile{BaseURL: "https://gmail.googleapis.com/gmail/v1", Timeout: time.Second})
+
+	profile, ok := LookupProfile("test-gmail")
+	assert.True(t, ok)
+	assert.Equal(t, "https://gmail.googleapis.com/gmail/v1", profile.BaseURL)
+	assert.Contains(t, ProfileNames(), "test-gmail")
+
+	_, err := NewClientFromProfile(context.Background(), nil, "missing", "sa@example.com", "user@example.com")
+	assert.ErrorContains(t, err, `unknown endpoint profile "missing"`)
+}
+
+func TestProfileRetry(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"message": "success"}`))
+	}))
+	defer ts.Close()
+
+	profile := Profile{Retry: &retry.Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond}}
+	client := newTestClient(ts.URL)
+	client.Use(profile.Interceptors()...)
+
+	body, err := client.makeRequest(context.Background(), "users", url.Values{})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"message": "success"}`, string(body))
+	assert.Equal(t, int32(3), calls.Load())
+
+	calls.Store(-10)
+	_, err = client.makePostRequest(context.Background(), "users", nil, []byte(`{"name": "a"}`))
+	assert.ErrorContains(t, err, "status 503", "the last response is returned once attempts run out")
+	assert.Equal(t, int32(-7), calls.Load())
+}
+
+func TestProfileRetryWithoutGetBody(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error": "unavailable"}`))
+	}))
+	defer ts.Close()
+
+	transport := retryInterceptor(retry.Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond})(http.DefaultTransport)
+	req, _ := http.NewRequest(http.MethodPost, ts.URL, io.NopCloser(strings.NewReader(`{"name": "a"}`)))
+	resp, err := transport.RoundTrip(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, `{"error": "unavailable"}`, string(body), "the response is returned unread when the body cannot be replayed")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestProfileTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer ts.Close()
+
+	client := newTestClient(ts.URL)
+	client.Use(Profile{Timeout: 20 * time.Millisecond}.Interceptors()...)
+
+	start := time.Now()
+	_, err := client.makeRequest(context.Background(), "users", url.Values{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestProfileRateLimit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(ts.URL)
+	client.Use(Profile{RateLimit: 20}.Interceptors()...)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := client.makeRequest(context.Background(), "users", url.Values{})
+		assert.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+}
@@ -0,0 +1,110 @@
+package googleclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultMaxRedirects is the number of redirects a client follows before
+// failing with a RedirectLoopError.
+const DefaultMaxRedirects = 10
+
+// RedirectLoopError is returned when a request is redirected to a URL it
+// already visited or more often than the configured maximum.
+type RedirectLoopError struct {
+	// URL is the redirect target that was refused.
+	URL string
+	// Via lists the URLs requested before it, oldest first.
+	Via []string
+}
+
+func (e *RedirectLoopError) Error() string {
+	return fmt.Sprintf("redirect loop at %s after %d redirects", e.URL, len(e.Via))
+}
+
+// WithMaxRedirects sets the number of redirects the client follows; zero or
+// less stops at the first redirect and returns it as an API error. Clients
+// created with NewGoogleBaseServiceClient follow DefaultMaxRedirects.
+func WithMaxRedirects(n int) ClientOption {
+	return func(c *GoogleBaseServiceClient) {
+		c.setMaxRedirects(n)
+	}
+}
+
+// setMaxRedirects installs the redirect policy of the client. Redirects to
+// the same scheme and host keep the Authorization header, which the oauth2
+// transport signs again with a current token; redirects to another host
+// never carry it. 307 and 308 redirects replay the request body, while a 308
+// without a Location, such as the "resume incomplete" answer of a resumable
+// upload, is returned to the caller.
+func (c *GoogleBaseServiceClient) setMaxRedirects(n int) {
+	if c.httpClient.CheckRedirect == nil {
+		c.Use(stripCrossHostAuth)
+	}
+	c.maxRedirects = n
+	c.httpClient.CheckRedirect = c.checkRedirect
+}
+
+// checkRedirect is the CheckRedirect function of the client.
+func (c *GoogleBaseServiceClient) checkRedirect(req *http.Request, via []*http.Request) error {
+	if c.maxRedirects <= 0 {
+		return http.ErrUseLastResponse
+	}
+
+	visited := make([]string, len(via))
+	loop := len(via) > c.maxRedirects
+	for i, prev := range via {
+		visited[i] = prev.URL.String()
+		if prev.Method == req.Method && sameURL(prev.URL, req.URL) {
+			loop = true
+		}
+	}
+	if loop {
+		return &RedirectLoopError{URL: req.URL.String(), Via: visited}
+	}
+
+	if sameHost(req.URL, via[0].URL) {
+		if auth := via[0].Header.Get("Authorization"); auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+	} else {
+		req.Header.Del("Authorization")
+	}
+	return nil
+}
+
+// stripCrossHostAuth removes the Authorization header the oauth2 transport
+// adds to a redirect that left the host of the original request.
+func stripCrossHostAuth(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Response == nil || req.Header.Get("Authorization") == "" {
+			return next.RoundTrip(req)
+		}
+		first := req
+		for first.Response != nil && first.Response.Request != nil {
+			first = first.Response.Request
+		}
+		if !sameHost(req.URL, first.URL) {
+			req = req.Clone(req.Context())
+			req.Header.Del("Authorization")
+		}
+		return next.RoundTrip(req)
+	})
+}
+
+// sameURL reports whether a and b address the same resource, ignoring an
+// empty query and the fragment.
+func sameURL(a, b *url.URL) bool {
+	x, y := *a, *b
+	x.ForceQuery, y.ForceQuery = false, false
+	x.Fragment, x.RawFragment = "", ""
+	y.Fragment, y.RawFragment = "", ""
+	return x.String() == y.String()
+}
+
+// sameHost reports whether a and b have the same scheme and host.
+func sameHost(a, b *url.URL) bool {
+	return strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(a.Host, b.Host)
+}
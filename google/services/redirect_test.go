@@ -0,0 +1,127 @@
+package googleclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apierrors "github.com/duizendstra/go/google/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirectSameHostKeepsAuthorization(t *testing.T) {
+	var auth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusFound)
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(ts.URL)
+	client.setMaxRedirects(DefaultMaxRedirects)
+
+	_, err := client.makeRequest(context.Background(), "old", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer mocked_access_token", auth)
+}
+
+func TestRedirectCrossHostStripsAuthorization(t *testing.T) {
+	auth := "unset"
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(`{}`))
+	}))
+	defer other.Close()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL+"/download", http.StatusFound)
+	}))
+	defer ts.Close()
+
+	client := newTestClient(ts.URL)
+	client.setMaxRedirects(DefaultMaxRedirects)
+
+	_, err := client.makeRequest(context.Background(), "export", nil)
+	assert.NoError(t, err)
+	assert.Empty(t, auth)
+}
+
+func TestRedirect308ReplaysBody(t *testing.T) {
+	var body, auth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusPermanentRedirect)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		body, auth = string(b), r.Header.Get("Authorization")
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(ts.URL)
+	client.setMaxRedirects(DefaultMaxRedirects)
+
+	_, err := client.makePostRequest(context.Background(), "old", nil, []byte(`{"name":"test"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"test"}`, body)
+	assert.Equal(t, "Bearer mocked_access_token", auth)
+}
+
+func TestRedirectLoop(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/a" {
+			http.Redirect(w, r, "/b", http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, "/a", http.StatusFound)
+	}))
+	defer ts.Close()
+
+	client := newTestClient(ts.URL)
+	client.setMaxRedirects(DefaultMaxRedirects)
+
+	_, err := client.makeRequest(context.Background(), "a", nil)
+	var loopErr *RedirectLoopError
+	assert.True(t, errors.As(err, &loopErr))
+	assert.Equal(t, ts.URL+"/a", loopErr.URL)
+	assert.Equal(t, []string{ts.URL + "/a?", ts.URL + "/b"}, loopErr.Via)
+}
+
+func TestRedirectLimit(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Redirect(w, r, r.URL.Path+"x", http.StatusFound)
+	}))
+	defer ts.Close()
+
+	client := newTestClient(ts.URL)
+	client.setMaxRedirects(2)
+
+	_, err := client.makeRequest(context.Background(), "a", nil)
+	var loopErr *RedirectLoopError
+	assert.True(t, errors.As(err, &loopErr))
+	assert.Equal(t, 3, calls)
+}
+
+func TestRedirectDisabled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusFound)
+	}))
+	defer ts.Close()
+
+	client := newTestClient(ts.URL)
+	WithMaxRedirects(0)(client)
+
+	_, err := client.makeRequest(context.Background(), "old", nil)
+	var apiErr *apierrors.GoogleAPIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusFound, apiErr.StatusCode)
+}
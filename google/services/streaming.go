@@ -0,0 +1,182 @@
+package googleclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxStreamLine caps the size of a single line of a streamed response.
+const maxStreamLine = 4 << 20
+
+// ErrStopStream can be returned by a stream consumer to stop reading without error.
+var ErrStopStream = errors.New("stop stream")
+
+// StreamRequest describes a request whose response is consumed incrementally.
+type StreamRequest struct {
+	// Method defaults to GET.
+	Method string
+	// Endpoint is relative to the base endpoint of the client.
+	Endpoint string
+	Params   url.Values
+	Headers  map[string]string
+	Body     []byte
+}
+
+// StreamEvent is one message of a streamed response. For server-sent events
+// all fields are set from the event stream; for other chunked responses each
+// non-empty line is an event with only Data set.
+type StreamEvent struct {
+	ID    string
+	Event string
+	Data  []byte
+	Retry time.Duration
+}
+
+// Stream sends req and calls fn for every event of the response as it
+// arrives, for endpoints that return server-sent events (text/event-stream)
+// or newline-delimited chunks such as NDJSON. It returns when the stream
+// ends, ctx is done, or fn returns an error; returning ErrStopStream stops
+// the stream without error.
+func (c *GoogleBaseServiceClient) Stream(ctx context.Context, req StreamRequest, fn func(StreamEvent) error) error {
+	method := req.Method
+	if method == "" {
+		method = "GET"
+	}
+	reqURL := fmt.Sprintf("%s/%s", c.baseEndpoint, req.Endpoint)
+	if len(req.Params) > 0 {
+		reqURL += "?" + req.Params.Encode()
+	}
+
+	var body io.Reader
+	if req.Body != nil {
+		body = bytes.NewReader(req.Body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return fmt.Errorf("error creating stream request: %w", err)
+	}
+	httpReq.Header.Set("Accept", "text/event-stream, application/x-ndjson, application/json")
+	for key, value := range req.Headers {
+		httpReq.Header.Set(key, value)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("error making API call: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return newAPIError(resp.StatusCode, bodyBytes)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLine)
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/event-stream" {
+		err = readEvents(scanner, fn)
+	} else {
+		err = readLines(scanner, fn)
+	}
+	if errors.Is(err, ErrStopStream) {
+		return nil
+	}
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// StreamChan is like Stream but delivers the events on a channel, which is
+// closed when the stream ends. The error channel then receives the result of
+// the stream, nil on success. Cancel ctx to stop consuming early.
+func (c *GoogleBaseServiceClient) StreamChan(ctx context.Context, req StreamRequest) (<-chan StreamEvent, <-chan error) {
+	events := make(chan StreamEvent)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(events)
+		errc <- c.Stream(ctx, req, func(e StreamEvent) error {
+			select {
+			case events <- e:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+	return events, errc
+}
+
+// readEvents parses a server-sent event stream as specified by the WHATWG
+// HTML standard.
+func readEvents(scanner *bufio.Scanner, fn func(StreamEvent) error) error {
+	var event StreamEvent
+	var data [][]byte
+	dispatch := func() error {
+		if data == nil {
+			event = StreamEvent{ID: event.ID}
+			return nil
+		}
+		event.Data = bytes.Join(data, []byte("\n"))
+		err := fn(event)
+		event, data = StreamEvent{ID: event.ID}, nil
+		return err
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if err := dispatch(); err != nil {
+				return err
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // comment, often used as keep-alive
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			data = append(data, []byte(value))
+		case "event":
+			event.Event = value
+		case "id":
+			event.ID = value
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil {
+				event.Retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	if scanner.Err() != nil {
+		return scanner.Err()
+	}
+	return dispatch()
+}
+
+// readLines calls fn for every non-empty line of a chunked response.
+func readLines(scanner *bufio.Scanner, fn func(StreamEvent) error) error {
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(StreamEvent{Data: bytes.Clone(line)}); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
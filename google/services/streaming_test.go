@@ -0,0 +1,79 @@
+package googleclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamServerSentEvents(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		w.Write([]byte(": keep-alive\n\nid: 1\nevent: progress\ndata: {\"done\":10}\n\n"))
+		w.(http.Flusher).Flush()
+		w.Write([]byte("id: 2\nretry: 1500\ndata: line one\ndata: line two\n\n"))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(ts.URL)
+	var events []StreamEvent
+	err := client.Stream(context.Background(), StreamRequest{Method: "POST", Endpoint: "jobs:stream", Body: []byte(`{}`)}, func(e StreamEvent) error {
+		events = append(events, e)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []StreamEvent{
+		{ID: "1", Event: "progress", Data: []byte(`{"done":10}`)},
+		{ID: "2", Data: []byte("line one\nline two"), Retry: 1500 * time.Millisecond},
+	}, events)
+}
+
+func TestStreamLines(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Write([]byte("{\"n\":1}\n\n{\"n\":2}\n{\"n\":3}\n"))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(ts.URL)
+	var lines []string
+	err := client.Stream(context.Background(), StreamRequest{Endpoint: "items"}, func(e StreamEvent) error {
+		lines = append(lines, string(e.Data))
+		if len(lines) == 2 {
+			return ErrStopStream
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`{"n":1}`, `{"n":2}`}, lines)
+}
+
+func TestStreamChan(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("a\nb\n"))
+	}))
+	defer ts.Close()
+
+	events, errc := newTestClient(ts.URL).StreamChan(context.Background(), StreamRequest{Endpoint: "items"})
+	var got []string
+	for e := range events {
+		got = append(got, string(e.Data))
+	}
+	assert.NoError(t, <-errc)
+	assert.Equal(t, []string{"a", "b"}, got)
+}
+
+func TestStreamErrorStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "denied", http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	err := newTestClient(ts.URL).Stream(context.Background(), StreamRequest{Endpoint: "items"}, func(StreamEvent) error { return nil })
+	assert.ErrorContains(t, err, "API request failed with status 403")
+}